@@ -0,0 +1,144 @@
+package jenv
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/oarkflow/jenv/internal/awssig"
+)
+
+var awsHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// ssmStore resolves `${ssm:/path/to/parameter}` from AWS Systems Manager
+// Parameter Store. SecureString parameters are decrypted, and the
+// parameters of a document are fetched together, ten per GetParameters
+// call.
+type ssmStore struct{}
+
+// ssmBatchSize is the most names GetParameters accepts in one call.
+const ssmBatchSize = 10
+
+func (s ssmStore) Get(ctx context.Context, ref string) (string, error) {
+	vals, err := s.GetMany(ctx, []string{ref})
+	if err != nil {
+		return "", err
+	}
+	val, ok := vals[ref]
+	if !ok {
+		return "", fmt.Errorf("ssm parameter '%s': %w", ref, ErrSecretNotFound)
+	}
+	return val, nil
+}
+
+func (ssmStore) GetMany(ctx context.Context, refs []string) (map[string]string, error) {
+	out := make(map[string]string, len(refs))
+	for len(refs) > 0 {
+		n := min(len(refs), ssmBatchSize)
+		var resp struct {
+			Parameters []struct {
+				Name  string `json:"Name"`
+				Value string `json:"Value"`
+			} `json:"Parameters"`
+		}
+		in := map[string]any{"Names": refs[:n], "WithDecryption": true}
+		if err := awsCall(ctx, "ssm", "AmazonSSM.GetParameters", in, &resp); err != nil {
+			return nil, err
+		}
+		for _, p := range resp.Parameters {
+			out[p.Name] = p.Value
+		}
+		refs = refs[n:]
+	}
+	return out, nil
+}
+
+// awsSecretStore resolves `${aws-secret:name}` from AWS Secrets Manager.
+// `${aws-secret:name#key}` reads one key of a secret stored as a JSON
+// object.
+type awsSecretStore struct{}
+
+func (awsSecretStore) Get(ctx context.Context, ref string) (string, error) {
+	name, key, hasKey := strings.Cut(strings.TrimSpace(ref), "#")
+	var resp struct {
+		SecretString string `json:"SecretString"`
+		SecretBinary string `json:"SecretBinary"`
+	}
+	if err := awsCall(ctx, "secretsmanager", "secretsmanager.GetSecretValue", map[string]string{"SecretId": name}, &resp); err != nil {
+		return "", err
+	}
+	val := resp.SecretString
+	if val == "" && resp.SecretBinary != "" {
+		b, err := base64.StdEncoding.DecodeString(resp.SecretBinary)
+		if err != nil {
+			return "", fmt.Errorf("aws secret '%s': %v", name, err)
+		}
+		val = string(b)
+	}
+	if !hasKey {
+		return val, nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(val), &fields); err != nil {
+		return "", fmt.Errorf("aws secret '%s' is not a JSON object", name)
+	}
+	v, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("key '%s' not found in aws secret '%s': %w", key, name, ErrSecretNotFound)
+	}
+	return secretString(v)
+}
+
+// awsCall calls an action of an AWS JSON protocol service, signing it with
+// the credentials awssig discovers, IAM roles included.
+func awsCall(ctx context.Context, service, target string, in, out any) error {
+	creds, err := awssig.LoadCredentials(ctx)
+	if err != nil {
+		return err
+	}
+	region := awssig.Region()
+	endpoint := awssig.Endpoint(service)
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.%s.amazonaws.com", service, region)
+	}
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", target)
+	awssig.Sign(req, body, creds, region, service, time.Now())
+	resp, err := awsHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling %s: %v", service, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	var awsErr struct {
+		Type    string `json:"__type"`
+		Message string `json:"message"`
+		Msg     string `json:"Message"`
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if json.Unmarshal(raw, &awsErr) != nil || awsErr.Type == "" {
+		return fmt.Errorf("%s returned %s: %s", service, resp.Status, strings.TrimSpace(string(raw)))
+	}
+	code := awsErr.Type[strings.LastIndex(awsErr.Type, "#")+1:]
+	msg := awsErr.Message + awsErr.Msg
+	if code == "ResourceNotFoundException" || code == "ParameterNotFound" {
+		return fmt.Errorf("%s: %s: %w", service, msg, ErrSecretNotFound)
+	}
+	return fmt.Errorf("%s returned %s: %s", service, code, msg)
+}
@@ -0,0 +1,88 @@
+package jenv_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestAWSSecretStores(t *testing.T) {
+	var batches [][]string
+	ssm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "AmazonSSM.GetParameters", r.Header.Get("X-Amz-Target"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		var in struct {
+			Names          []string
+			WithDecryption bool
+		}
+		json.NewDecoder(r.Body).Decode(&in)
+		assert.True(t, in.WithDecryption)
+		batches = append(batches, in.Names)
+		var params []map[string]string
+		for _, name := range in.Names {
+			if name != "/app/missing" {
+				params = append(params, map[string]string{"Name": name, "Value": "value" + name})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"Parameters": params})
+	}))
+	defer ssm.Close()
+	sm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		var in struct{ SecretId string }
+		json.NewDecoder(r.Body).Decode(&in)
+		switch in.SecretId {
+		case "prod/db":
+			json.NewEncoder(w).Encode(map[string]string{"SecretString": `{"username": "app", "password": "s3cret"}`})
+		case "prod/token":
+			json.NewEncoder(w).Encode(map[string]string{"SecretString": "tok"})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"__type": "ResourceNotFoundException", "Message": "not found"})
+		}
+	}))
+	defer sm.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ENDPOINT_URL_SSM", ssm.URL)
+	t.Setenv("AWS_ENDPOINT_URL_SECRETSMANAGER", sm.URL)
+
+	var cfg struct {
+		Params   []string `json:"params"`
+		Password string   `json:"password"`
+		Token    string   `json:"token"`
+		Missing  string   `json:"missing"`
+	}
+	doc := `{"params": [`
+	for i := range 12 {
+		if i > 0 {
+			doc += ", "
+		}
+		doc += `"${ssm:/app/p` + string(rune('a'+i)) + `}"`
+	}
+	doc += `], "password": "${aws-secret:prod/db#password}", "token": "${aws-secret:prod/token}", "missing": "${ssm:/app/missing:-none}"}`
+	require.NoError(t, jenv.UnmarshalJSON([]byte(doc), &cfg))
+	assert.Len(t, cfg.Params, 12)
+	assert.Equal(t, "value/app/pa", cfg.Params[0])
+	assert.Equal(t, "s3cret", cfg.Password)
+	assert.Equal(t, "tok", cfg.Token)
+	assert.Equal(t, "none", cfg.Missing)
+	// Two batches, then the parameter they did not return on its own.
+	require.Len(t, batches, 3)
+	assert.Len(t, batches[0], 10)
+	assert.Len(t, batches[1], 3)
+	assert.Equal(t, []string{"/app/missing"}, batches[2])
+
+	err := jenv.UnmarshalJSON([]byte(`{"token": "${aws-secret:prod/missing}"}`), &cfg)
+	assert.ErrorIs(t, err, jenv.ErrSecretNotFound)
+	err = jenv.UnmarshalJSON([]byte(`{"token": "${aws-secret:prod/db#missing}"}`), &cfg)
+	assert.ErrorIs(t, err, jenv.ErrSecretNotFound)
+}
@@ -0,0 +1,118 @@
+package jenv
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a number of bytes written in human-readable form, such as
+// "512MiB" or "10GB", for cache sizes and upload limits. Binary units
+// (KiB, MiB, ..., also written Ki, Mi, ...) are powers of 1024 and decimal
+// units (KB, MB, ..., also K, M, ...) powers of 1000; units are not case
+// sensitive. A bare number is a count of bytes.
+type ByteSize int64
+
+// Byte sizes in binary and decimal units.
+const (
+	Byte ByteSize = 1
+	KiB  ByteSize = 1 << (10 * iota)
+	MiB
+	GiB
+	TiB
+	PiB
+)
+
+const (
+	KB ByteSize = 1000
+	MB          = 1000 * KB
+	GB          = 1000 * MB
+	TB          = 1000 * GB
+	PB          = 1000 * TB
+)
+
+var byteUnits = map[string]ByteSize{
+	"": Byte, "b": Byte,
+	"k": KB, "kb": KB, "ki": KiB, "kib": KiB,
+	"m": MB, "mb": MB, "mi": MiB, "mib": MiB,
+	"g": GB, "gb": GB, "gi": GiB, "gib": GiB,
+	"t": TB, "tb": TB, "ti": TiB, "tib": TiB,
+	"p": PB, "pb": PB, "pi": PiB, "pib": PiB,
+}
+
+// byteUnitNames lists the units String writes, largest first.
+var byteUnitNames = []struct {
+	size ByteSize
+	name string
+}{
+	{PiB, "PiB"}, {TiB, "TiB"}, {GiB, "GiB"}, {MiB, "MiB"}, {KiB, "KiB"},
+	{PB, "PB"}, {TB, "TB"}, {GB, "GB"}, {MB, "MB"}, {KB, "KB"},
+}
+
+func init() {
+	registerConverter(ParseByteSize)
+}
+
+// ParseByteSize parses a size such as "512MiB", "1.5 GB" or "4096".
+func ParseByteSize(s string) (ByteSize, error) {
+	str := strings.TrimSpace(s)
+	i := strings.IndexFunc(str, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != 'e' && r != 'E' && r != '+'
+	})
+	if i < 0 {
+		i = len(str)
+	}
+	num, unit := str[:i], strings.ToLower(strings.TrimSpace(str[i:]))
+	// The number may carry an exponent, as JSON numbers are printed, but a
+	// trailing "e" is the start of an exabyte unit, which is not supported.
+	if strings.HasSuffix(num, "e") || strings.HasSuffix(num, "E") {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	scale, ok := byteUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid byte size %q: unknown unit %q", s, strings.TrimSpace(str[i:]))
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	size := f * float64(scale)
+	if size >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid byte size %q: too large", s)
+	}
+	return ByteSize(math.Round(size)), nil
+}
+
+// String writes the size in the largest unit it is a whole multiple of,
+// preferring binary units, so that it parses back to the same size.
+func (b ByteSize) String() string {
+	if b != 0 {
+		for _, u := range byteUnitNames {
+			if b%u.size == 0 {
+				return strconv.FormatInt(int64(b/u.size), 10) + u.name
+			}
+		}
+	}
+	return strconv.FormatInt(int64(b), 10) + "B"
+}
+
+// Int64 returns the size in bytes.
+func (b ByteSize) Int64() int64 {
+	return int64(b)
+}
+
+// MarshalText returns the size in human-readable form.
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText parses a human-readable size.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	parsed, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = parsed
+	return nil
+}
@@ -0,0 +1,48 @@
+package jenv_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestByteSize(t *testing.T) {
+	t.Setenv("BYTESIZE_CACHE", "512MiB")
+	type limits struct {
+		Cache  jenv.ByteSize   `json:"cache"`
+		Upload jenv.ByteSize   `json:"upload"`
+		Buffer jenv.ByteSize   `json:"buffer"`
+		Page   *jenv.ByteSize  `json:"page"`
+		Tiers  []jenv.ByteSize `json:"tiers"`
+	}
+	var cfg limits
+	require.NoError(t, jenv.UnmarshalJSON([]byte(`{
+		"cache": "${BYTESIZE_CACHE}",
+		"upload": "1.5 GB",
+		"buffer": 1073741824,
+		"page": "4ki",
+		"tiers": ["1k", "2Mi"]
+	}`), &cfg))
+	assert.Equal(t, 512*jenv.MiB, cfg.Cache)
+	assert.Equal(t, 1500*jenv.MB, cfg.Upload)
+	assert.Equal(t, jenv.GiB, cfg.Buffer)
+	require.NotNil(t, cfg.Page)
+	assert.Equal(t, 4*jenv.KiB, *cfg.Page)
+	assert.Equal(t, []jenv.ByteSize{jenv.KB, 2 * jenv.MiB}, cfg.Tiers)
+
+	assert.Equal(t, "512MiB", cfg.Cache.String())
+	assert.Equal(t, "1500MB", cfg.Upload.String())
+	assert.Equal(t, "1023B", jenv.ByteSize(1023).String())
+	out, err := json.Marshal(cfg.Tiers)
+	require.NoError(t, err)
+	assert.JSONEq(t, `["1KB", "2MiB"]`, string(out))
+
+	err = jenv.UnmarshalJSON([]byte(`{"cache": "10 parsecs"}`), &cfg)
+	assert.ErrorContains(t, err, `error decoding 'cache': invalid byte size "10 parsecs": unknown unit "parsecs"`)
+	_, err = jenv.ParseByteSize("-1KB")
+	assert.Error(t, err)
+}
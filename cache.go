@@ -0,0 +1,152 @@
+package jenv
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Cache stores values resolved by secret stores, keyed by `scheme:ref`.
+// Unlike a ResolutionCache, which serves one Live configuration, a Cache
+// can be backed by shared storage so that replicas reuse each other's
+// lookups instead of all contacting the secret store. Values are secrets:
+// back a Cache only with storage trusted to hold them.
+type Cache interface {
+	// Get returns the cached value for key; ok is false on a miss.
+	Get(ctx context.Context, key string) (value string, ok bool, err error)
+	// Set stores value for key. A ttl of zero means no expiry.
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+}
+
+// WithCache serves secret placeholders from c when it has them and stores
+// values fetched from secret stores in it for ttl. Cache errors are not
+// fatal: the store is asked instead, and the cache is not used again for
+// the rest of the decode, so a cache that is down costs one timeout
+// rather than one per secret.
+func WithCache(c Cache, ttl time.Duration) Option {
+	return func(o *options) {
+		o.cache, o.cacheTTL = c, ttl
+	}
+}
+
+func (d *decoder) cacheGet(key string) (string, bool) {
+	if d.cache == nil || d.cacheFailed {
+		return "", false
+	}
+	val, ok, err := d.cache.Get(d.resolveCtx, key)
+	if err != nil {
+		d.cacheFailed = true
+		return "", false
+	}
+	return val, ok
+}
+
+func (d *decoder) cacheSet(key, val string) {
+	if d.cache == nil || d.cacheFailed {
+		return
+	}
+	if err := d.cache.Set(d.resolveCtx, key, val, d.cacheTTL); err != nil {
+		d.cacheFailed = true
+	}
+}
+
+// MemoryCache is a Cache held in process memory.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	Value   string    `json:"value"`
+	Expires time.Time `json:"expires,omitzero"`
+}
+
+func (e cacheEntry) expired() bool {
+	return !e.Expires.IsZero() && time.Now().After(e.Expires)
+}
+
+func newCacheEntry(value string, ttl time.Duration) cacheEntry {
+	e := cacheEntry{Value: value}
+	if ttl > 0 {
+		e.Expires = time.Now().Add(ttl)
+	}
+	return e
+}
+
+// NewMemoryCache returns an empty in-memory cache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: map[string]cacheEntry{}}
+}
+
+func (c *MemoryCache) Get(_ context.Context, key string) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || e.expired() {
+		delete(c.entries, key)
+		return "", false, nil
+	}
+	return e.Value, true, nil
+}
+
+func (c *MemoryCache) Set(_ context.Context, key, value string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = newCacheEntry(value, ttl)
+	return nil
+}
+
+// FileCache is a Cache keeping one file per entry in a directory, which
+// processes on the same host or sharing a volume can use together. Files
+// are created readable by their owner only.
+type FileCache struct {
+	dir string
+}
+
+// NewFileCache returns a cache storing its entries in dir, creating the
+// directory if needed.
+func NewFileCache(dir string) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileCache{dir: dir}, nil
+}
+
+func (c *FileCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+func (c *FileCache) Get(_ context.Context, key string) (string, bool, error) {
+	data, err := os.ReadFile(c.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return "", false, err
+	}
+	if e.expired() {
+		os.Remove(c.path(key))
+		return "", false, nil
+	}
+	return e.Value, true, nil
+}
+
+func (c *FileCache) Set(_ context.Context, key, value string, ttl time.Duration) error {
+	data, err := json.Marshal(newCacheEntry(value, ttl))
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path(key), data)
+}
@@ -0,0 +1,113 @@
+package jenv_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestCaches(t *testing.T) {
+	fileCache, err := jenv.NewFileCache(t.TempDir())
+	require.NoError(t, err)
+	for name, c := range map[string]jenv.Cache{"memory": jenv.NewMemoryCache(), "file": fileCache} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_, ok, err := c.Get(ctx, "vault:db")
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			require.NoError(t, c.Set(ctx, "vault:db", "s3cret", 0))
+			require.NoError(t, c.Set(ctx, "vault:short", "brief", time.Millisecond))
+			time.Sleep(5 * time.Millisecond)
+
+			val, ok, err := c.Get(ctx, "vault:db")
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, "s3cret", val)
+			_, ok, err = c.Get(ctx, "vault:short")
+			require.NoError(t, err)
+			assert.False(t, ok, "expired entries are misses")
+		})
+	}
+}
+
+func TestFileCachePermissions(t *testing.T) {
+	dir := t.TempDir()
+	c, err := jenv.NewFileCache(dir)
+	require.NoError(t, err)
+	require.NoError(t, c.Set(context.Background(), "vault:db", "s3cret", 0))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	info, err := entries[0].Info()
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestWithCache(t *testing.T) {
+	var calls atomic.Int32
+	jenv.RegisterSecretStore("cachetest", jenv.SecretStoreFunc(func(ctx context.Context, ref string) (string, error) {
+		calls.Add(1)
+		return "value-of-" + ref, nil
+	}))
+	defer jenv.UnregisterSecretStore("cachetest")
+	type creds struct {
+		Token string `json:"token"`
+	}
+	doc := []byte(`{"token": "${cachetest:api/token}"}`)
+	shared := jenv.NewMemoryCache()
+
+	for replica := 0; replica < 3; replica++ {
+		var cfg creds
+		var res jenv.Result
+		require.NoError(t, jenv.UnmarshalJSON(doc, &cfg, jenv.WithCache(shared, time.Minute), jenv.WithResult(&res)))
+		assert.Equal(t, "value-of-api/token", cfg.Token)
+		if replica > 0 {
+			assert.Equal(t, "cache", res.Sources["token"])
+		}
+	}
+	assert.Equal(t, int32(1), calls.Load())
+
+	val, err := jenv.ExpandString("${cachetest:api/token}", jenv.WithCache(shared, time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, "value-of-api/token", val)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// downCache fails every call, as a cache whose server is unreachable does.
+type downCache struct {
+	calls atomic.Int32
+}
+
+func (c *downCache) Get(context.Context, string) (string, bool, error) {
+	c.calls.Add(1)
+	return "", false, errors.New("connection refused")
+}
+
+func (c *downCache) Set(context.Context, string, string, time.Duration) error {
+	c.calls.Add(1)
+	return errors.New("connection refused")
+}
+
+func TestWithCacheDown(t *testing.T) {
+	jenv.RegisterSecretStore("cachedown", mapStore{"a": "1", "b": "2", "c": "3"})
+	defer jenv.UnregisterSecretStore("cachedown")
+	var cfg struct {
+		A string `json:"a"`
+		B string `json:"b"`
+		C string `json:"c"`
+	}
+	cache := &downCache{}
+	doc := []byte(`{"a": "${cachedown:a}", "b": "${cachedown:b}", "c": "${cachedown:c}"}`)
+	require.NoError(t, jenv.UnmarshalJSON(doc, &cfg, jenv.WithCache(cache, time.Minute)))
+	assert.Equal(t, "3", cfg.C)
+	assert.Equal(t, int32(1), cache.calls.Load(), "the cache is left alone after its first error")
+}
@@ -0,0 +1,136 @@
+package jenv
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/oarkflow/jenv/internal/azauth"
+	"github.com/oarkflow/jenv/internal/gcpauth"
+)
+
+var cloudHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+var (
+	gcpSecretManagerURL = "https://secretmanager.googleapis.com"
+	// akvURL returns the base URL of the key vault at host.
+	akvURL = func(host string) string {
+		return "https://" + host
+	}
+)
+
+// akvSuffixes are the DNS suffixes of Key Vault in the public and
+// sovereign Azure clouds.
+var akvSuffixes = []string{"vault.azure.net", "vault.azure.cn", "vault.usgovcloudapi.net"}
+
+// akvHost returns the host of a key vault and the resource its tokens are
+// issued for. A bare name is a vault in the public cloud; a name with a
+// dot must end in one of akvSuffixes, so a document cannot have the
+// token sent to any other host.
+func akvHost(vault string) (host, resource string, err error) {
+	name, suffix, ok := strings.Cut(vault, ".")
+	if !ok {
+		suffix = akvSuffixes[0]
+	}
+	if name == "" || !slices.Contains(akvSuffixes, suffix) {
+		return "", "", fmt.Errorf("invalid key vault '%s', expected a vault name or a host ending in %s", vault, strings.Join(akvSuffixes, ", "))
+	}
+	return name + "." + suffix, "https://" + suffix, nil
+}
+
+// gcpSecretStore resolves `${gcp-secret:projects/p/secrets/s}` from Google
+// Cloud Secret Manager, reading the latest version unless the reference
+// ends in `/versions/N`. A bare secret name is looked up in the project
+// named by GOOGLE_CLOUD_PROJECT. Requests use application default
+// credentials.
+type gcpSecretStore struct{}
+
+func (gcpSecretStore) Get(ctx context.Context, ref string) (string, error) {
+	name := strings.Trim(strings.TrimSpace(ref), "/")
+	if !strings.HasPrefix(name, "projects/") {
+		project := Getenv("GOOGLE_CLOUD_PROJECT")
+		if project == "" {
+			return "", fmt.Errorf("invalid gcp secret reference '%s', expected projects/p/secrets/s or GOOGLE_CLOUD_PROJECT to be set", ref)
+		}
+		name = "projects/" + project + "/secrets/" + name
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+	token, err := gcpauth.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := getCloudJSON(ctx, "gcp secret manager", gcpSecretManagerURL+"/v1/"+name+":access", token, &resp); err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("gcp secret '%s': %v", ref, err)
+	}
+	return string(data), nil
+}
+
+// akvStore resolves `${akv:vault-name/secret-name}` from Azure Key Vault,
+// reading the current version unless one is given as a third segment.
+// Requests use the Entra ID credentials azauth discovers.
+type akvStore struct{}
+
+func (akvStore) Get(ctx context.Context, ref string) (string, error) {
+	parts := strings.Split(strings.Trim(strings.TrimSpace(ref), "/"), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("invalid key vault reference '%s', expected vault-name/secret-name", ref)
+	}
+	host, resource, err := akvHost(parts[0])
+	if err != nil {
+		return "", err
+	}
+	token, err := azauth.Token(ctx, resource)
+	if err != nil {
+		return "", err
+	}
+	endpoint := akvURL(host) + "/secrets/" + url.PathEscape(parts[1])
+	if len(parts) == 3 {
+		endpoint += "/" + url.PathEscape(parts[2])
+	}
+	var resp struct {
+		Value string `json:"value"`
+	}
+	if err := getCloudJSON(ctx, "azure key vault", endpoint+"?api-version=7.4", token, &resp); err != nil {
+		return "", err
+	}
+	return resp.Value, nil
+}
+
+func getCloudJSON(ctx context.Context, service, endpoint, token string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := cloudHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling %s: %v", service, err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return json.NewDecoder(resp.Body).Decode(out)
+	case http.StatusNotFound:
+		return fmt.Errorf("%s: %s: %w", service, req.URL.Path, ErrSecretNotFound)
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("%s returned %s: %s", service, resp.Status, strings.TrimSpace(string(body)))
+}
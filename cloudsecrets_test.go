@@ -0,0 +1,102 @@
+package jenv
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloudSecretStores(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tenant/oauth2/v2.0/token":
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "https://vault.azure.net/.default", r.Form.Get("scope"))
+			assert.Equal(t, "client-secret", r.Form.Get("client_secret"))
+			json.NewEncoder(w).Encode(map[string]any{"access_token": "az-token", "expires_in": 3600})
+			return
+		}
+		auth := r.Header.Get("Authorization")
+		gcpSecrets := map[string]string{
+			"/v1/projects/p/secrets/db/versions/latest:access":  "gcp-latest",
+			"/v1/projects/default/secrets/db/versions/3:access": "gcp-3",
+		}
+		if val, ok := gcpSecrets[r.URL.Path]; ok {
+			assert.Equal(t, "Bearer gcp-token", auth)
+			json.NewEncoder(w).Encode(map[string]any{"payload": map[string]string{
+				"data": base64.StdEncoding.EncodeToString([]byte(val)),
+			}})
+			return
+		}
+		switch r.URL.Path {
+		case "/kv/secrets/db-password", "/kv/secrets/db-password/v1":
+			assert.Equal(t, "Bearer az-token", auth)
+			assert.Equal(t, "7.4", r.URL.Query().Get("api-version"))
+			json.NewEncoder(w).Encode(map[string]string{"value": "akv" + r.URL.Path[len("/kv/secrets/db-password"):]})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	defer func(gcp string, akv func(string) string) { gcpSecretManagerURL, akvURL = gcp, akv }(gcpSecretManagerURL, akvURL)
+	gcpSecretManagerURL = srv.URL
+	akvURL = func(host string) string { return srv.URL + "/" + strings.TrimSuffix(host, ".vault.azure.net") }
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "gcp-token")
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "default")
+	t.Setenv("AZURE_AUTHORITY_HOST", srv.URL)
+	t.Setenv("AZURE_TENANT_ID", "tenant")
+	t.Setenv("AZURE_CLIENT_ID", "client")
+	t.Setenv("AZURE_CLIENT_SECRET", "client-secret")
+
+	var cfg struct {
+		GCP       string `json:"gcp"`
+		Versioned string `json:"versioned"`
+		AKV       string `json:"akv"`
+		AKVv1     string `json:"akv_v1"`
+	}
+	err := UnmarshalJSON([]byte(`{
+		"gcp": "${gcp-secret:projects/p/secrets/db}",
+		"versioned": "${gcp-secret:db/versions/3}",
+		"akv": "${akv:kv/db-password}",
+		"akv_v1": "${akv:kv/db-password/v1}"
+	}`), &cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "gcp-latest", cfg.GCP)
+	assert.Equal(t, "gcp-3", cfg.Versioned)
+	assert.Equal(t, "akv", cfg.AKV)
+	assert.Equal(t, "akv/v1", cfg.AKVv1)
+
+	_, err = akvStore{}.Get(t.Context(), "kv/missing")
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+	_, err = akvStore{}.Get(t.Context(), "kv")
+	assert.ErrorContains(t, err, "expected vault-name/secret-name")
+	_, err = akvStore{}.Get(t.Context(), "attacker.example/x")
+	assert.ErrorContains(t, err, "invalid key vault 'attacker.example'")
+	_, err = akvStore{}.Get(t.Context(), "kv.vault.azure.net.attacker.example/x")
+	assert.ErrorContains(t, err, "invalid key vault")
+	_, err = gcpSecretStore{}.Get(t.Context(), "projects/p/secrets/missing")
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+}
+
+func TestAKVHost(t *testing.T) {
+	host, resource, err := akvHost("kv")
+	require.NoError(t, err)
+	assert.Equal(t, "kv.vault.azure.net", host)
+	assert.Equal(t, "https://vault.azure.net", resource)
+
+	host, resource, err = akvHost("kv.vault.azure.cn")
+	require.NoError(t, err)
+	assert.Equal(t, "kv.vault.azure.cn", host)
+	assert.Equal(t, "https://vault.azure.cn", resource)
+
+	for _, vault := range []string{"evil.example", ".vault.azure.net", "kv.evil.vault.azure.net"} {
+		_, _, err := akvHost(vault)
+		assert.Error(t, err, vault)
+	}
+}
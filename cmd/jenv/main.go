@@ -0,0 +1,117 @@
+// Command jenv checks configuration documents from the command line, e.g.
+// as a CI step:
+//
+//	jenv lint config/*.yaml
+//
+// With --format json it prints a report per file for CI systems and
+// dashboards to consume.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/oarkflow/jenv"
+)
+
+const usage = `usage: jenv <command> [arguments]
+
+commands:
+  lint [--format text|json] FILE...
+                 report common mistakes in JSON/YAML config files
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	switch os.Args[1] {
+	case "lint":
+		os.Exit(lint(os.Args[2:]))
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "jenv: unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+}
+
+// lint reports issues in each file and returns the process exit code:
+// 0 when clean, 1 when issues were found and 2 when a file could not be
+// checked.
+func lint(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	files := fs.Args()
+	if len(files) == 0 || *format != "text" && *format != "json" {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+	if *format == "json" {
+		return lintJSON(files)
+	}
+	code := 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "jenv: %v\n", err)
+			code = 2
+			continue
+		}
+		issues, err := jenv.Lint(data, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "jenv: %s: %v\n", file, err)
+			code = 2
+			continue
+		}
+		for _, issue := range issues {
+			fmt.Printf("%s:%d: %s: %s (%s)\n", file, issue.Line, issue.Path, issue.Message, issue.Rule)
+		}
+		if len(issues) > 0 && code == 0 {
+			code = 1
+		}
+	}
+	return code
+}
+
+// fileReport is the JSON output for one file.
+type fileReport struct {
+	File string `json:"file"`
+	*jenv.Report
+}
+
+// lintJSON prints a jenv.Report for each file as a JSON array, with the
+// same exit codes as lint.
+func lintJSON(files []string) int {
+	code := 0
+	reports := make([]fileReport, 0, len(files))
+	for _, file := range files {
+		var report *jenv.Report
+		if data, err := os.ReadFile(file); err != nil {
+			report = &jenv.Report{
+				Errors:       []jenv.LintIssue{{Rule: "read", Message: err.Error()}},
+				Warnings:     []jenv.LintIssue{},
+				Unresolved:   []jenv.LintIssue{},
+				Deprecations: []jenv.LintIssue{},
+			}
+		} else {
+			report = jenv.Check(data, nil)
+		}
+		switch {
+		case len(report.Errors) > 0:
+			code = 2
+		case code == 0 && (len(report.Warnings) > 0 || len(report.Unresolved) > 0 || len(report.Deprecations) > 0):
+			code = 1
+		}
+		reports = append(reports, fileReport{File: file, Report: report})
+	}
+	out, _ := json.MarshalIndent(reports, "", "  ")
+	fmt.Println(string(out))
+	return code
+}
@@ -0,0 +1,172 @@
+package jenv
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oarkflow/jenv/internal/expr"
+)
+
+// computeFields evaluates the `expr` tags of cfg once every field has been
+// decoded. Fields are visited in declaration order, nested structs
+// included, so an expression may read a computed field declared before it.
+func (d *decoder) computeFields(cfg any) error {
+	root := reflect.ValueOf(cfg).Elem()
+	if root.Kind() != reflect.Struct {
+		return nil
+	}
+	lookup := func(path string) (any, error) {
+		return d.lookupField(root, path)
+	}
+	return d.computeStruct(root, lookup)
+}
+
+func (d *decoder) computeStruct(val reflect.Value, lookup expr.Lookup) error {
+	for _, field := range structFields(val.Type(), d.tagOrder) {
+		fv, ok := fieldByIndexRead(val, field.Index)
+		if !ok {
+			continue
+		}
+		if isComputed(field) {
+			v, err := expr.Eval(field.Tag.Get("expr"), lookup)
+			if err != nil {
+				return fmt.Errorf("error computing field '%s': %w", field.Name, err)
+			}
+			if err := assignComputed(fv, v); err != nil {
+				return fmt.Errorf("error computing field '%s': %w", field.Name, err)
+			}
+			continue
+		}
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}) {
+			if err := d.computeStruct(fv, lookup); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isComputed reports whether field is set from an `expr` tag rather than
+// the document.
+func isComputed(field reflect.StructField) bool {
+	_, ok := field.Tag.Lookup("expr")
+	return ok
+}
+
+// lookupField resolves a document path, such as "service.timeout" or
+// "servers[0].port", against the decoded struct, matching keys the way
+// populateFields does.
+func (d *decoder) lookupField(val reflect.Value, path string) (any, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, fmt.Errorf("unknown field %q", path)
+	}
+	for _, seg := range segments {
+		for val.Kind() == reflect.Ptr {
+			if val.IsNil() {
+				return nil, fmt.Errorf("unknown field %q", path)
+			}
+			val = val.Elem()
+		}
+		var ok bool
+		if val, ok = d.fieldAt(val, seg); !ok {
+			return nil, fmt.Errorf("unknown field %q", path)
+		}
+	}
+	return val.Interface(), nil
+}
+
+// fieldAt returns the value of a struct, map, slice or array at seg. As
+// in documents, a numeric key also indexes a list.
+func (d *decoder) fieldAt(val reflect.Value, seg pathSegment) (reflect.Value, bool) {
+	switch val.Kind() {
+	case reflect.Struct:
+		if seg.index < 0 {
+			return d.structField(val, seg.key)
+		}
+	case reflect.Map:
+		if seg.index < 0 && val.Type().Key().Kind() == reflect.String {
+			next := val.MapIndex(reflect.ValueOf(seg.key).Convert(val.Type().Key()))
+			return next, next.IsValid()
+		}
+	case reflect.Slice, reflect.Array:
+		i := seg.index
+		if i < 0 {
+			n, err := strconv.Atoi(seg.key)
+			if err != nil {
+				return reflect.Value{}, false
+			}
+			i = n
+		}
+		if i >= 0 && i < val.Len() {
+			return val.Index(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func (d *decoder) structField(val reflect.Value, key string) (reflect.Value, bool) {
+	for _, field := range structFields(val.Type(), d.tagOrder) {
+		name := fieldKey(field, d.tagOrder)
+		if name == key || !hasKeyTag(field, d.tagOrder) && strings.EqualFold(name, key) {
+			return fieldByIndexRead(val, field.Index)
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// assignComputed stores the result of an expression in field, converting
+// between the expression's int64, float64, string and bool values and the
+// field's kind.
+func assignComputed(field reflect.Value, v any) error {
+	if field.Kind() == reflect.Ptr {
+		field.Set(reflect.New(field.Type().Elem()))
+		field = field.Elem()
+	}
+	rv := reflect.ValueOf(v)
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch v := v.(type) {
+		case int64:
+			field.SetInt(v)
+			return nil
+		case float64:
+			if v == float64(int64(v)) {
+				field.SetInt(int64(v))
+				return nil
+			}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n, ok := v.(int64); ok && n >= 0 && !field.OverflowUint(uint64(n)) {
+			field.SetUint(uint64(n))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		switch v := v.(type) {
+		case int64:
+			field.SetFloat(float64(v))
+			return nil
+		case float64:
+			field.SetFloat(v)
+			return nil
+		}
+	case reflect.String:
+		field.SetString(fmt.Sprint(v))
+		return nil
+	default:
+		if rv.IsValid() && rv.Type().AssignableTo(field.Type()) {
+			field.Set(rv)
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot assign %T to %s", v, field.Type())
+}
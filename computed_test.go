@@ -0,0 +1,50 @@
+package jenv_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestExprTag(t *testing.T) {
+	type service struct {
+		BaseURL   string        `yaml:"base_url"`
+		HealthURL string        `expr:"service.base_url + \"/healthz\""`
+		Timeout   time.Duration `yaml:"timeout"`
+	}
+	type config struct {
+		Service  service       `yaml:"service"`
+		MaxConns int           `yaml:"max_conns"`
+		PoolSize int           `expr:"max_conns / 2"`
+		Deadline time.Duration `expr:"service.timeout * 3"`
+		Parallel bool          `expr:"PoolSize > 1"`
+	}
+	doc := []byte(`
+service:
+  base_url: https://api.example.com
+  timeout: 2s
+max_conns: 10
+`)
+	var cfg config
+	assert.NoError(t, jenv.UnmarshalYAML(doc, &cfg))
+	assert.Equal(t, "https://api.example.com/healthz", cfg.Service.HealthURL)
+	assert.Equal(t, 5, cfg.PoolSize)
+	assert.Equal(t, 6*time.Second, cfg.Deadline)
+	assert.True(t, cfg.Parallel)
+
+	type broken struct {
+		Total int `expr:"missing + 1"`
+	}
+	err := jenv.UnmarshalYAML([]byte("{}"), &broken{})
+	assert.ErrorContains(t, err, `error computing field 'Total': unknown field "missing"`)
+
+	type lists struct {
+		Hosts []string `json:"hosts"`
+		Same  bool     `expr:"hosts == hosts"`
+	}
+	err = jenv.UnmarshalYAML([]byte("hosts: [a, b]"), &lists{})
+	assert.ErrorContains(t, err, "not comparable")
+}
@@ -0,0 +1,280 @@
+package jenv
+
+import (
+	"crypto/rand"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// converter decodes the resolved string form of the value at path into a
+// field of the type it is registered for.
+type converter func(d *decoder, path, s string) (any, error)
+
+// converters holds the field types decoded from strings by a dedicated
+// parser rather than by kind, such as CronSpec.
+var converters = map[reflect.Type]converter{}
+
+// registerConverter registers a parser that needs neither options nor the
+// path of the value.
+func registerConverter[T any](parse func(s string) (T, error)) {
+	converters[reflect.TypeOf((*T)(nil)).Elem()] = func(_ *decoder, _, s string) (any, error) {
+		return parse(s)
+	}
+}
+
+// DecodeFunc decodes a value for a field of the type it is registered
+// for. raw is the document's value with its placeholders resolved: a
+// string, number or bool, or a []any or map[string]any of them. The result
+// must be assignable or convertible to the type.
+type DecodeFunc func(raw any) (any, error)
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[reflect.Type]DecodeFunc{}
+)
+
+// RegisterDecoder makes fields of type typ decode through fn in every
+// decode, replacing jenv's own handling of the type and any decoder
+// previously registered for it. A decoder for T also serves *T fields.
+func RegisterDecoder(typ reflect.Type, fn DecodeFunc) {
+	if typ == nil {
+		panic("jenv: RegisterDecoder type is nil")
+	}
+	if fn == nil {
+		panic("jenv: RegisterDecoder decoder is nil")
+	}
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[typ] = fn
+}
+
+// WithDecoder decodes fields of type typ through fn for a single decode,
+// taking precedence over RegisterDecoder.
+func WithDecoder(typ reflect.Type, fn DecodeFunc) Option {
+	return func(o *options) {
+		if o.decoders == nil {
+			o.decoders = map[reflect.Type]DecodeFunc{}
+		}
+		o.decoders[typ] = fn
+	}
+}
+
+// decoderFor returns the decoder registered for typ, per decode or
+// globally.
+func (d *decoder) decoderFor(typ reflect.Type) (DecodeFunc, bool) {
+	if fn, ok := d.decoders[typ]; ok {
+		return fn, true
+	}
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	fn, ok := decoders[typ]
+	return fn, ok
+}
+
+// decodeCustom resolves rawValue and stores fn's result in field. A nil
+// result leaves the field untouched.
+func (d *decoder) decodeCustom(field reflect.Value, fn DecodeFunc, rawValue any, path string) error {
+	raw, err := d.resolveTree(rawValue, path)
+	if err != nil {
+		return err
+	}
+	v, err := fn(raw)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.Type().AssignableTo(field.Type()):
+		field.Set(rv)
+	case rv.Type().ConvertibleTo(field.Type()):
+		field.Set(rv.Convert(field.Type()))
+	default:
+		return fmt.Errorf("decoder for %s returned %T", field.Type(), v)
+	}
+	return nil
+}
+
+func init() {
+	registerConverter(regexp.Compile)
+	registerConverter(func(s string) (regexp.Regexp, error) {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return regexp.Regexp{}, err
+		}
+		return *re, nil
+	})
+	registerConverter(parseStdURL)
+	registerConverter(parseIPNet)
+	converters[reflect.TypeOf((*template.Template)(nil))] = func(d *decoder, path, s string) (any, error) {
+		return template.New(path).Funcs(d.templateFuncs).Parse(s)
+	}
+}
+
+// parseStdURL parses a url.URL field. Unlike URL, relative references are
+// accepted.
+func parseStdURL(s string) (url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return url.URL{}, fmt.Errorf("invalid URL %q: %w", s, err)
+	}
+	return *u, nil
+}
+
+// parseIPNet parses a net.IPNet field written in CIDR notation.
+func parseIPNet(s string) (net.IPNet, error) {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		return net.IPNet{}, fmt.Errorf("invalid CIDR %q", s)
+	}
+	return *n, nil
+}
+
+// convert resolves rawValue and stores conv's result in field. Empty
+// values leave the field untouched, as they do for other kinds.
+func (d *decoder) convert(field reflect.Value, conv converter, rawValue any, path string) error {
+	s, err := d.getEnv(rawValue, path)
+	if err != nil {
+		return err
+	}
+	if s == "" {
+		return nil
+	}
+	v, err := conv(d, path, s)
+	if err != nil {
+		return err
+	}
+	field.Set(reflect.ValueOf(v))
+	return nil
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// isTextField reports whether field should be decoded through its
+// UnmarshalText method. time.Time keeps its own, more lenient parsing, and
+// structured values still populate the type field by field.
+func isTextField(field reflect.Value, rawValue any) bool {
+	switch rawValue.(type) {
+	case map[string]any, []any:
+		return false
+	}
+	return field.Type() != reflect.TypeOf(time.Time{}) &&
+		reflect.PointerTo(field.Type()).Implements(textUnmarshalerType)
+}
+
+// unmarshalText resolves rawValue and hands it to the field's UnmarshalText
+// method. For UUID-shaped types (16-byte arrays) the literal "auto" yields
+// a new random (version 4) UUID instead.
+func (d *decoder) unmarshalText(field reflect.Value, rawValue any, path string) error {
+	s, err := d.getEnv(rawValue, path)
+	if err != nil {
+		return err
+	}
+	if s == "" {
+		return nil
+	}
+	if s == "auto" && isUUIDType(field.Type()) {
+		var id [16]byte
+		if _, err := rand.Read(id[:]); err != nil {
+			return err
+		}
+		id[6] = id[6]&0x0f | 0x40
+		id[8] = id[8]&0x3f | 0x80
+		field.Set(reflect.ValueOf(id).Convert(field.Type()))
+		return nil
+	}
+	if err := field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+		return err
+	}
+	return nil
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// isJSONField reports whether field should be decoded through its
+// UnmarshalJSON method. It is consulted after isTextField, and
+// json.RawMessage keeps the document's value as it is.
+func isJSONField(field reflect.Value) bool {
+	typ := field.Type()
+	return typ != reflect.TypeOf(time.Time{}) && typ != reflect.TypeOf(json.RawMessage{}) &&
+		reflect.PointerTo(typ).Implements(jsonUnmarshalerType)
+}
+
+// unmarshalJSON resolves the placeholders in rawValue and hands its JSON
+// encoding to the field's UnmarshalJSON method. A string holding a JSON
+// object or array, as an env var might, is passed as that JSON. Other
+// strings are passed quoted, or bare when the method rejects them quoted
+// and they are valid JSON, so "1.5" reaches a decimal type as either.
+func (d *decoder) unmarshalJSON(field reflect.Value, rawValue any, path string) error {
+	v, err := d.resolveTree(rawValue, path)
+	if err != nil {
+		return err
+	}
+	s, isString := v.(string)
+	s = strings.TrimSpace(s)
+	if isString && s == "" {
+		return nil
+	}
+	u := field.Addr().Interface().(json.Unmarshaler)
+	if isString && (s[0] == '{' || s[0] == '[') && json.Valid([]byte(s)) {
+		err = u.UnmarshalJSON([]byte(s))
+	} else {
+		data, merr := json.Marshal(v)
+		if merr != nil {
+			return merr
+		}
+		err = u.UnmarshalJSON(data)
+		if err != nil && isString && json.Valid([]byte(s)) {
+			err = u.UnmarshalJSON([]byte(s))
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// resolveTree resolves the placeholders in every string of rawValue,
+// descending into mappings and lists.
+func (d *decoder) resolveTree(rawValue any, path string) (any, error) {
+	switch v := rawValue.(type) {
+	case string:
+		return d.getEnv(v, path)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			r, err := d.resolveTree(item, joinPath(path, k))
+			if err != nil {
+				return nil, err
+			}
+			out[k] = r
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			r, err := d.resolveTree(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	}
+	return rawValue, nil
+}
+
+func isUUIDType(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Array && typ.Len() == 16 && typ.Elem().Kind() == reflect.Uint8
+}
@@ -0,0 +1,242 @@
+package jenv_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+// uuid mirrors the shape of the common UUID packages: a 16-byte array that
+// implements encoding.TextUnmarshaler.
+type uuid [16]byte
+
+func (u *uuid) UnmarshalText(text []byte) error {
+	b, err := hex.DecodeString(strings.ReplaceAll(string(text), "-", ""))
+	if err != nil || len(b) != 16 {
+		return fmt.Errorf("invalid UUID %q", text)
+	}
+	copy(u[:], b)
+	return nil
+}
+
+func TestTextUnmarshalerFields(t *testing.T) {
+	type node struct {
+		ID       uuid   `json:"id"`
+		Instance uuid   `json:"instance"`
+		Peers    []uuid `json:"peers"`
+		Bind     net.IP `json:"bind"`
+	}
+	var cfg node
+	require.NoError(t, jenv.UnmarshalJSON([]byte(`{
+		"id": "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		"instance": "auto",
+		"peers": ["auto", "auto"],
+		"bind": "${BIND_ADDR:10.0.0.5}"
+	}`), &cfg))
+	assert.Equal(t, "6ba7b8109dad11d180b400c04fd430c8", hex.EncodeToString(cfg.ID[:]))
+	assert.NotEqual(t, uuid{}, cfg.Instance)
+	assert.Equal(t, byte(0x40), cfg.Instance[6]&0xf0, "auto generates a version 4 UUID")
+	assert.NotEqual(t, cfg.Peers[0], cfg.Peers[1])
+	assert.Equal(t, "10.0.0.5", cfg.Bind.String())
+
+	err := jenv.UnmarshalJSON([]byte(`{"id": "nope"}`), &cfg)
+	assert.ErrorContains(t, err, `error decoding 'id': invalid UUID "nope"`)
+}
+
+// cents implements only json.Unmarshaler, accepting amounts as JSON numbers
+// or strings, the way decimal packages do.
+type cents int64
+
+func (c *cents) UnmarshalJSON(data []byte) error {
+	var f float64
+	if err := json.Unmarshal(bytes.Trim(data, `"`), &f); err != nil {
+		return fmt.Errorf("invalid amount %s", data)
+	}
+	*c = cents(math.Round(f * 100))
+	return nil
+}
+
+// limits decodes a JSON object of its own shape.
+type limits struct {
+	perMinute int
+}
+
+func (l *limits) UnmarshalJSON(data []byte) error {
+	var v struct {
+		PerMinute int `json:"per_minute"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	l.perMinute = v.PerMinute
+	return nil
+}
+
+func TestJSONUnmarshalerFields(t *testing.T) {
+	t.Setenv("JSONFIELD_LIMITS", `{"per_minute": 120}`)
+	type billing struct {
+		Fee     cents   `json:"fee"`
+		Cap     *cents  `json:"cap"`
+		Prices  []cents `json:"prices"`
+		Limits  limits  `json:"limits"`
+		Default limits  `json:"default"`
+	}
+	var cfg billing
+	require.NoError(t, jenv.UnmarshalYAML([]byte(`
+fee: "${FEE:1.25}"
+cap: 99.5
+prices: [1, "2.5"]
+limits: "${JSONFIELD_LIMITS}"
+default:
+  per_minute: 60
+`), &cfg))
+	assert.Equal(t, cents(125), cfg.Fee)
+	require.NotNil(t, cfg.Cap)
+	assert.Equal(t, cents(9950), *cfg.Cap)
+	assert.Equal(t, []cents{100, 250}, cfg.Prices)
+	assert.Equal(t, limits{perMinute: 120}, cfg.Limits)
+	assert.Equal(t, limits{perMinute: 60}, cfg.Default)
+
+	err := jenv.UnmarshalYAML([]byte(`fee: lots`), &cfg)
+	assert.ErrorContains(t, err, `error decoding 'fee': invalid amount "lots"`)
+}
+
+// money is an application type jenv knows nothing about.
+type money struct {
+	Currency string
+	Cents    int64
+}
+
+func parseMoney(raw any) (any, error) {
+	s, ok := raw.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected amount like \"EUR 12.50\", got %T", raw)
+	}
+	cur, amount, _ := strings.Cut(s, " ")
+	f, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	return money{Currency: cur, Cents: int64(math.Round(f * 100))}, nil
+}
+
+// duration is decoded as a number of days by a per-call decoder.
+type duration time.Duration
+
+func TestRegisterDecoder(t *testing.T) {
+	jenv.RegisterDecoder(reflect.TypeOf(money{}), parseMoney)
+	type plan struct {
+		Price  money    `yaml:"price"`
+		Setup  *money   `yaml:"setup"`
+		Extras []money  `yaml:"extras"`
+		Trial  duration `yaml:"trial"`
+	}
+	var cfg plan
+	require.NoError(t, jenv.UnmarshalYAML([]byte(`
+price: "${PRICE:EUR 12.50}"
+setup: USD 5
+extras: ["EUR 1", "EUR 2.5"]
+trial: 2
+`), &cfg, jenv.WithDecoder(reflect.TypeOf(duration(0)), func(raw any) (any, error) {
+		days, ok := raw.(int)
+		if !ok {
+			return nil, fmt.Errorf("expected days, got %T", raw)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	})))
+	assert.Equal(t, money{Currency: "EUR", Cents: 1250}, cfg.Price)
+	require.NotNil(t, cfg.Setup)
+	assert.Equal(t, money{Currency: "USD", Cents: 500}, *cfg.Setup)
+	assert.Equal(t, []money{{"EUR", 100}, {"EUR", 250}}, cfg.Extras)
+	assert.Equal(t, duration(48*time.Hour), cfg.Trial)
+
+	err := jenv.UnmarshalYAML([]byte("price: {amount: 1}\n"), &cfg)
+	assert.ErrorContains(t, err, `error decoding 'price': expected amount like "EUR 12.50", got map[string]interface {}`)
+}
+
+func TestNetFields(t *testing.T) {
+	t.Setenv("NETFIELD_UPSTREAM", "https://api.example.com/v1")
+	type network struct {
+		Upstream  url.URL        `yaml:"upstream"`
+		Callback  *url.URL       `yaml:"callback"`
+		Bind      net.IP         `yaml:"bind"`
+		Allow     []net.IPNet    `yaml:"allow"`
+		Internal  *net.IPNet     `yaml:"internal"`
+		Gateway   netip.Addr     `yaml:"gateway"`
+		Subnet    netip.Prefix   `yaml:"subnet"`
+		Listen    netip.AddrPort `yaml:"listen"`
+		Resolvers []netip.Addr   `yaml:"resolvers"`
+	}
+	var cfg network
+	require.NoError(t, jenv.UnmarshalYAML([]byte(`
+upstream: "${NETFIELD_UPSTREAM}"
+callback: /hooks/deploy
+bind: "::1"
+allow: [10.0.0.0/8, "fd00::/8"]
+internal: 192.168.0.0/16
+gateway: 10.0.0.1
+subnet: 10.0.0.0/24
+listen: "[::]:8443"
+resolvers: [1.1.1.1, 8.8.8.8]
+`), &cfg))
+	assert.Equal(t, "api.example.com", cfg.Upstream.Host)
+	require.NotNil(t, cfg.Callback)
+	assert.Equal(t, "/hooks/deploy", cfg.Callback.Path)
+	assert.Equal(t, "::1", cfg.Bind.String())
+	require.Len(t, cfg.Allow, 2)
+	assert.True(t, cfg.Allow[0].Contains(net.ParseIP("10.1.2.3")))
+	assert.Equal(t, "fd00::/8", cfg.Allow[1].String())
+	require.NotNil(t, cfg.Internal)
+	assert.Equal(t, "192.168.0.0/16", cfg.Internal.String())
+	assert.Equal(t, netip.MustParseAddr("10.0.0.1"), cfg.Gateway)
+	assert.True(t, cfg.Subnet.Contains(cfg.Gateway))
+	assert.Equal(t, uint16(8443), cfg.Listen.Port())
+	assert.Equal(t, []netip.Addr{netip.MustParseAddr("1.1.1.1"), netip.MustParseAddr("8.8.8.8")}, cfg.Resolvers)
+
+	err := jenv.UnmarshalYAML([]byte("allow: [10.0.0.0/8, 10.0.0.0/33]\n"), &cfg)
+	assert.ErrorContains(t, err, `error decoding 'allow[1]': invalid CIDR "10.0.0.0/33"`)
+	err = jenv.UnmarshalYAML([]byte("gateway: 10.0.0.256\n"), &cfg)
+	assert.ErrorContains(t, err, "error decoding 'gateway'")
+	err = jenv.UnmarshalYAML([]byte("upstream: \"http://[::1\"\n"), &cfg)
+	assert.ErrorContains(t, err, `error decoding 'upstream': invalid URL "http://[::1"`)
+}
+
+func TestRegexpFields(t *testing.T) {
+	type routing struct {
+		Allow  *regexp.Regexp   `yaml:"allow"`
+		Deny   []*regexp.Regexp `yaml:"deny"`
+		Unused *regexp.Regexp   `yaml:"unused"`
+		Host   regexp.Regexp    `yaml:"host"`
+	}
+	var cfg routing
+	require.NoError(t, jenv.UnmarshalYAML([]byte(`
+allow: "^/api/v[0-9]+/"
+deny: ["\\.php$", "^/admin"]
+unused: "${UNSET_PATTERN}"
+host: "${HOST_PATTERN:^api\\.}"
+`), &cfg))
+	assert.True(t, cfg.Host.MatchString("api.example.com"))
+	assert.True(t, cfg.Allow.MatchString("/api/v2/users"))
+	assert.True(t, cfg.Deny[0].MatchString("/index.php"))
+	assert.True(t, cfg.Deny[1].MatchString("/admin/users"))
+	assert.Nil(t, cfg.Unused)
+
+	err := jenv.UnmarshalYAML([]byte(`deny: ["ok", "(unclosed"]`), &cfg)
+	assert.ErrorContains(t, err, "error decoding 'deny[1]': error parsing regexp: missing closing ): `(unclosed`")
+}
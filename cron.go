@@ -0,0 +1,198 @@
+package jenv
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSpec is a cron schedule validated when the configuration is decoded.
+// It accepts the standard five fields (minute, hour, day of month, month,
+// day of week) with lists, ranges, steps and month/weekday names, and the
+// descriptors @yearly, @annually, @monthly, @weekly, @daily, @midnight and
+// @hourly. As in cron, when both day fields are restricted a day matching
+// either of them is scheduled.
+type CronSpec struct {
+	expr                         string
+	minute, hour, dom, month     uint64
+	dow                          uint64
+	domRestricted, dowRestricted bool
+}
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var cronFields = [5]cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+func init() {
+	registerConverter(ParseCron)
+}
+
+// ParseCron parses a cron expression.
+func ParseCron(expr string) (CronSpec, error) {
+	spec := CronSpec{expr: strings.TrimSpace(expr)}
+	fieldsExpr := spec.expr
+	if strings.HasPrefix(fieldsExpr, "@") {
+		expanded, ok := cronDescriptors[strings.ToLower(fieldsExpr)]
+		if !ok {
+			return CronSpec{}, fmt.Errorf("invalid cron expression %q: unknown descriptor", expr)
+		}
+		fieldsExpr = expanded
+	}
+	fields := strings.Fields(fieldsExpr)
+	if len(fields) != len(cronFields) {
+		return CronSpec{}, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", expr, len(fields))
+	}
+	var bits [5]uint64
+	for i, f := range fields {
+		b, err := cronFields[i].parse(f)
+		if err != nil {
+			return CronSpec{}, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+		bits[i] = b
+	}
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+	spec.minute, spec.hour, spec.dom, spec.month, spec.dow = bits[0], bits[1], bits[2], bits[3], bits[4]
+	spec.domRestricted = !strings.HasPrefix(fields[2], "*")
+	spec.dowRestricted = !strings.HasPrefix(fields[4], "*")
+	return spec, nil
+}
+
+func (f cronField) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(expr, ",") {
+		rangeExpr, stepExpr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepExpr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepExpr, f.name)
+			}
+			step = n
+		}
+		lo, hi := f.min, f.max
+		switch {
+		case rangeExpr == "*":
+		case strings.Contains(rangeExpr, "-"):
+			loExpr, hiExpr, _ := strings.Cut(rangeExpr, "-")
+			var err error
+			if lo, err = f.value(loExpr); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(hiExpr); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangeExpr, f.name)
+			}
+		default:
+			v, err := f.value(rangeExpr)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			if !hasStep {
+				hi = v
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return i + f.min, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value %q in %s field, expected %d-%d", s, f.name, f.min, f.max)
+	}
+	return v, nil
+}
+
+// String returns the expression the schedule was parsed from.
+func (c CronSpec) String() string {
+	return c.expr
+}
+
+// IsZero reports whether c was never set.
+func (c CronSpec) IsZero() bool {
+	return c.expr == ""
+}
+
+// MarshalText returns the expression the schedule was parsed from.
+func (c CronSpec) MarshalText() ([]byte, error) {
+	return []byte(c.expr), nil
+}
+
+// UnmarshalText parses a cron expression.
+func (c *CronSpec) UnmarshalText(text []byte) error {
+	spec, err := ParseCron(string(text))
+	if err != nil {
+		return err
+	}
+	*c = spec
+	return nil
+}
+
+// Next returns the first scheduled time after t, in t's location, or the
+// zero time if the schedule never fires (such as February 30th).
+func (c CronSpec) Next(t time.Time) time.Time {
+	if c.IsZero() {
+		return time.Time{}
+	}
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + 5
+	for t.Year() <= limit {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c CronSpec) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domRestricted && c.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
@@ -0,0 +1,52 @@
+package jenv_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestCronSpec(t *testing.T) {
+	type jobs struct {
+		Cleanup jenv.CronSpec  `json:"cleanup"`
+		Report  *jenv.CronSpec `json:"report"`
+	}
+	var cfg jobs
+	require.NoError(t, jenv.UnmarshalJSON([]byte(`{"cleanup": "*/15 2-4 * * mon-fri", "report": "@daily"}`), &cfg))
+	assert.Equal(t, "*/15 2-4 * * mon-fri", cfg.Cleanup.String())
+
+	from := time.Date(2024, 3, 1, 4, 50, 0, 0, time.UTC) // a Friday
+	assert.Equal(t, time.Date(2024, 3, 4, 2, 0, 0, 0, time.UTC), cfg.Cleanup.Next(from))
+	assert.Equal(t, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), cfg.Report.Next(from))
+
+	err := jenv.UnmarshalJSON([]byte(`{"cleanup": "61 * * * *"}`), &cfg)
+	assert.ErrorContains(t, err, `error decoding 'cleanup': invalid cron expression "61 * * * *": invalid value "61" in minute field, expected 0-59`)
+}
+
+func TestParseCron(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) // a Monday
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"30 9 * * *", time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC)},
+		{"0 0 13 * fri", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 feb *", time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"5,10 1 1 jan,jun *", time.Date(2024, 1, 1, 1, 5, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		spec, err := jenv.ParseCron(tt.expr)
+		require.NoError(t, err, tt.expr)
+		assert.Equal(t, tt.want, spec.Next(from), tt.expr)
+	}
+	for _, expr := range []string{"* * * *", "@sometimes", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		_, err := jenv.ParseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
@@ -0,0 +1,104 @@
+package jenv
+
+import (
+	"reflect"
+	"time"
+)
+
+// Defaulter is implemented by configuration structs that set their own
+// defaults. SetDefaults is called on a struct before it is decoded, so the
+// document, and then environment bindings, override what it sets.
+type Defaulter interface {
+	SetDefaults()
+}
+
+var defaulterType = reflect.TypeOf((*Defaulter)(nil)).Elem()
+
+// setDefaults calls SetDefaults on val when it is a Defaulter.
+func setDefaults(val reflect.Value) {
+	if val.CanAddr() {
+		if d, ok := val.Addr().Interface().(Defaulter); ok {
+			d.SetDefaults()
+		}
+	}
+}
+
+// fieldTags are the tags that give a field a value even when the document
+// leaves it out, so nested structs missing from the document are still
+// decoded when one of their fields carries one.
+var fieldTags = []string{"default", "env"}
+
+// usesPlaceholders reports whether rawValue is a string with placeholders,
+// whose value the environment rather than the document decides.
+func usesPlaceholders(rawValue any) bool {
+	s, ok := rawValue.(string)
+	return ok && len(findPlaceholders(s)) > 0
+}
+
+// nestedStruct returns the struct type a field decodes into, or nil when
+// it is not a plain struct or pointer to one. Structs decoded from text,
+// such as time.Time, by their own UnmarshalJSON or by a registered
+// decoder are not plain.
+func nestedStruct(typ reflect.Type) reflect.Type {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || typ == reflect.TypeOf(time.Time{}) || reflect.PointerTo(typ).Implements(textUnmarshalerType) || reflect.PointerTo(typ).Implements(jsonUnmarshalerType) {
+		return nil
+	}
+	if _, ok := converters[typ]; ok {
+		return nil
+	}
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	if _, ok := decoders[typ]; ok {
+		return nil
+	}
+	return typ
+}
+
+// hasFieldTags reports whether typ or a struct nested in it has a field
+// carrying one of tags.
+func hasFieldTags(typ reflect.Type, tags ...string) bool {
+	return hasFieldTagsSeen(typ, tags, map[reflect.Type]bool{})
+}
+
+func hasFieldTagsSeen(typ reflect.Type, tags []string, seen map[reflect.Type]bool) bool {
+	if seen[typ] {
+		return false
+	}
+	seen[typ] = true
+	for _, field := range structFields(typ, defaultTagOrder) {
+		for _, tag := range tags {
+			if _, ok := field.Tag.Lookup(tag); ok {
+				return true
+			}
+		}
+		if nested := nestedStruct(field.Type); nested != nil && hasFieldTagsSeen(nested, tags, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDefaulter reports whether typ or a struct nested in it is a
+// Defaulter.
+func hasDefaulter(typ reflect.Type) bool {
+	return hasDefaulterSeen(typ, map[reflect.Type]bool{})
+}
+
+func hasDefaulterSeen(typ reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[typ] {
+		return false
+	}
+	seen[typ] = true
+	if reflect.PointerTo(typ).Implements(defaulterType) {
+		return true
+	}
+	for _, field := range structFields(typ, defaultTagOrder) {
+		if nested := nestedStruct(field.Type); nested != nil && field.Type.Kind() == reflect.Struct && hasDefaulterSeen(nested, seen) {
+			return true
+		}
+	}
+	return false
+}
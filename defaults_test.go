@@ -0,0 +1,124 @@
+package jenv_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestDefaultTag(t *testing.T) {
+	t.Setenv("DEFTAG_PORT", "9090")
+	t.Setenv("DEFTAG_CACHE_URL", "redis://cache")
+	type tls struct {
+		Enabled bool   `json:"enabled"`
+		Cert    string `json:"cert"`
+	}
+	type database struct {
+		Host    string        `json:"host" default:"localhost"`
+		Timeout time.Duration `json:"timeout" default:"5s"`
+	}
+	type cache struct {
+		URL string        `json:"url" env:"DEFTAG_CACHE_URL"`
+		TTL time.Duration `json:"ttl" default:"1m"`
+	}
+	type config struct {
+		Port     int           `json:"port" default:"8080" env:"DEFTAG_PORT"`
+		Timeout  time.Duration `json:"timeout" default:"30s"`
+		Name     string        `json:"name" default:"api"`
+		Region   string        `json:"region" default:"eu-west-1"`
+		Hosts    []string      `json:"hosts" default:"a, b"`
+		Start    time.Time     `json:"start" default:"2024-01-01T00:00:00Z"`
+		Database database      `json:"database"`
+		Replica  *database     `json:"replica"`
+		TLS      *tls          `json:"tls"`
+		Cache    *cache        `json:"cache"`
+	}
+	var cfg config
+	err := jenv.UnmarshalJSON([]byte(`{"name": "billing", "region": "${DEFTAG_UNSET}"}`), &cfg)
+	require.NoError(t, err)
+	assert.Equal(t, 9090, cfg.Port)
+	assert.Equal(t, 30*time.Second, cfg.Timeout)
+	assert.Equal(t, "billing", cfg.Name)
+	assert.Equal(t, "eu-west-1", cfg.Region, "placeholders resolving to nothing fall back")
+	assert.Equal(t, []string{"a", "b"}, cfg.Hosts)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), cfg.Start.UTC())
+	assert.Equal(t, database{Host: "localhost", Timeout: 5 * time.Second}, cfg.Database)
+	require.NotNil(t, cfg.Replica)
+	assert.Equal(t, "localhost", cfg.Replica.Host)
+	assert.Nil(t, cfg.TLS, "structs without defaults stay unset")
+	assert.Equal(t, &cache{URL: "redis://cache", TTL: time.Minute}, cfg.Cache)
+
+	cfg = config{}
+	err = jenv.UnmarshalJSON([]byte(`{"hosts": ["c"], "database": {"host": "db"}}`), &cfg, jenv.WithSliceSeparator(";"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, cfg.Hosts)
+	assert.Equal(t, database{Host: "db", Timeout: 5 * time.Second}, cfg.Database)
+}
+
+func TestRequiredTag(t *testing.T) {
+	type database struct {
+		Host string `json:"host" required:"true"`
+		Port int    `json:"port" default:"5432"`
+	}
+	type config struct {
+		Name     string    `json:"name" required:"true"`
+		Database database  `json:"database"`
+		Replica  *database `json:"replica"`
+	}
+	var cfg config
+	require.NoError(t, jenv.UnmarshalJSON([]byte(`{"name": "api", "database": {"host": "db"}}`), &cfg))
+	assert.Equal(t, &database{Port: 5432}, cfg.Replica, "absent optional structs are not checked")
+
+	err := jenv.UnmarshalJSON([]byte(`{"database": {"host": "db"}}`), &config{})
+	assert.EqualError(t, err, "error decoding 'name': required field is not set")
+
+	err = jenv.UnmarshalJSON([]byte(`{"name": "${REQTAG_UNSET}", "database": {"host": "db"}}`), &config{})
+	assert.EqualError(t, err, "error decoding 'name': required field is not set")
+
+	err = jenv.UnmarshalJSON([]byte(`{"name": "api"}`), &config{})
+	assert.ErrorContains(t, err, "error decoding 'database.host': required field is not set")
+
+	err = jenv.UnmarshalJSON([]byte(`{"name": "api", "database": {"host": "db"}, "replica": {"port": 5433}}`), &config{})
+	assert.ErrorContains(t, err, "error decoding 'replica.host': required field is not set")
+}
+
+type defaulterPool struct {
+	Size    int           `yaml:"size"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+func (p *defaulterPool) SetDefaults() {
+	p.Size, p.Timeout = 10, 5*time.Second
+}
+
+type defaulterConfig struct {
+	Host  string        `yaml:"host" required:"true"`
+	Port  int           `yaml:"port" env:"DEFAULTER_PORT"`
+	Pool  defaulterPool `yaml:"pool"`
+	Cache defaulterPool `yaml:"cache"`
+}
+
+func (c *defaulterConfig) SetDefaults() {
+	c.Host, c.Port = "localhost", 8080
+}
+
+func TestDefaulter(t *testing.T) {
+	var cfg defaulterConfig
+	require.NoError(t, jenv.UnmarshalYAML([]byte("pool:\n  size: 50\n"), &cfg))
+	assert.Equal(t, defaulterConfig{
+		Host:  "localhost",
+		Port:  8080,
+		Pool:  defaulterPool{Size: 50, Timeout: 5 * time.Second},
+		Cache: defaulterPool{Size: 10, Timeout: 5 * time.Second},
+	}, cfg, "SetDefaults also satisfies required fields and reaches structs the document leaves out")
+
+	t.Setenv("DEFAULTER_PORT", "9090")
+	cfg = defaulterConfig{}
+	require.NoError(t, jenv.UnmarshalYAML([]byte("host: db\nport: 7000\n"), &cfg))
+	assert.Equal(t, "db", cfg.Host, "the document overrides SetDefaults")
+	assert.Equal(t, 9090, cfg.Port, "env bindings override the document")
+}
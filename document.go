@@ -0,0 +1,166 @@
+package jenv
+
+import (
+	"fmt"
+	"time"
+)
+
+// Doc is a parsed document read by key path, for tools that would rather
+// not define structs. Placeholders are resolved lazily, each time a value
+// is read, with the options given to Parse. The getters return the zero
+// value when a key is missing or does not decode; Get reports why.
+type Doc struct {
+	raw  map[string]any
+	opts []Option
+}
+
+// Parse parses a document, sniffing JSON, TOML or YAML as Unmarshal does.
+func Parse(data []byte, opts ...Option) (*Doc, error) {
+	d := newDecoder(opts)
+	defer d.close()
+	raw, err := d.parse(sniffFormat(data), data)
+	if err != nil {
+		return nil, err
+	}
+	return &Doc{raw: raw, opts: opts}, nil
+}
+
+// UnmarshalKey decodes the value at path into out, as the package's
+// UnmarshalKey does.
+func (doc *Doc) UnmarshalKey(path string, out any) error {
+	d := newDecoder(doc.opts)
+	defer d.close()
+	return d.decodeKey(out, cloneRaw(doc.raw).(map[string]any), path)
+}
+
+// Unmarshal decodes the whole document into cfg.
+func (doc *Doc) Unmarshal(cfg any) error {
+	return doc.UnmarshalKey("", cfg)
+}
+
+// IsSet reports whether the document has a value at path.
+func (doc *Doc) IsSet(path string) bool {
+	_, ok := lookupPath(doc.raw, path)
+	return ok
+}
+
+// Get returns the value at path with its placeholders resolved: a string,
+// number or bool, or a []any or map[string]any. It is nil when the key is
+// missing or a placeholder fails to resolve.
+func (doc *Doc) Get(path string) any {
+	d := newDecoder(doc.opts)
+	defer d.close()
+	raw := cloneRaw(doc.raw).(map[string]any)
+	if d.expandVars(raw) != nil || expandRefs(raw) != nil {
+		return nil
+	}
+	v, ok := lookupPath(raw, path)
+	if !ok {
+		return nil
+	}
+	v, err := d.resolveTree(v, path)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// GetString returns the value at path as a string.
+func (doc *Doc) GetString(path string) string {
+	return docValue[string](doc, path)
+}
+
+// GetInt returns the value at path as an int.
+func (doc *Doc) GetInt(path string) int {
+	return docValue[int](doc, path)
+}
+
+// GetInt64 returns the value at path as an int64.
+func (doc *Doc) GetInt64(path string) int64 {
+	return docValue[int64](doc, path)
+}
+
+// GetFloat64 returns the value at path as a float64.
+func (doc *Doc) GetFloat64(path string) float64 {
+	return docValue[float64](doc, path)
+}
+
+// GetBool returns the value at path as a bool.
+func (doc *Doc) GetBool(path string) bool {
+	return docValue[bool](doc, path)
+}
+
+// GetDuration returns the value at path as a duration, such as "30s" or
+// "1d12h".
+func (doc *Doc) GetDuration(path string) time.Duration {
+	return docValue[time.Duration](doc, path)
+}
+
+// GetTime returns the value at path as a time, in any layout time.Time
+// fields accept.
+func (doc *Doc) GetTime(path string) time.Time {
+	return docValue[time.Time](doc, path)
+}
+
+// GetStringSlice returns the value at path as a []string; a single string
+// is split on commas.
+func (doc *Doc) GetStringSlice(path string) []string {
+	return docValue[[]string](doc, path)
+}
+
+// GetStringMap returns the mapping at path as a map[string]string.
+func (doc *Doc) GetStringMap(path string) map[string]string {
+	return docValue[map[string]string](doc, path)
+}
+
+// Get returns the value at path decoded as a struct field of type T
+// would be, so Get[time.Duration](doc, "timeout") accepts "1d12h" and
+// Get[[]int](doc, "ports") splits "80,443". A missing key is an error
+// matching ErrKeyNotFound.
+func Get[T any](doc *Doc, path string) (T, error) {
+	var v T
+	if !doc.IsSet(path) {
+		return v, fmt.Errorf("error reading '%s': %w", path, ErrKeyNotFound)
+	}
+	if err := doc.UnmarshalKey(path, &v); err != nil {
+		var zero T
+		return zero, err
+	}
+	return v, nil
+}
+
+// MustGet is like Get but panics if the key is missing or does not
+// decode, for values a program cannot run without.
+func MustGet[T any](doc *Doc, path string) T {
+	v, err := Get[T](doc, path)
+	if err != nil {
+		panic("jenv: " + err.Error())
+	}
+	return v
+}
+
+// docValue returns the value at path as Get does, or T's zero value.
+func docValue[T any](doc *Doc, path string) T {
+	v, _ := Get[T](doc, path)
+	return v
+}
+
+// cloneRaw deep-copies a raw document value, since decoding resolves
+// variables and references in place.
+func cloneRaw(raw any) any {
+	switch v := raw.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = cloneRaw(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = cloneRaw(item)
+		}
+		return out
+	}
+	return raw
+}
@@ -0,0 +1,83 @@
+package jenv_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestDoc(t *testing.T) {
+	doc, err := jenv.Parse([]byte(`
+service:
+  name: ${DOC_SERVICE_NAME:api}
+  port: "8080"
+  timeout: 1d
+  debug: "${DOC_DEBUG:false}"
+  ratio: 0.5
+  started: 2024-01-02T03:04:05Z
+  hosts: a, b
+  labels: {team: core}
+servers:
+  - host: ${DOC_HOST:localhost}
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "api", doc.GetString("service.name"))
+	t.Setenv("DOC_SERVICE_NAME", "billing")
+	t.Setenv("DOC_DEBUG", "true")
+	assert.Equal(t, "billing", doc.GetString("service.name"), "placeholders resolve on each read")
+	assert.True(t, doc.GetBool("service.debug"))
+	assert.Equal(t, 8080, doc.GetInt("service.port"))
+	assert.Equal(t, int64(8080), doc.GetInt64("service.port"))
+	assert.Equal(t, 0.5, doc.GetFloat64("service.ratio"))
+	assert.Equal(t, 24*time.Hour, doc.GetDuration("service.timeout"))
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), doc.GetTime("service.started").UTC())
+	assert.Equal(t, []string{"a", "b"}, doc.GetStringSlice("service.hosts"))
+	assert.Equal(t, map[string]string{"team": "core"}, doc.GetStringMap("service.labels"))
+	assert.Equal(t, "localhost", doc.GetString("servers[0].host"))
+	assert.Equal(t, map[string]any{"host": "localhost"}, doc.Get("servers[0]"))
+
+	assert.True(t, doc.IsSet("service.port"))
+	assert.False(t, doc.IsSet("service.missing"))
+	assert.Zero(t, doc.GetInt("service.name"), "values that do not decode read as zero")
+	assert.Nil(t, doc.Get("service.missing"))
+
+	var svc struct {
+		Name string `yaml:"name"`
+		Port int    `yaml:"port"`
+	}
+	require.NoError(t, doc.UnmarshalKey("service", &svc))
+	assert.Equal(t, "billing", svc.Name)
+	assert.Equal(t, 8080, svc.Port)
+	assert.Equal(t, "billing", doc.GetString("service.name"), "decoding leaves the document as parsed")
+}
+
+func TestGetGeneric(t *testing.T) {
+	doc, err := jenv.Parse([]byte(`{"ports": "80,443", "retry": {"max": 3, "backoff": "250ms"}, "mode": "0644", "ratio": "fast"}`))
+	require.NoError(t, err)
+
+	ports, err := jenv.Get[[]int](doc, "ports")
+	require.NoError(t, err)
+	assert.Equal(t, []int{80, 443}, ports)
+
+	type retry struct {
+		Max     int           `json:"max"`
+		Backoff time.Duration `json:"backoff"`
+	}
+	r, err := jenv.Get[retry](doc, "retry")
+	require.NoError(t, err)
+	assert.Equal(t, retry{Max: 3, Backoff: 250 * time.Millisecond}, r)
+	assert.Equal(t, os.FileMode(0o644), jenv.MustGet[os.FileMode](doc, "mode"))
+
+	_, err = jenv.Get[string](doc, "retry.jitter")
+	assert.ErrorIs(t, err, jenv.ErrKeyNotFound)
+	_, err = jenv.Get[float64](doc, "ratio")
+	var fe *jenv.FieldError
+	require.ErrorAs(t, err, &fe)
+	assert.Equal(t, "ratio", fe.Path)
+	assert.Panics(t, func() { jenv.MustGet[int](doc, "missing") })
+}
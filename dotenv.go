@@ -0,0 +1,196 @@
+package jenv
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+)
+
+// LoadDotenv reads a dotenv file and sets its variables in the process
+// environment. Variables that are already set keep their value, so the
+// real environment overrides the file.
+//
+// Values may use placeholders, resolved as in configuration documents:
+// `${VAR:default}`, secret store references, and variables defined earlier
+// in the file. Single-quoted values are taken literally.
+func LoadDotenv(path string, opts ...Option) error {
+	return loadDotenvs([]string{path}, opts)
+}
+
+// LoadDotenvs loads several dotenv files in order, as LoadDotenv does. A
+// variable defined by an earlier file is not overridden by a later one,
+// and later files can refer to variables of earlier ones.
+func LoadDotenvs(paths ...string) error {
+	return loadDotenvs(paths, nil)
+}
+
+// LoadDotenvsWith is LoadDotenvs with options for resolving placeholders,
+// as LoadDotenv takes them.
+func LoadDotenvsWith(opts []Option, paths ...string) error {
+	return loadDotenvs(paths, opts)
+}
+
+func loadDotenvs(paths []string, opts []Option) error {
+	d := newDecoder(opts)
+	defer d.close()
+	defined := map[string]string{}
+	set := func(k string) bool {
+		_, ok := os.LookupEnv(k)
+		return ok
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		vars, err := d.parseDotenv(data, defined, set)
+		if err != nil {
+			return fmt.Errorf("error loading %s: %w", path, err)
+		}
+		for k, v := range vars {
+			if _, ok := defined[k]; ok || set(k) {
+				continue
+			}
+			defined[k] = v
+			if err := os.Setenv(k, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ParseDotenv parses a dotenv file into a map without touching the process
+// environment, resolving placeholders as LoadDotenv does.
+func ParseDotenv(data []byte, opts ...Option) (map[string]string, error) {
+	d := newDecoder(opts)
+	defer d.close()
+	return d.parseDotenv(data, nil, nil)
+}
+
+// parseDotenv parses data, resolving placeholders against its own earlier
+// definitions, then defined, then the decoder's environment. Definitions
+// of the variables for which set reports true are returned but not seen
+// by placeholders, which read the environment's value instead.
+func (d *decoder) parseDotenv(data []byte, defined map[string]string, set func(key string) bool) (map[string]string, error) {
+	entries, err := parseDotenvEntries(string(data))
+	if err != nil {
+		return nil, err
+	}
+	vars := map[string]string{}
+	visible := maps.Clone(defined)
+	if visible == nil {
+		visible = map[string]string{}
+	}
+	ctx := d.ctx
+	defer func() { d.ctx = ctx }()
+	for _, e := range entries {
+		val := e.value
+		if e.expand {
+			d.ctx = WithEnv(ctx, visible)
+			if val, err = d.getEnv(val, e.key); err != nil {
+				return nil, fmt.Errorf("line %d: %w", e.line, err)
+			}
+		}
+		vars[e.key] = val
+		if set == nil || !set(e.key) {
+			visible[e.key] = val
+		}
+	}
+	return vars, nil
+}
+
+type dotenvEntry struct {
+	key, value string
+	line       int
+	// expand is false for single-quoted values, which are literal.
+	expand bool
+}
+
+// parseDotenvEntries splits a dotenv file into its assignments. It accepts
+// `KEY=value` lines with an optional `export ` prefix, comments, and
+// single- or double-quoted values; double-quoted values may span lines and
+// use \n, \t, \" and \\ escapes.
+func parseDotenvEntries(src string) ([]dotenvEntry, error) {
+	var entries []dotenvEntry
+	line := 1
+	for len(src) > 0 {
+		var current string
+		current, src, _ = strings.Cut(src, "\n")
+		startLine := line
+		line++
+		trimmed := strings.TrimSpace(current)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		trimmed = strings.TrimPrefix(trimmed, "export ")
+		key, rest, ok := strings.Cut(trimmed, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: expected KEY=value", startLine)
+		}
+		rest = strings.TrimLeft(rest, " \t")
+		e := dotenvEntry{key: key, line: startLine, expand: true}
+		switch {
+		case strings.HasPrefix(rest, "'"):
+			end := strings.Index(rest[1:], "'")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated single-quoted value", startLine)
+			}
+			e.value, e.expand = rest[1:1+end], false
+		case strings.HasPrefix(rest, `"`):
+			// The value may continue on the following lines.
+			text := rest[1:]
+			for {
+				val, ok := unquoteDotenv(text)
+				if ok {
+					e.value = val
+					break
+				}
+				if src == "" {
+					return nil, fmt.Errorf("line %d: unterminated double-quoted value", startLine)
+				}
+				var next string
+				next, src, _ = strings.Cut(src, "\n")
+				line++
+				text += "\n" + next
+			}
+		default:
+			if i := strings.Index(rest, " #"); i >= 0 {
+				rest = rest[:i]
+			}
+			e.value = strings.TrimSpace(rest)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// unquoteDotenv reads a double-quoted value up to its closing quote,
+// processing escapes. ok is false when text has no closing quote.
+func unquoteDotenv(text string) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '"':
+			return b.String(), true
+		case c == '\\' && i+1 < len(text):
+			i++
+			switch text[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(text[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,100 @@
+package jenv_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestParseDotenv(t *testing.T) {
+	t.Setenv("DOTENV_USER", "alice")
+	jenv.RegisterSecretStore("dotenvtest", mapStore{"db/password": "s3cret"})
+	defer jenv.UnregisterSecretStore("dotenvtest")
+
+	vars, err := jenv.ParseDotenv([]byte(`
+# comment
+export HOST=db.internal
+PORT = 5432 # inline comment
+USER=${DOTENV_USER}
+REGION=${DOTENV_REGION:eu-west-1}
+DB_HOST=${HOST}
+PASSWORD="${dotenvtest:db/password}"
+LITERAL='${HOST}'
+MULTI="line one
+line \"two\"\tend"
+EMPTY=
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"HOST":     "db.internal",
+		"PORT":     "5432",
+		"USER":     "alice",
+		"REGION":   "eu-west-1",
+		"DB_HOST":  "db.internal",
+		"PASSWORD": "s3cret",
+		"LITERAL":  "${HOST}",
+		"MULTI":    "line one\nline \"two\"\tend",
+		"EMPTY":    "",
+	}, vars)
+
+	_, err = jenv.ParseDotenv([]byte("A=1\nnot an assignment\n"))
+	assert.ErrorContains(t, err, "line 2")
+	_, err = jenv.ParseDotenv([]byte("A=\"open\n"))
+	assert.ErrorContains(t, err, "unterminated")
+}
+
+func TestLoadDotenvs(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, ".env")
+	local := filepath.Join(dir, ".env.local")
+	require.NoError(t, os.WriteFile(base, []byte("DOTENV_A=base\nDOTENV_B=${DOTENV_A}\nDOTENV_C=from-file\n"), 0o644))
+	require.NoError(t, os.WriteFile(local, []byte("DOTENV_A=local\nDOTENV_D=${DOTENV_B}\n"), 0o644))
+	t.Setenv("DOTENV_C", "from-env")
+	for _, k := range []string{"DOTENV_A", "DOTENV_B", "DOTENV_D"} {
+		t.Setenv(k, "")
+		os.Unsetenv(k)
+	}
+
+	require.NoError(t, jenv.LoadDotenvs(base, local))
+	assert.Equal(t, "base", os.Getenv("DOTENV_A"))
+	assert.Equal(t, "base", os.Getenv("DOTENV_B"))
+	assert.Equal(t, "from-env", os.Getenv("DOTENV_C"))
+	assert.Equal(t, "base", os.Getenv("DOTENV_D"))
+
+	assert.Error(t, jenv.LoadDotenv(filepath.Join(dir, "missing")))
+}
+
+func TestLoadDotenvsWith(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte("DOTENV_TOKEN=${dotenvres:token}\n"), 0o644))
+	t.Setenv("DOTENV_TOKEN", "")
+	os.Unsetenv("DOTENV_TOKEN")
+
+	resolver := jenv.ResolverFunc(func(_, key string) (string, error) { return "value-of-" + key, nil })
+	require.NoError(t, jenv.LoadDotenvsWith([]jenv.Option{jenv.WithResolver(resolver, "dotenvres")}, path))
+	assert.Equal(t, "value-of-token", os.Getenv("DOTENV_TOKEN"))
+}
+
+func TestLoadDotenvProcessWins(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte("DOTENV_HOST=localhost\nDOTENV_URL=http://${DOTENV_HOST}\n"), 0o644))
+	t.Setenv("DOTENV_HOST", "prod")
+	t.Setenv("DOTENV_URL", "")
+	os.Unsetenv("DOTENV_URL")
+
+	require.NoError(t, jenv.LoadDotenv(path))
+	assert.Equal(t, "prod", os.Getenv("DOTENV_HOST"))
+	assert.Equal(t, "http://prod", os.Getenv("DOTENV_URL"))
+}
+
+func TestParseDotenvInterpolation(t *testing.T) {
+	vars, err := jenv.ParseDotenv([]byte("HOST=db\nPORT=5432\nADDR=${HOST}:${PORT}\nURL=pg://${ADDR}/app-${ENV_UNSET:dev}\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "db:5432", vars["ADDR"])
+	assert.Equal(t, "pg://db:5432/app-dev", vars["URL"])
+}
@@ -0,0 +1,126 @@
+package jenv
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DriftReport lists the differences between a document and the struct it
+// is decoded into.
+type DriftReport struct {
+	// UnknownKeys are document keys that no struct field decodes.
+	UnknownKeys []string `json:"unknown_keys"`
+	// MissingFields are struct fields the document never sets.
+	MissingFields []MissingField `json:"missing_fields"`
+}
+
+// MissingField is a struct field the document does not mention.
+type MissingField struct {
+	Path string `json:"path"`
+	// Default is the value the field keeps when the document omits it,
+	// i.e. its value in cfg before decoding.
+	Default any `json:"default,omitempty"`
+	// HasDefault is false when that value is the zero value.
+	HasDefault bool `json:"has_default"`
+}
+
+// Drift compares a JSON or YAML document with cfg, a pointer to the struct
+// it is meant for. cfg is not modified; values already set in it are
+// reported as the defaults of missing fields.
+func Drift(data []byte, cfg any) (*DriftReport, error) {
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing document: %v", err)
+	}
+	val := reflect.ValueOf(cfg)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cfg must be a pointer to a struct, got %T", cfg)
+	}
+	r := &DriftReport{}
+	r.compare(doc, val.Elem(), "")
+	sort.Strings(r.UnknownKeys)
+	sort.Slice(r.MissingFields, func(i, j int) bool { return r.MissingFields[i].Path < r.MissingFields[j].Path })
+	return r, nil
+}
+
+func (r *DriftReport) compare(doc any, val reflect.Value, path string) {
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			val = reflect.New(val.Type().Elem())
+		}
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.Struct:
+		if val.Type() == reflect.TypeOf(time.Time{}) {
+			return
+		}
+		m, ok := doc.(map[string]any)
+		if !ok {
+			return
+		}
+		known := map[string]bool{}
+		for _, field := range structFields(val.Type(), defaultTagOrder) {
+			key := fieldKey(field, defaultTagOrder)
+			if _, exists := m[key]; !exists && !hasKeyTag(field, defaultTagOrder) {
+				if docKey, ok := foldKey(m, key); ok {
+					key = docKey
+				}
+			}
+			known[key] = true
+			fv, ok := fieldByIndexRead(val, field.Index)
+			if !ok {
+				fv = reflect.New(field.Type).Elem()
+			}
+			if v, exists := m[key]; exists {
+				r.compare(v, fv, joinPath(path, key))
+			} else if !isComputed(field) {
+				r.missing(fv, joinPath(path, key))
+			}
+		}
+		for key := range m {
+			if !known[key] && !(path == "" && key == varsKey) {
+				r.UnknownKeys = append(r.UnknownKeys, joinPath(path, key))
+			}
+		}
+	case reflect.Map:
+		if m, ok := doc.(map[string]any); ok {
+			for key, v := range m {
+				r.compare(v, reflect.New(val.Type().Elem()).Elem(), joinPath(path, key))
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if items, ok := doc.([]any); ok {
+			for i, v := range items {
+				r.compare(v, reflect.New(val.Type().Elem()).Elem(), fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+}
+
+// missing records field and, for nested structs, each of its fields.
+func (r *DriftReport) missing(field reflect.Value, path string) {
+	typ := field.Type()
+	if typ.Kind() == reflect.Struct && typ != reflect.TypeOf(time.Time{}) {
+		for _, f := range structFields(typ, defaultTagOrder) {
+			if isComputed(f) {
+				continue
+			}
+			fv, ok := fieldByIndexRead(field, f.Index)
+			if !ok {
+				fv = reflect.New(f.Type).Elem()
+			}
+			r.missing(fv, joinPath(path, fieldKey(f, defaultTagOrder)))
+		}
+		return
+	}
+	mf := MissingField{Path: path}
+	if !field.IsZero() && field.CanInterface() {
+		mf.Default, mf.HasDefault = field.Interface(), true
+	}
+	r.MissingFields = append(r.MissingFields, mf)
+}
@@ -0,0 +1,38 @@
+package jenv_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestDrift(t *testing.T) {
+	doc := []byte(`
+vars:
+  host: api
+service:
+  name: api
+  timeout: 5s
+  retries: 3
+database:
+  hosts: [a, b]
+cache:
+  ttl: 1m
+`)
+	cfg := Config{Service: Service{Rate: 0.5}}
+	report, err := jenv.Drift(doc, &cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"cache", "service.retries"}, report.UnknownKeys)
+	assert.Equal(t, []jenv.MissingField{
+		{Path: "database.ports"},
+		{Path: "service.enabled"},
+		{Path: "service.rate", Default: 0.5, HasDefault: true},
+		{Path: "service.start_time"},
+	}, report.MissingFields)
+	assert.Equal(t, time.Duration(0), cfg.Service.Timeout, "cfg must not be modified")
+}
@@ -0,0 +1,239 @@
+package jenv
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DuplicateKeyPolicy decides what a decode does when a mapping in the
+// document defines the same key more than once.
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateKeysError fails the decode, naming both definitions.
+	DuplicateKeysError DuplicateKeyPolicy = iota + 1
+	// DuplicateKeysWarn uses the last definition and records a warning in
+	// the Result.
+	DuplicateKeysWarn
+	// DuplicateKeysFirstWins uses the first definition.
+	DuplicateKeysFirstWins
+	// DuplicateKeysLastWins uses the last definition.
+	DuplicateKeysLastWins
+)
+
+// WithDuplicateKeys sets how duplicate keys are handled. Without it each
+// format keeps its parser's behaviour: JSON silently uses the last
+// definition and YAML rejects the document.
+func WithDuplicateKeys(policy DuplicateKeyPolicy) Option {
+	return func(o *options) {
+		o.duplicateKeys = policy
+	}
+}
+
+// duplicateKey applies the duplicate-key policy to path, defined again at
+// line after a first definition at first. It reports whether the new
+// definition replaces the earlier one.
+func (d *decoder) duplicateKey(path string, first, line int) (bool, error) {
+	switch d.duplicateKeys {
+	case DuplicateKeysError:
+		return false, fmt.Errorf("duplicate key '%s' at line %d, first defined at line %d", path, line, first)
+	case DuplicateKeysFirstWins:
+		return false, nil
+	case DuplicateKeysWarn:
+		d.recordWarning(fmt.Sprintf("duplicate key '%s' at line %d overrides the definition at line %d", path, line, first))
+	}
+	return true, nil
+}
+
+// parseJSON parses a JSON document into a raw map, applying the
+// duplicate-key policy when one is set.
+func (d *decoder) parseJSON(data []byte) (map[string]any, error) {
+	var rawMap map[string]any
+	if d.duplicateKeys == 0 {
+		err := json.Unmarshal(data, &rawMap)
+		return rawMap, err
+	}
+	p := &jsonParser{d: d, data: data, dec: json.NewDecoder(bytes.NewReader(data))}
+	v, err := p.value("")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid character after top-level value at offset %d", p.dec.InputOffset())
+	}
+	if v == nil {
+		return nil, nil
+	}
+	rawMap, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot unmarshal %T into a document", v)
+	}
+	return rawMap, nil
+}
+
+// jsonParser builds the same values as json.Unmarshal into an any, but
+// from tokens, so that repeated keys are seen.
+type jsonParser struct {
+	d    *decoder
+	data []byte
+	dec  *json.Decoder
+}
+
+func (p *jsonParser) value(path string) (any, error) {
+	tok, err := p.dec.Token()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := map[string]any{}
+		lines := map[string]int{}
+		for p.dec.More() {
+			keyTok, err := p.dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := keyTok.(string)
+			line := p.line()
+			childPath := joinPath(path, key)
+			v, err := p.value(childPath)
+			if err != nil {
+				return nil, err
+			}
+			if first, ok := lines[key]; ok {
+				replace, err := p.d.duplicateKey(childPath, first, line)
+				if err != nil {
+					return nil, err
+				}
+				if !replace {
+					continue
+				}
+			} else {
+				lines[key] = line
+			}
+			obj[key] = v
+		}
+		_, err := p.dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []any{}
+		for p.dec.More() {
+			v, err := p.value(fmt.Sprintf("%s[%d]", path, len(arr)))
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err := p.dec.Token()
+		return arr, err
+	}
+	return tok, nil
+}
+
+// line returns the line the decoder has read up to.
+func (p *jsonParser) line() int {
+	return bytes.Count(p.data[:p.dec.InputOffset()], []byte("\n")) + 1
+}
+
+// parseYAML parses a YAML document into a raw map, applying the
+// duplicate-key policy when one is set.
+func (d *decoder) parseYAML(data []byte) (map[string]any, error) {
+	var rawMap map[string]any
+	if d.duplicateKeys == 0 {
+		err := yaml.Unmarshal(data, &rawMap)
+		stringKeys(rawMap)
+		return rawMap, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	if err := d.dedupeYAML(doc.Content[0], "", map[*yaml.Node]bool{}); err != nil {
+		return nil, err
+	}
+	err := doc.Decode(&rawMap)
+	stringKeys(rawMap)
+	return rawMap, err
+}
+
+// stringKeys replaces, in place, the mappings under value that YAML
+// decodes with non-string keys, such as `404: not found`, by
+// map[string]any, so they decode like any other mapping.
+func stringKeys(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = stringKeys(item)
+		}
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = stringKeys(item)
+		}
+		return m
+	case []any:
+		for i, item := range v {
+			v[i] = stringKeys(item)
+		}
+	}
+	return value
+}
+
+// dedupeYAML removes repeated keys from the mappings under node according
+// to the duplicate-key policy. Merge keys are left to the YAML decoder.
+func (d *decoder) dedupeYAML(node *yaml.Node, path string, visited map[*yaml.Node]bool) error {
+	if visited[node] {
+		return nil
+	}
+	visited[node] = true
+	switch node.Kind {
+	case yaml.MappingNode:
+		var kept []*yaml.Node
+		index := map[string]int{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			childPath := joinPath(path, k.Value)
+			if err := d.dedupeYAML(v, childPath, visited); err != nil {
+				return err
+			}
+			if k.Kind != yaml.ScalarNode || k.Tag == "!!merge" {
+				kept = append(kept, k, v)
+				continue
+			}
+			pos, ok := index[k.Value]
+			if !ok {
+				index[k.Value] = len(kept)
+				kept = append(kept, k, v)
+				continue
+			}
+			replace, err := d.duplicateKey(childPath, kept[pos].Line, k.Line)
+			if err != nil {
+				return err
+			}
+			if replace {
+				kept[pos+1] = v
+			}
+		}
+		node.Content = kept
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			if err := d.dedupeYAML(item, fmt.Sprintf("%s[%d]", path, i), visited); err != nil {
+				return err
+			}
+		}
+	case yaml.AliasNode:
+		return d.dedupeYAML(node.Alias, path, visited)
+	}
+	return nil
+}
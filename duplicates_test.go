@@ -0,0 +1,71 @@
+package jenv_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestDuplicateKeys(t *testing.T) {
+	type config struct {
+		DB struct {
+			Host string `json:"host"`
+			Port int    `json:"port"`
+		} `json:"db"`
+		Hosts []string `json:"hosts"`
+	}
+	jsonDoc := []byte(`{
+  "db": {"host": "first", "port": 1,
+    "host": "second"},
+  "hosts": ["a"]
+}`)
+	yamlDoc := []byte(`
+db:
+  host: first
+  port: 1
+  host: second
+hosts: [a]
+`)
+	unmarshal := map[string]func([]byte, any, ...jenv.Option) error{
+		"json": jenv.UnmarshalJSON,
+		"yaml": jenv.UnmarshalYAML,
+	}
+	docs := map[string][]byte{"json": jsonDoc, "yaml": yamlDoc}
+	lines := map[string]string{"json": "at line 3, first defined at line 2", "yaml": "at line 5, first defined at line 3"}
+
+	for format, fn := range unmarshal {
+		t.Run(format, func(t *testing.T) {
+			doc := docs[format]
+
+			var cfg config
+			err := fn(doc, &cfg, jenv.WithDuplicateKeys(jenv.DuplicateKeysError))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "duplicate key 'db.host' "+lines[format])
+
+			cfg = config{}
+			require.NoError(t, fn(doc, &cfg, jenv.WithDuplicateKeys(jenv.DuplicateKeysFirstWins)))
+			assert.Equal(t, "first", cfg.DB.Host)
+			assert.Equal(t, 1, cfg.DB.Port)
+			assert.Equal(t, []string{"a"}, cfg.Hosts)
+
+			cfg = config{}
+			require.NoError(t, fn(doc, &cfg, jenv.WithDuplicateKeys(jenv.DuplicateKeysLastWins)))
+			assert.Equal(t, "second", cfg.DB.Host)
+
+			cfg = config{}
+			var res jenv.Result
+			require.NoError(t, fn(doc, &cfg, jenv.WithDuplicateKeys(jenv.DuplicateKeysWarn), jenv.WithResult(&res)))
+			assert.Equal(t, "second", cfg.DB.Host)
+			require.Len(t, res.Warnings, 1)
+			assert.Contains(t, res.Warnings[0], "duplicate key 'db.host'")
+		})
+	}
+
+	var cfg config
+	require.NoError(t, jenv.UnmarshalJSON(jsonDoc, &cfg), "JSON keeps the last definition by default")
+	assert.Equal(t, "second", cfg.DB.Host)
+	assert.Error(t, jenv.UnmarshalYAML(yamlDoc, &cfg), "YAML rejects duplicates by default")
+}
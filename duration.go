@@ -0,0 +1,77 @@
+package jenv
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// longUnits are the duration units time.ParseDuration lacks.
+var longUnits = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// parseDuration parses durations as time.ParseDuration does, also
+// accepting days ("d") and weeks ("w") in any combination, such as "1d12h"
+// or "2w". A day is always 24 hours.
+func parseDuration(s string) (time.Duration, error) {
+	orig := s
+	neg := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	if s == "0" {
+		return 0, nil
+	}
+	if s == "" {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+	var total time.Duration
+	for s != "" {
+		i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		if i <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		j := strings.IndexFunc(s[i:], func(r rune) bool { return r >= '0' && r <= '9' || r == '.' })
+		if j < 0 {
+			j = len(s) - i
+		}
+		num, unit := s[:i], s[i:i+j]
+		s = s[i+j:]
+		if scale, ok := longUnits[unit]; ok {
+			f, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", orig)
+			}
+			total += time.Duration(f * float64(scale))
+			continue
+		}
+		d, err := time.ParseDuration(num + unit)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		total += d
+	}
+	if neg {
+		return -total, nil
+	}
+	return total, nil
+}
+
+// parseDurationUnit parses s as parseDuration does, taking a bare number
+// to be in unit, as given by a `unit:"ms"` tag, when unit is set.
+func parseDurationUnit(s, unit string) (time.Duration, error) {
+	if unit != "" {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			scale, err := parseDuration("1" + unit)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration unit %q", unit)
+			}
+			return time.Duration(f * float64(scale)), nil
+		}
+	}
+	return parseDuration(s)
+}
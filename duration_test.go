@@ -0,0 +1,48 @@
+package jenv_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestExtendedDurations(t *testing.T) {
+	t.Setenv("DURATION_RETENTION", "2w")
+	type retention struct {
+		Logs     time.Duration   `yaml:"logs"`
+		Backups  time.Duration   `yaml:"backups"`
+		Mixed    time.Duration   `yaml:"mixed"`
+		Drift    time.Duration   `yaml:"drift"`
+		Timeout  time.Duration   `yaml:"timeout" unit:"ms"`
+		Interval time.Duration   `yaml:"interval" unit:"s"`
+		Steps    []time.Duration `yaml:"steps" unit:"m"`
+	}
+	var cfg retention
+	require.NoError(t, jenv.UnmarshalYAML([]byte(`
+logs: 1d
+backups: "${DURATION_RETENTION}"
+mixed: 1.5h30m
+drift: -1d12h
+timeout: 250
+interval: "2.5"
+steps: [1, 5m, 1d]
+`), &cfg))
+	assert.Equal(t, retention{
+		Logs:     24 * time.Hour,
+		Backups:  14 * 24 * time.Hour,
+		Mixed:    2 * time.Hour,
+		Drift:    -36 * time.Hour,
+		Timeout:  250 * time.Millisecond,
+		Interval: 2500 * time.Millisecond,
+		Steps:    []time.Duration{time.Minute, 5 * time.Minute, 24 * time.Hour},
+	}, cfg)
+
+	err := jenv.UnmarshalYAML([]byte("logs: 1 day\n"), &cfg)
+	assert.ErrorContains(t, err, `invalid duration "1 day"`)
+	err = jenv.UnmarshalYAML([]byte("logs: 30\n"), &cfg)
+	assert.ErrorContains(t, err, `invalid duration "30"`, "bare numbers need a unit tag")
+}
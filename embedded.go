@@ -0,0 +1,91 @@
+package jenv
+
+import "reflect"
+
+// embeddedStruct returns the struct type of an anonymous field without a
+// key tag, or of a field with the inline option as in `yaml:",inline"`,
+// whose fields are promoted into the keys of the enclosing struct as in
+// encoding/json, or nil for any other field.
+func embeddedStruct(field reflect.StructField, tags []string) reflect.Type {
+	if !hasTagOption(field.Tag, tags, "inline") && (!field.Anonymous || hasKeyTag(field, tags)) {
+		return nil
+	}
+	if field.Type.Kind() == reflect.Ptr && !field.IsExported() {
+		// The pointer could not be allocated.
+		return nil
+	}
+	return nestedStruct(field.Type)
+}
+
+// structFields lists the exported fields of the struct type typ that
+// decode document keys, leaving out those tagged "-". Embedded structs
+// are replaced by their fields, whose Index is then the path to them from
+// typ; a field of the enclosing struct hides a promoted field with the
+// same key.
+func structFields(typ reflect.Type, tags []string) []reflect.StructField {
+	return structFieldsSeen(typ, tags, map[reflect.Type]bool{})
+}
+
+func structFieldsSeen(typ reflect.Type, tags []string, seen map[reflect.Type]bool) []reflect.StructField {
+	seen[typ] = true
+	defer delete(seen, typ)
+	own := map[string]bool{}
+	for i := 0; i < typ.NumField(); i++ {
+		if field := typ.Field(i); field.IsExported() && !ignoredField(field, tags) && embeddedStruct(field, tags) == nil {
+			own[fieldKey(field, tags)] = true
+		}
+	}
+	var fields []reflect.StructField
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if ignoredField(field, tags) {
+			continue
+		}
+		embedded := embeddedStruct(field, tags)
+		switch {
+		case embedded != nil:
+			if seen[embedded] {
+				continue
+			}
+			for _, f := range structFieldsSeen(embedded, tags, seen) {
+				if !own[fieldKey(f, tags)] {
+					f.Index = append([]int{i}, f.Index...)
+					fields = append(fields, f)
+				}
+			}
+		case field.IsExported():
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// fieldByIndex returns the field of the struct val at index, as listed by
+// structFields, allocating the embedded pointers on the way.
+func fieldByIndex(val reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && val.Kind() == reflect.Ptr {
+			if val.IsNil() {
+				val.Set(reflect.New(val.Type().Elem()))
+			}
+			val = val.Elem()
+		}
+		val = val.Field(x)
+	}
+	return val
+}
+
+// fieldByIndexRead is fieldByIndex for reading, reporting false rather
+// than allocating when an embedded pointer is nil.
+func fieldByIndexRead(val reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && val.Kind() == reflect.Ptr {
+			if val.IsNil() {
+				return reflect.Value{}, false
+			}
+			val = val.Elem()
+		}
+		val = val.Field(x)
+	}
+	return val, true
+}
@@ -1,92 +1,510 @@
 package jenv
 
 import (
+	"cmp"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/oarkflow/date"
-	"gopkg.in/yaml.v3"
 
 	"github.com/oarkflow/jenv/utils"
 )
 
-func UnmarshalJSON(jsonData []byte, cfg any) error {
-	var rawMap map[string]any
-	if err := json.Unmarshal(jsonData, &rawMap); err != nil {
-		return fmt.Errorf("error unmarshalling json: %v", err)
+// Unmarshal decodes a document in any of the formats jenv sniffs, JSON,
+// TOML or YAML, into cfg. Options such as WithStrict, WithTagName,
+// WithEnvTagPrefix and WithResolver apply to this decode alone.
+func Unmarshal(data []byte, cfg any, opts ...Option) error {
+	return unmarshalFormat(sniffFormat(data), data, cfg, opts...)
+}
+
+func UnmarshalJSON(jsonData []byte, cfg any, opts ...Option) error {
+	return unmarshalFormat("json", jsonData, cfg, opts...)
+}
+
+func UnmarshalYAML(yamlData []byte, cfg any, opts ...Option) error {
+	return unmarshalFormat("yaml", yamlData, cfg, opts...)
+}
+
+// decoder carries the state of a single decode: its options and the
+// values recorded into the caller's Result.
+type decoder struct {
+	*options
+	// resolveCtx is passed to secret stores; it carries the resolve
+	// budget when one is set.
+	resolveCtx context.Context
+	cancel     context.CancelFunc
+	// now is the clock reading relative times are resolved against, taken
+	// once so every field of a decode agrees.
+	now time.Time
+	// prefetched holds secrets resolved before population, in batches or
+	// from the Cache, keyed by "scheme:ref".
+	prefetched map[string]resolvedEntry
+	// cacheFailed is set once the Cache has returned an error, after
+	// which the decode no longer uses it.
+	cacheFailed bool
+	// depth is the nesting level of the placeholder being resolved, and
+	// expanding the variables whose values are being expanded, innermost
+	// last.
+	depth     int
+	expanding []string
+	// optional is non-zero while decoding a pointer struct the document
+	// leaves out, whose required fields are not checked. Such a struct is
+	// kept if a default or env binding applies inside it or, under
+	// LoadEnv, where defaults alone would allocate them all, if a binding
+	// does; bound counts the bindings applied.
+	optional int
+	bound    int
+	fromEnv  bool
+	// errs collects the fields that failed under WithAllErrors.
+	errs []error
+	// valuePath names the value being decoded by struct field indexes,
+	// list indexes and map keys, which unlike its document path does not
+	// depend on how keys were matched; see Redact.
+	valuePath string
+	// secretResolved is set whenever a placeholder resolves from a secret
+	// store or command; secretVars holds the document variables resolved
+	// that way, and secretPaths the document paths they were substituted
+	// at, whose fields are recorded as secret values when decoded.
+	secretResolved bool
+	secretVars     map[string]bool
+	secretPaths    map[string]bool
+	// descending holds the struct types missing from the document that
+	// are being decoded for their tags, so recursive types terminate.
+	descending map[reflect.Type]bool
+	// tagPrefix is the WithEnvTagPrefix prefix followed by the
+	// concatenated `envPrefix` tags of the structs being decoded, put
+	// before the names of their `env` tags.
+	tagPrefix string
+	// missing collects the required variables found unset during a
+	// decode; it is nil outside one, and they are reported immediately.
+	missing *MissingEnvError
+}
+
+// defaultMaxDepth bounds placeholder nesting unless WithMaxDepth is used.
+const defaultMaxDepth = 16
+
+func newDecoder(opts []Option) *decoder {
+	d := &decoder{options: newOptions(opts)}
+	d.now = d.clock()
+	d.tagPrefix = d.envTagPrefix
+	if d.document != "" {
+		d.recordDocument(d.document)
+	}
+	d.resolveCtx, d.cancel = d.ctx, func() {}
+	if d.resolveTimeout > 0 {
+		d.resolveCtx, d.cancel = context.WithTimeout(d.ctx, d.resolveTimeout)
+	}
+	return d
+}
+
+func (d *decoder) close() {
+	d.cancel()
+}
+
+// decode populates cfg from a parsed document.
+func (d *decoder) decode(cfg any, rawMap map[string]any) error {
+	return d.decodeKey(cfg, rawMap, "")
+}
+
+// decodeKey populates cfg from the value at path in a parsed document, or
+// from the whole document when path is empty. Variables and references
+// are resolved across the whole document first, so the value may use
+// them, and errors name fields by their full path.
+func (d *decoder) decodeKey(cfg any, rawMap map[string]any, path string) error {
+	if err := d.expandVars(rawMap); err != nil {
+		return err
+	}
+	if err := expandRefs(rawMap); err != nil {
+		return err
+	}
+	root := reflect.ValueOf(cfg).Elem()
+	var rawValue any = rawMap
+	if path != "" {
+		v, ok := lookupPath(rawMap, path)
+		switch {
+		case ok:
+			rawValue = v
+		case nestedStruct(root.Type()) != nil:
+			// A missing struct still gets its defaults.
+			rawValue = map[string]any{}
+		default:
+			return nil
+		}
+	}
+	if m, ok := rawValue.(map[string]any); ok {
+		d.prefetchSecrets(m)
+	}
+	d.missing = &MissingEnvError{}
+	defer func() { d.missing = nil }()
+	d.errs = nil
+	var err error
+	if path == "" {
+		err = d.populateFields(root, rawMap, "")
+	} else {
+		err = d.setFieldValue(root, rawValue, path, "")
+	}
+	if err == nil && len(d.errs) > 0 {
+		err = &DecodeErrors{Errors: d.errs}
 	}
-	return populateFields(cfg, rawMap)
+	if err == nil {
+		err = d.computeFields(cfg)
+	}
+	if err == nil {
+		err = d.afterLoad(cfg, path)
+	}
+	if err == nil {
+		err = d.validateFields(cfg, path)
+	}
+	// A missing variable decodes as empty, which may well be what made a
+	// field fail; report the variables instead.
+	if len(d.missing.Names) > 0 {
+		return d.missing
+	}
+	return err
 }
 
-func UnmarshalYAML(yamlData []byte, cfg any) error {
-	var rawMap map[string]any
-	if err := yaml.Unmarshal(yamlData, &rawMap); err != nil {
-		return fmt.Errorf("error unmarshalling yaml: %v", err)
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
 	}
-	return populateFields(cfg, rawMap)
+	return prefix + "." + key
 }
 
-func populateFields(cfg any, rawMap map[string]any) error {
-	val := reflect.ValueOf(cfg).Elem()
-	typ := val.Type()
-	for i := 0; i < val.NumField(); i++ {
-		field := typ.Field(i)
-		key := strings.Split(field.Tag.Get("json"), ",")[0]
-		if key == "" {
-			key = strings.Split(field.Tag.Get("yaml"), ",")[0]
+func (d *decoder) populateFields(val reflect.Value, rawMap map[string]any, path string) error {
+	setDefaults(val)
+	var normalized map[string][]string
+	if d.keyNormalizer != nil {
+		normalized = make(map[string][]string, len(rawMap))
+		for k := range rawMap {
+			n := d.keyNormalizer(k)
+			normalized[n] = append(normalized[n], k)
 		}
+	}
+	for _, field := range structFields(val.Type(), d.tagOrder) {
+		key := fieldKey(field, d.tagOrder)
 		rawValue, exists := rawMap[key]
+		if !exists && normalized != nil {
+			switch docKeys := normalized[d.keyNormalizer(key)]; len(docKeys) {
+			case 0:
+			case 1:
+				key, rawValue, exists = docKeys[0], rawMap[docKeys[0]], true
+			default:
+				// Which key wins would depend on map order.
+				slices.Sort(docKeys)
+				err := fmt.Errorf("keys '%s' match the same field after normalization", strings.Join(docKeys, "', '"))
+				if err := d.fail(&FieldError{Path: joinPath(path, key), Expected: field.Type.String(), Cause: err}); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !exists && !hasKeyTag(field, d.tagOrder) {
+			if docKey, ok := foldKey(rawMap, key); ok {
+				key, rawValue, exists = docKey, rawMap[docKey], true
+			}
+		}
+		if name, _ := parseTag(field.Tag.Get("env")); name != "" && name != "-" {
+			if v, ok := d.bindEnv(d.tagPrefix+name, joinPath(path, key)); ok {
+				rawValue, exists = v, true
+			}
+		}
+		def, hasDefault := field.Tag.Lookup("default")
+		required := field.Tag.Get("required") == "true"
+		fieldPath := joinPath(path, key)
+		descend := false
+		switch nested := nestedStruct(field.Type); {
+		case exists:
+		case hasDefault:
+			rawValue, exists = def, true
+		case nested != nil && d.descending[nested]:
+		case nested != nil && hasFieldTags(nested, fieldTags...),
+			field.Type.Kind() == reflect.Struct && nested != nil && (hasFieldTags(nested, "required") || hasDefaulter(nested)):
+			// Decode the missing struct anyway so the defaults, env
+			// bindings and required fields inside it are seen to.
+			rawValue, exists, descend = map[string]any{}, true, true
+		}
 		if !exists {
+			// A value from SetDefaults satisfies required.
+			if fv, ok := fieldByIndexRead(val, field.Index); required && d.optional == 0 && (!ok || fv.IsZero()) {
+				if err := d.fail(&FieldError{Path: fieldPath, Expected: field.Type.String(), Cause: ErrRequired}); err != nil {
+					return err
+				}
+			}
 			continue
 		}
-		if err := setFieldValue(val.Field(i), rawValue); err != nil {
-			return fmt.Errorf("error setting field '%s': %v", field.Name, err)
+		optional := descend && field.Type.Kind() == reflect.Ptr
+		bound := d.bound
+		if optional {
+			d.optional++
+		}
+		if descend {
+			if d.descending == nil {
+				d.descending = map[reflect.Type]bool{}
+			}
+			d.descending[nestedStruct(field.Type)] = true
+		}
+		fv := fieldByIndex(val, field.Index)
+		tagPrefix, valuePath := d.tagPrefix, d.valuePath
+		d.tagPrefix += field.Tag.Get("envPrefix")
+		d.valuePath += fieldValuePath(field.Index)
+		err := d.setFieldValue(fv, rawValue, fieldPath, field.Tag)
+		d.tagPrefix, d.valuePath = tagPrefix, valuePath
+		if descend {
+			delete(d.descending, nestedStruct(field.Type))
+		}
+		if optional {
+			d.optional--
+		}
+		if err == nil {
+			switch {
+			case optional && (d.fromEnv && d.bound == bound || fv.Elem().IsZero()):
+				fv.SetZero()
+			case hasDefault && fv.IsZero() && usesPlaceholders(rawValue):
+				// The placeholders resolved to nothing.
+				err = d.setFieldValue(fv, def, fieldPath, field.Tag)
+			}
+		}
+		if err != nil {
+			if err := d.fail(err); err != nil {
+				return err
+			}
+			continue
+		}
+		if required && d.optional == 0 && fv.IsZero() {
+			if err := d.fail(&FieldError{Path: fieldPath, Expected: field.Type.String(), Cause: ErrRequired}); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
-func setFieldValue(field reflect.Value, rawValue any) error {
+// bindEnv reads the variable named by a field's `env` tag. When it is set
+// it takes the place of the document value; like any env value it is
+// literal unless WithRecursiveExpansion is used.
+func (d *decoder) bindEnv(name, path string) (string, bool) {
+	d.recordDependency(path, "env", name)
+	v, ok := d.lookupEnv(name)
+	if ok {
+		d.bound++
+	}
+	if ok && !d.recursiveExpansion {
+		v = strings.ReplaceAll(v, "${", "$${")
+	}
+	return v, ok
+}
+
+// defaultTagOrder lists the tags consulted for key names when no
+// WithTagOrder option is given.
+var defaultTagOrder = []string{"json", "yaml"}
+
+// fieldKey returns the document key a struct field is decoded from: the
+// name in the first of tags that sets one, or the Go field name.
+func fieldKey(field reflect.StructField, tags []string) string {
+	if key := tagKey(field, tags); key != "" {
+		return key
+	}
+	return field.Name
+}
+
+func tagKey(field reflect.StructField, tags []string) string {
+	for _, tag := range tags {
+		value := field.Tag.Get(tag)
+		if value == "-" {
+			return ""
+		}
+		if name, _ := parseTag(value); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// parseTag splits a struct tag value such as "name,omitempty" into the
+// name and its options.
+func parseTag(value string) (string, []string) {
+	name, opts, ok := strings.Cut(value, ",")
+	if !ok {
+		return name, nil
+	}
+	return name, strings.Split(opts, ",")
+}
+
+// ignoredField reports whether the first of tags on field that names a
+// key is "-", as in `json:"-"`, which leaves the field out of decoding.
+// Write "-," for a key that really is "-".
+func ignoredField(field reflect.StructField, tags []string) bool {
+	for _, tag := range tags {
+		value := field.Tag.Get(tag)
+		if value == "-" {
+			return true
+		}
+		if name, _ := parseTag(value); name != "" {
+			return false
+		}
+	}
+	return false
+}
+
+// hasTagOption reports whether one of tags in st carries opt, as in
+// `yaml:",inline"`.
+func hasTagOption(st reflect.StructTag, tags []string, opt string) bool {
+	for _, tag := range tags {
+		if _, opts := parseTag(st.Get(tag)); slices.Contains(opts, opt) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasKeyTag(field reflect.StructField, tags []string) bool {
+	return tagKey(field, tags) != ""
+}
+
+// foldKey finds a document key equal to key under case folding, as
+// encoding/json does for untagged fields. Ties go to the smallest key so
+// the result does not depend on map order.
+func foldKey(rawMap map[string]any, key string) (string, bool) {
+	found, ok := "", false
+	for k := range rawMap {
+		if strings.EqualFold(k, key) && (!ok || k < found) {
+			found, ok = k, true
+		}
+	}
+	return found, ok
+}
+
+// setFieldValue decodes rawValue into field, reporting a failure as a
+// *FieldError for the innermost value at fault.
+func (d *decoder) setFieldValue(field reflect.Value, rawValue any, path string, tag reflect.StructTag) error {
+	if d.secretPaths[path] {
+		d.recordSecretValue()
+	}
+	err := d.setValue(field, rawValue, path, tag)
+	if err == nil || errors.As(err, new(*FieldError)) || errors.As(err, new(*MissingEnvError)) {
+		return err
+	}
+	return &FieldError{Path: path, Expected: field.Type().String(), Got: rawValue, Cause: err}
+}
+
+// setElem decodes rawValue into the list item or map value field, keyed
+// by key.
+func (d *decoder) setElem(field reflect.Value, rawValue any, path string, tag reflect.StructTag, key any) error {
+	valuePath := d.valuePath
+	d.valuePath += elemValuePath(key)
+	defer func() { d.valuePath = valuePath }()
+	return d.setFieldValue(field, rawValue, path, tag)
+}
+
+func (d *decoder) setValue(field reflect.Value, rawValue any, path string, tag reflect.StructTag) error {
+	if len(d.decodeHooks) > 0 {
+		data, done, err := d.applyHooks(field, rawValue, path)
+		if err != nil || done {
+			return err
+		}
+		rawValue = data
+	}
+	if rawValue == nil {
+		// An explicit null clears pointers, maps, slices and interfaces,
+		// as in encoding/json, and leaves other values alone.
+		switch field.Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+			field.SetZero()
+		}
+		return nil
+	}
+	if fn, ok := d.decoderFor(field.Type()); ok {
+		return d.decodeCustom(field, fn, rawValue, path)
+	}
+	if conv, ok := converters[field.Type()]; ok {
+		return d.convert(field, conv, rawValue, path)
+	}
 	if field.Kind() == reflect.Ptr {
 		field.Set(reflect.New(field.Type().Elem()))
 		field = field.Elem()
+		if fn, ok := d.decoderFor(field.Type()); ok {
+			return d.decodeCustom(field, fn, rawValue, path)
+		}
+		if conv, ok := converters[field.Type()]; ok {
+			return d.convert(field, conv, rawValue, path)
+		}
+	}
+	if field.Type() == fileModeType {
+		mode, err := d.getEnvValueFileMode(rawValue, path)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(mode))
+		return nil
+	}
+	if isTextField(field, rawValue) {
+		return d.unmarshalText(field, rawValue, path)
+	}
+	if isJSONField(field) {
+		return d.unmarshalJSON(field, rawValue, path)
+	}
+	// The string option, as in `json:"port,string"`, declares the value
+	// quoted on purpose.
+	if !hasTagOption(tag, d.tagOrder, "string") {
+		if err := d.checkStrictType(field, rawValue); err != nil {
+			return err
+		}
 	}
 	switch field.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
-		val, err := getEnvValueInt(rawValue)
+		val, err := d.getEnvValueInt(rawValue, path)
 		if err != nil {
 			return err
 		}
 		field.SetInt(int64(val))
 	case reflect.Int64:
 		if field.Type() == reflect.TypeOf(time.Duration(0)) {
-			val, err := getEnvValueDuration(rawValue)
+			val, err := d.getEnvValueDurationUnit(rawValue, path, tag.Get("unit"))
 			if err != nil {
 				return err
 			}
 			field.SetInt(int64(val))
 		} else {
-			val, err := getEnvValueInt64(rawValue)
+			val, err := d.getEnvValueInt64(rawValue, path)
 			if err != nil {
 				return err
 			}
 			field.SetInt(val)
 		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		val, err := d.getEnvValueUint(rawValue, path, field.Type())
+		if err != nil {
+			return err
+		}
+		field.SetUint(val)
 	case reflect.Float32, reflect.Float64:
-		val, err := getEnvValueFloat(rawValue)
+		getFloat := d.getEnvValueFloat
+		if scale := tag.Get("percent"); scale != "" {
+			getFloat = func(rawValue any, path string) (float64, error) {
+				return d.getEnvValuePercent(rawValue, path, scale)
+			}
+		}
+		val, err := getFloat(rawValue, path)
 		if err != nil {
 			return err
 		}
 		field.SetFloat(val)
 	case reflect.String:
-		field.SetString(getEnv(rawValue))
+		val, err := d.getEnv(rawValue, path)
+		if err != nil {
+			return err
+		}
+		field.SetString(val)
 	case reflect.Bool:
-		val, err := getEnvValueBool(rawValue)
+		val, err := d.getEnvValueBool(rawValue, path)
 		if err != nil {
 			return err
 		}
@@ -102,34 +520,72 @@ func setFieldValue(field reflect.Value, rawValue any) error {
 			}
 		} else {
 			rawSlice, ok := rawValue.([]any)
+			if str, isString := rawValue.(string); isString {
+				items, err := d.splitScalar(str, path, d.sliceSep(tag))
+				if err != nil {
+					return err
+				}
+				rawSlice, ok = items, true
+			}
 			if !ok {
 				return fmt.Errorf("expected slice for field, got %T", rawValue)
 			}
 			slice := reflect.MakeSlice(field.Type(), len(rawSlice), len(rawSlice))
 			for i := 0; i < len(rawSlice); i++ {
-				if err := setFieldValue(slice.Index(i), rawSlice[i]); err != nil {
+				if err := d.setElem(slice.Index(i), rawSlice[i], fmt.Sprintf("%s[%d]", path, i), tag, i); err != nil {
 					return err
 				}
 			}
 			field.Set(slice)
 		}
+	case reflect.Array:
+		rawSlice, ok := rawValue.([]any)
+		if str, isString := rawValue.(string); isString {
+			items, err := d.splitScalar(str, path, d.sliceSep(tag))
+			if err != nil {
+				return err
+			}
+			rawSlice, ok = items, true
+		}
+		if !ok {
+			return fmt.Errorf("expected list of %d items for field, got %T", field.Len(), rawValue)
+		}
+		if len(rawSlice) != field.Len() {
+			return fmt.Errorf("expected %d items, got %d", field.Len(), len(rawSlice))
+		}
+		for i := 0; i < len(rawSlice); i++ {
+			if err := d.setElem(field.Index(i), rawSlice[i], fmt.Sprintf("%s[%d]", path, i), tag, i); err != nil {
+				return err
+			}
+		}
 	case reflect.Map:
 		rawMap, ok := rawValue.(map[string]any)
+		if str, isString := rawValue.(string); isString && d.pairSeparator != "" {
+			pairs, err := d.splitPairs(str, path)
+			if err != nil {
+				return err
+			}
+			rawMap, ok = pairs, true
+		}
 		if !ok {
 			return fmt.Errorf("expected map for field, got %T", rawValue)
 		}
 		newMap := reflect.MakeMap(field.Type())
 		for k, v := range rawMap {
+			key, err := d.mapKey(field.Type().Key(), k, path)
+			if err != nil {
+				return err
+			}
 			elem := reflect.New(field.Type().Elem()).Elem()
-			if err := setFieldValue(elem, v); err != nil {
+			if err := d.setElem(elem, v, joinPath(path, k), tag, key.Interface()); err != nil {
 				return err
 			}
-			newMap.SetMapIndex(reflect.ValueOf(k), elem)
+			newMap.SetMapIndex(key, elem)
 		}
 		field.Set(newMap)
 	case reflect.Struct:
 		if field.Type() == reflect.TypeOf(time.Time{}) {
-			val, err := getEnvValueTime(rawValue)
+			val, err := d.getEnvValueTimeLayout(rawValue, path, tag.Get("format"))
 			if err != nil {
 				return err
 			}
@@ -139,94 +595,403 @@ func setFieldValue(field reflect.Value, rawValue any) error {
 			if !ok {
 				return fmt.Errorf("expected struct map for field, got %T", rawValue)
 			}
-			if err := populateFields(field.Addr().Interface(), rawStructMap); err != nil {
+			if err := d.populateFields(field, rawStructMap, path); err != nil {
 				return err
 			}
 		}
 	case reflect.Interface:
+		if u, ok := d.unionFor(field.Type()); ok {
+			return d.decodeUnion(field, u, rawValue, path)
+		}
 		if rawValue != nil {
 			field.Set(reflect.ValueOf(rawValue))
 		}
 	default:
-		return fmt.Errorf("unsupported field type: %s %v %v", field.Kind(), field, rawValue)
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, field.Type())
 	}
 	return nil
 }
 
-func getEnv(rawValue any) string {
+// mapKey converts the document key k to the key type of a map field,
+// decoding it like a value of that type, so map[int]string and
+// map[Region]Endpoint work. Placeholders in keys are not resolved.
+func (d *decoder) mapKey(typ reflect.Type, k, path string) (reflect.Value, error) {
+	if typ.Kind() == reflect.String {
+		return reflect.ValueOf(k).Convert(typ), nil
+	}
+	key := reflect.New(typ).Elem()
+	if err := d.setValue(key, strings.ReplaceAll(k, "${", "$${"), joinPath(path, k), ""); err != nil {
+		return reflect.Value{}, fmt.Errorf("invalid key %q: %w", k, err)
+	}
+	return key, nil
+}
+
+// getEnv resolves the placeholders in rawValue. A value may hold several
+// placeholders among literal text, as in "postgres://${DB_USER}@${DB_HOST}/app";
+// `$${` in the text stands for a literal `${`.
+func (d *decoder) getEnv(rawValue any, path string) (string, error) {
 	strValue := fmt.Sprintf("%v", rawValue)
-	if strings.HasPrefix(strValue, "${") && strings.HasSuffix(strValue, "}") {
-		envVar := strings.TrimSpace(strValue[2 : len(strValue)-1])
-		parts := strings.SplitN(envVar, ":", 2)
-		envValue := Getenv(parts[0])
-		if envValue == "" && len(parts) > 1 {
-			envValue = parts[1]
+	refs := findPlaceholders(strValue)
+	if len(refs) == 0 {
+		return unescapePlaceholders(strValue), nil
+	}
+	if limit := cmp.Or(d.maxDepth, defaultMaxDepth); d.depth >= limit {
+		return "", fmt.Errorf("error resolving '%s': placeholders nest deeper than %d levels", strValue, limit)
+	}
+	d.depth++
+	defer func() { d.depth-- }()
+	var b strings.Builder
+	last := 0
+	for _, ref := range refs {
+		val, err := d.resolvePlaceholder(ref.body, path)
+		if err != nil {
+			return "", err
 		}
-		return strings.ReplaceAll(envValue, "'", "")
+		b.WriteString(unescapePlaceholders(strValue[last:ref.start]))
+		b.WriteString(val)
+		last = ref.end
 	}
-	return strValue
+	b.WriteString(unescapePlaceholders(strValue[last:]))
+	return b.String(), nil
 }
 
-func getEnvValueInt(rawValue any) (int, error) {
-	val := getEnv(rawValue)
+// resolvePlaceholder resolves the body of a single placeholder: a secret
+// store reference or an env var reference (see envRef). Everything after
+// the operator is the argument, so a default may contain colons, as in
+// `${DATABASE_URL:postgres://localhost:5432/app}`, or further placeholders,
+// as in `${DATABASE_URL:postgres://${DB_HOST:localhost}/app}`.
+func (d *decoder) resolvePlaceholder(body, path string) (string, error) {
+	if scheme, ref, ok := strings.Cut(body, ":"); ok {
+		if scheme == execScheme {
+			return d.resolveExec(ref, path)
+		}
+		if _, ok := d.secretStore(scheme); !ok && scheme == fileScheme {
+			return "", fmt.Errorf("error resolving '%s:%s': file placeholders are disabled; enable them with WithSecretFiles", fileScheme, ref)
+		}
+		if _, ok := d.secretStore(scheme); ok {
+			return d.resolveSecret(scheme, ref, path)
+		}
+	}
+	ref := parseEnvRef(body)
+	d.recordDependency(path, "env", ref.name)
+	envValue, set := d.lookupEnv(ref.name)
+	var err error
+	if d.recursiveExpansion && envValue != "" {
+		if envValue, err = d.expandEnvValue(ref.name, envValue, path); err != nil {
+			return "", err
+		}
+	}
+	// The argument may hold placeholders of its own and is only resolved
+	// when it is used.
+	arg := func() (string, error) { return d.getEnv(ref.arg, path) }
+	switch ref.op {
+	case '-':
+		if envValue == "" {
+			if envValue, err = arg(); err != nil {
+				return "", err
+			}
+		}
+	case '?':
+		if envValue == "" {
+			msg, err := arg()
+			if err != nil {
+				return "", err
+			}
+			if msg == "" {
+				return "", fmt.Errorf("error resolving '%s': %w", ref.name, ErrMissingEnvVar)
+			}
+			return "", fmt.Errorf("error resolving '%s': %s", ref.name, msg)
+		}
+	case '+':
+		if envValue == "" {
+			return "", nil
+		}
+		if envValue, err = arg(); err != nil {
+			return "", err
+		}
+	default:
+		if ref.required && envValue == "" {
+			if d.missing == nil {
+				return "", &MissingEnvError{Names: []string{ref.name}}
+			}
+			if !slices.Contains(d.missing.Names, ref.name) {
+				d.missing.Names = append(d.missing.Names, ref.name)
+			}
+			return "", nil
+		}
+		if !set && d.strict {
+			return "", fmt.Errorf("error resolving '%s': %w", ref.name, ErrMissingEnvVar)
+		}
+	}
+	return strings.ReplaceAll(envValue, "'", ""), nil
+}
+
+// expandEnvValue resolves the placeholders in the value of the variable
+// name, failing on a cycle back to a variable already being expanded.
+func (d *decoder) expandEnvValue(name, value, path string) (string, error) {
+	if slices.Contains(d.expanding, name) {
+		cycle := append(slices.Clone(d.expanding[slices.Index(d.expanding, name):]), name)
+		return "", fmt.Errorf("error resolving '%s': placeholder cycle %s", name, strings.Join(cycle, " -> "))
+	}
+	d.expanding = append(d.expanding, name)
+	defer func() { d.expanding = d.expanding[:len(d.expanding)-1] }()
+	return d.getEnv(value, path)
+}
+
+// splitScalar resolves str and splits it into slice elements, so a single
+// env var such as HOSTS=a,b,c can feed a slice field.
+func (d *decoder) splitScalar(str, path, sep string) ([]any, error) {
+	val, err := d.getEnv(str, path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(val) == "" {
+		return []any{}, nil
+	}
+	parts := strings.Split(val, sep)
+	items := make([]any, len(parts))
+	for i, part := range parts {
+		// The items are decoded in turn, so placeholders in the resolved
+		// value are escaped to stay literal.
+		items[i] = strings.ReplaceAll(strings.TrimSpace(part), "${", "$${")
+	}
+	return items, nil
+}
+
+// sliceSep returns the separator a string is split on for a list field:
+// the field's `sep` tag, the WithSliceSeparator separator, or a comma.
+func (d *decoder) sliceSep(tag reflect.StructTag) string {
+	if sep := tag.Get("sep"); sep != "" {
+		return sep
+	}
+	return cmp.Or(d.sliceSeparator, ",")
+}
+
+// splitPairs resolves str and parses it as key/value pairs, e.g.
+// "us=10,eu=20" with the default separators.
+func (d *decoder) splitPairs(str, path string) (map[string]any, error) {
+	val, err := d.getEnv(str, path)
+	if err != nil {
+		return nil, err
+	}
+	pairs := map[string]any{}
+	if strings.TrimSpace(val) == "" {
+		return pairs, nil
+	}
+	for _, pair := range strings.Split(val, d.pairSeparator) {
+		k, v, ok := strings.Cut(pair, d.keyValueSeparator)
+		if !ok {
+			return nil, fmt.Errorf("invalid pair %q, expected key%svalue", strings.TrimSpace(pair), d.keyValueSeparator)
+		}
+		pairs[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return pairs, nil
+}
+
+// checkStrictType rejects literal strings for number and bool fields when
+// strict types are enabled. Placeholders are exempt since env values are
+// always strings.
+func (d *decoder) checkStrictType(field reflect.Value, rawValue any) error {
+	str, ok := rawValue.(string)
+	if !d.strictTypes || !ok {
+		return nil
+	}
+	if len(findPlaceholders(str)) > 0 {
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if field.Type() != reflect.TypeOf(time.Duration(0)) {
+			return fmt.Errorf("expected number, got string %q", str)
+		}
+	case reflect.Bool:
+		return fmt.Errorf("expected bool, got string %q", str)
+	}
+	return nil
+}
+
+func (d *decoder) getEnvValueInt(rawValue any, path string) (int, error) {
+	val, err := d.getEnv(rawValue, path)
+	if err != nil {
+		return 0, err
+	}
 	if val == "" {
 		return 0, nil
 	}
 	return strconv.Atoi(val)
 }
 
-func getEnvValueInt64(rawValue any) (int64, error) {
-	val := getEnv(rawValue)
+func (d *decoder) getEnvValueInt64(rawValue any, path string) (int64, error) {
+	val, err := d.getEnv(rawValue, path)
+	if err != nil {
+		return 0, err
+	}
+	if val == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(val, 10, 64)
+}
+
+// getEnvValueUint parses an unsigned integer that fits in typ, rejecting
+// negative and out-of-range values with the bounds in the error.
+func (d *decoder) getEnvValueUint(rawValue any, path string, typ reflect.Type) (uint64, error) {
+	val, err := d.getEnv(rawValue, path)
+	if err != nil {
+		return 0, err
+	}
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(val, 10, typ.Bits())
+	switch {
+	case err == nil:
+		return n, nil
+	case strings.HasPrefix(val, "-"):
+		return 0, fmt.Errorf("%s must not be negative for %s", val, typ)
+	case errors.Is(err, strconv.ErrRange):
+		return 0, fmt.Errorf("%s overflows %s (max %d)", val, typ, uint64(1)<<typ.Bits()-1)
+	}
+	return 0, fmt.Errorf("%q is not an unsigned integer", val)
+}
+
+func (d *decoder) getEnvValueFloat(rawValue any, path string) (float64, error) {
+	val, err := d.getEnv(rawValue, path)
+	if err != nil {
+		return 0, err
+	}
 	if val == "" {
 		return 0, nil
 	}
-	return strconv.ParseInt(getEnv(rawValue), 10, 64)
+	return strconv.ParseFloat(val, 64)
 }
 
-func getEnvValueFloat(rawValue any) (float64, error) {
-	val := getEnv(rawValue)
+// getEnvValuePercent parses values such as "75%" for fields tagged
+// `percent:"1"` (stored as 0.75) or `percent:"100"` (stored as 75). Bare
+// numbers are taken to already be on the field's scale.
+func (d *decoder) getEnvValuePercent(rawValue any, path, scale string) (float64, error) {
+	max, err := strconv.ParseFloat(scale, 64)
+	if err != nil || max <= 0 {
+		return 0, fmt.Errorf("invalid percent scale %q, expected 1 or 100", scale)
+	}
+	val, err := d.getEnv(rawValue, path)
+	if err != nil {
+		return 0, err
+	}
+	val = strings.TrimSpace(val)
 	if val == "" {
 		return 0, nil
 	}
-	return strconv.ParseFloat(getEnv(rawValue), 64)
+	if number, ok := strings.CutSuffix(val, "%"); ok {
+		f, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid percentage %q", val)
+		}
+		return f / 100 * max, nil
+	}
+	return strconv.ParseFloat(val, 64)
 }
 
-func getEnvValueBool(rawValue any) (bool, error) {
-	val := getEnv(rawValue)
+func (d *decoder) getEnvValueBool(rawValue any, path string) (bool, error) {
+	val, err := d.getEnv(rawValue, path)
+	if err != nil {
+		return false, err
+	}
 	if val == "" {
 		return false, nil
 	}
-	return strconv.ParseBool(getEnv(rawValue))
+	return strconv.ParseBool(val)
 }
 
-func getEnvValueDuration(rawValue any) (time.Duration, error) {
-	val := getEnv(rawValue)
+func (d *decoder) getEnvValueDuration(rawValue any, path string) (time.Duration, error) {
+	return d.getEnvValueDurationUnit(rawValue, path, "")
+}
+
+// getEnvValueDurationUnit parses a duration, in days and weeks too, or a
+// bare number in unit when a `unit` tag gives one.
+func (d *decoder) getEnvValueDurationUnit(rawValue any, path, unit string) (time.Duration, error) {
+	val, err := d.getEnv(rawValue, path)
+	if err != nil {
+		return 0, err
+	}
 	if val == "" {
 		return 0, nil
 	}
-	return time.ParseDuration(getEnv(rawValue))
+	return parseDurationUnit(strings.TrimSpace(val), unit)
 }
 
-func getEnvValueTime(rawValue any) (time.Time, error) {
-	val := getEnv(rawValue)
+func (d *decoder) getEnvValueTime(rawValue any, path string) (time.Time, error) {
+	return d.getEnvValueTimeLayout(rawValue, path, "")
+}
+
+// getEnvValueTimeLayout parses a time written in layout, as given by a
+// `format` tag, or in any common format when layout is empty. Times
+// without a zone are taken to be in the WithLocation location, or UTC.
+func (d *decoder) getEnvValueTimeLayout(rawValue any, path, layout string) (time.Time, error) {
+	val, err := d.getEnv(rawValue, path)
+	if err != nil {
+		return time.Time{}, err
+	}
 	if val == "" {
 		return time.Time{}, nil // Return zero time if empty
 	}
+	if d.relativeTime {
+		if t, ok, err := parseRelativeTime(val, d.now); ok {
+			return t, err
+		}
+	}
+	if layout != "" {
+		t, err := time.ParseInLocation(layout, val, cmp.Or(d.location, time.UTC))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%q does not match layout %q", val, layout)
+		}
+		return t, nil
+	}
 	switch rawValue := rawValue.(type) {
 	case string:
-		return date.Parse(getEnv(rawValue))
+		if d.location != nil {
+			return date.ParseIn(val, d.location)
+		}
+		return date.Parse(val)
 	case time.Time:
 		return rawValue, nil
 	}
-	return time.Parse("2006-01-02T15:04:05Z07:00", getEnv(rawValue))
+	return time.Parse("2006-01-02T15:04:05Z07:00", val)
+}
+
+// parseRelativeTime resolves "now", "now+2h", "now-30m", "+2h" and "-30m"
+// against now. ok is false when s is not written relative to now.
+func parseRelativeTime(s string, now time.Time) (t time.Time, ok bool, err error) {
+	offset := strings.TrimPrefix(s, "now")
+	if offset == "" {
+		return now, true, nil
+	}
+	if offset[0] != '+' && offset[0] != '-' {
+		return time.Time{}, false, nil
+	}
+	d, err := parseDuration(offset)
+	if err != nil {
+		return time.Time{}, true, fmt.Errorf("invalid relative time %q", s)
+	}
+	return now.Add(d), true, nil
 }
 
+// GetEnvFn reads an environment variable, or returns defaultVal when it is
+// unset or empty.
 type GetEnvFn func(v string, defaultVal ...any) string
 
+// Getenv reads an environment variable, or returns defaultVal when it is
+// unset or empty. Decodes read through it when it has been replaced.
+//
+// Deprecated: replacing Getenv changes the environment of every decode in
+// the process and races with decodes in progress. Pass an EnvSource to
+// each decode with WithEnvSource instead, and read variables with Lookup
+// or os.Getenv.
 var Getenv GetEnvFn
 
 func getenv(v string, defaultVal ...any) string {
-	val := os.Getenv(v)
+	val, _ := os.LookupEnv(v)
 	if val != "" {
 		return val
 	}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/oarkflow/jenv"
 )
@@ -115,3 +116,362 @@ database:
 	assert.Equal(t, []string{"yaml-db.example.com"}, config.Database.Hosts)
 	assert.Equal(t, map[string]int{"primary": 3306, "replica": 3307}, config.Database.Ports)
 }
+
+func TestPercentTag(t *testing.T) {
+	type sampling struct {
+		Rate     float64   `json:"rate" percent:"1"`
+		Capacity float64   `json:"capacity" percent:"100"`
+		Ratio    float64   `json:"ratio" percent:"1"`
+		Steps    []float64 `json:"steps" percent:"1"`
+	}
+	os.Setenv("SAMPLE_RATE", "12.5%")
+	defer os.Unsetenv("SAMPLE_RATE")
+
+	var cfg sampling
+	err := jenv.UnmarshalJSON([]byte(`{
+		"rate": "${SAMPLE_RATE:1%}",
+		"capacity": "75%",
+		"ratio": 0.25,
+		"steps": ["10%", "50%", 1]
+	}`), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, sampling{Rate: 0.125, Capacity: 75, Ratio: 0.25, Steps: []float64{0.1, 0.5, 1}}, cfg)
+
+	err = jenv.UnmarshalJSON([]byte(`{"rate": "lots%"}`), &cfg)
+	assert.Error(t, err)
+}
+
+func TestUntaggedFields(t *testing.T) {
+	type upstream struct {
+		Host    string
+		Port    int
+		Timeout time.Duration
+		secret  string
+	}
+	var cfg upstream
+	err := jenv.UnmarshalYAML([]byte("Host: example.com\nport: 8080\nTIMEOUT: 5s\nsecret: x\n"), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, upstream{Host: "example.com", Port: 8080, Timeout: 5 * time.Second}, cfg)
+
+	var tagged struct {
+		Name string `json:"name"`
+	}
+	assert.NoError(t, jenv.UnmarshalJSON([]byte(`{"NAME": "ignored"}`), &tagged))
+	assert.Empty(t, tagged.Name)
+}
+
+func TestEnvTag(t *testing.T) {
+	t.Setenv("ENVTAG_NAME", "from-env")
+	t.Setenv("ENVTAG_LITERAL", "${NOT_EXPANDED}")
+	t.Setenv("ENVTAG_HOSTS", "a, b")
+	type service struct {
+		Hosts   []string      `json:"hosts" env:"ENVTAG_HOSTS"`
+		Name    string        `json:"name" env:"ENVTAG_NAME"`
+		Port    int           `json:"port" env:"ENVTAG_PORT"`
+		Timeout time.Duration `json:"timeout" env:"ENVTAG_TIMEOUT"`
+		Literal string        `json:"literal" env:"ENVTAG_LITERAL"`
+	}
+	var cfg service
+	var res jenv.Result
+	err := jenv.UnmarshalJSON([]byte(`{"name": "from-doc", "port": 8080}`), &cfg, jenv.WithResult(&res))
+	require.NoError(t, err)
+	assert.Equal(t, service{
+		Hosts:   []string{"a", "b"},
+		Name:    "from-env",
+		Port:    8080,
+		Literal: "${NOT_EXPANDED}",
+	}, cfg)
+	assert.Contains(t, res.Dependencies, jenv.Dependency{Path: "name", Kind: "env", Name: "ENVTAG_NAME"})
+}
+
+func TestEnvPrefixTag(t *testing.T) {
+	t.Setenv("PRIMARY_DB_HOST", "primary.internal")
+	t.Setenv("PRIMARY_DB_PORT", "5433")
+	t.Setenv("REPLICA_DB_HOST", "replica.internal")
+	type database struct {
+		Host string `json:"host" env:"HOST"`
+		Port int    `json:"port" env:"PORT" default:"5432"`
+	}
+	type config struct {
+		Primary database  `json:"primary" envPrefix:"PRIMARY_DB_"`
+		Replica *database `json:"replica" envPrefix:"REPLICA_DB_"`
+	}
+	var cfg config
+	require.NoError(t, jenv.UnmarshalJSON([]byte(`{"primary": {"host": "doc"}}`), &cfg))
+	assert.Equal(t, database{Host: "primary.internal", Port: 5433}, cfg.Primary)
+	require.NotNil(t, cfg.Replica)
+	assert.Equal(t, database{Host: "replica.internal", Port: 5432}, *cfg.Replica)
+}
+
+type baseConfig struct {
+	Name     string `json:"name"`
+	LogLevel string `json:"log_level" default:"info"`
+}
+
+type Tracing struct {
+	Endpoint string `json:"endpoint"`
+}
+
+func TestEmbeddedStructs(t *testing.T) {
+	type config struct {
+		baseConfig
+		*Tracing
+		Port int    `json:"port"`
+		Name string `json:"name_override"`
+	}
+	var cfg config
+	err := jenv.UnmarshalYAML([]byte("name: billing\nendpoint: otel:4317\nport: 8080\n"), &cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "billing", cfg.baseConfig.Name)
+	assert.Equal(t, "info", cfg.LogLevel)
+	require.NotNil(t, cfg.Tracing)
+	assert.Equal(t, "otel:4317", cfg.Endpoint)
+	assert.Equal(t, 8080, cfg.Port)
+
+	cfg = config{}
+	require.NoError(t, jenv.UnmarshalYAML([]byte("port: 8080\n"), &cfg))
+	assert.Nil(t, cfg.Tracing)
+
+	type tagged struct {
+		Tracing `json:"tracing"`
+	}
+	var nested tagged
+	require.NoError(t, jenv.UnmarshalYAML([]byte("tracing:\n  endpoint: otel:4317\n"), &nested))
+	assert.Equal(t, "otel:4317", nested.Endpoint)
+}
+
+func TestTagOptions(t *testing.T) {
+	t.Setenv("TAGOPT_TOKEN", "s3cret")
+	type logging struct {
+		Level string `yaml:"level"`
+	}
+	type config struct {
+		Name     string  `json:"name,omitempty"`
+		Port     int     `json:"port,string"`
+		Internal string  `json:"-"`
+		Dash     string  `json:"-,"`
+		Skipped  string  `json:"-" yaml:"skipped"`
+		Token    string  `json:"token" env:"TAGOPT_TOKEN,omitempty"`
+		Logging  logging `yaml:",inline"`
+	}
+	var cfg config
+	err := jenv.UnmarshalYAML([]byte("name: api\nport: \"8080\"\n\"-\": dash\nInternal: x\nskipped: y\nlevel: debug\n"), &cfg, jenv.WithStrictTypes())
+	require.NoError(t, err)
+	assert.Equal(t, config{
+		Name:    "api",
+		Port:    8080,
+		Dash:    "dash",
+		Token:   "s3cret",
+		Logging: logging{Level: "debug"},
+	}, cfg)
+}
+
+func TestUnsignedFields(t *testing.T) {
+	t.Setenv("UINT_PORT", "8443")
+	type limits struct {
+		Port    uint16   `json:"port"`
+		Workers uint     `json:"workers"`
+		Weight  uint8    `json:"weight"`
+		Quota   uint64   `json:"quota"`
+		Shards  []uint32 `json:"shards"`
+		Backlog *uint    `json:"backlog"`
+	}
+	var cfg limits
+	require.NoError(t, jenv.UnmarshalJSON([]byte(`{
+		"port": "${UINT_PORT}",
+		"workers": 8,
+		"weight": "255",
+		"quota": "18446744073709551615",
+		"shards": [1, "2"],
+		"backlog": 128
+	}`), &cfg))
+	backlog := uint(128)
+	assert.Equal(t, limits{
+		Port:    8443,
+		Workers: 8,
+		Weight:  255,
+		Quota:   18446744073709551615,
+		Shards:  []uint32{1, 2},
+		Backlog: &backlog,
+	}, cfg)
+
+	err := jenv.UnmarshalJSON([]byte(`{"port": 70000}`), &cfg)
+	assert.ErrorContains(t, err, "error decoding 'port': 70000 overflows uint16 (max 65535)")
+	err = jenv.UnmarshalJSON([]byte(`{"weight": -1}`), &cfg)
+	assert.ErrorContains(t, err, "error decoding 'weight': -1 must not be negative for uint8")
+	err = jenv.UnmarshalJSON([]byte(`{"workers": "many"}`), &cfg)
+	assert.ErrorContains(t, err, `error decoding 'workers': "many" is not an unsigned integer`)
+}
+
+func TestArrayFields(t *testing.T) {
+	t.Setenv("ARRAY_PRIMARY", "db-1")
+	type cluster struct {
+		Replicas [3]string        `yaml:"replicas"`
+		Weights  [2]float64       `yaml:"weights"`
+		Zones    [2][2]string     `yaml:"zones"`
+		Ports    *[2]uint16       `yaml:"ports"`
+		Spare    [2]time.Duration `yaml:"spare"`
+	}
+	var cfg cluster
+	require.NoError(t, jenv.UnmarshalYAML([]byte(`
+replicas: ["${ARRAY_PRIMARY}", db-2, db-3]
+weights: [0.5, "0.5"]
+zones: [[a, b], [c, d]]
+ports: [5432, 5433]
+`), &cfg))
+	assert.Equal(t, [3]string{"db-1", "db-2", "db-3"}, cfg.Replicas)
+	assert.Equal(t, [2]float64{0.5, 0.5}, cfg.Weights)
+	assert.Equal(t, [2][2]string{{"a", "b"}, {"c", "d"}}, cfg.Zones)
+	require.NotNil(t, cfg.Ports)
+	assert.Equal(t, [2]uint16{5432, 5433}, *cfg.Ports)
+
+	require.NoError(t, jenv.UnmarshalYAML([]byte(`spare: "1s, 2s"`), &cfg, jenv.WithSliceSeparator(",")))
+	assert.Equal(t, [2]time.Duration{time.Second, 2 * time.Second}, cfg.Spare)
+
+	err := jenv.UnmarshalYAML([]byte(`replicas: [db-1, db-2]`), &cfg)
+	assert.ErrorContains(t, err, "error decoding 'replicas': expected 3 items, got 2")
+}
+
+type region string
+
+func TestMapKeyTypes(t *testing.T) {
+	type endpoint struct {
+		Host string `yaml:"host"`
+	}
+	type routing struct {
+		Codes     map[int]string           `yaml:"codes"`
+		Ports     map[uint16]endpoint      `yaml:"ports"`
+		Regions   map[region]int           `yaml:"regions"`
+		Cooldowns map[time.Duration]string `yaml:"cooldowns"`
+		Flags     map[bool]string          `yaml:"flags"`
+	}
+	var cfg routing
+	require.NoError(t, jenv.UnmarshalYAML([]byte(`
+codes:
+  404: not found
+  500: "${ERROR_TEXT:server error}"
+ports:
+  8080: {host: web}
+regions:
+  eu: 2
+cooldowns:
+  30s: short
+flags:
+  true: "on"
+`), &cfg))
+	assert.Equal(t, map[int]string{404: "not found", 500: "server error"}, cfg.Codes)
+	assert.Equal(t, map[uint16]endpoint{8080: {Host: "web"}}, cfg.Ports)
+	assert.Equal(t, map[region]int{"eu": 2}, cfg.Regions)
+	assert.Equal(t, map[time.Duration]string{30 * time.Second: "short"}, cfg.Cooldowns)
+	assert.Equal(t, map[bool]string{true: "on"}, cfg.Flags)
+
+	err := jenv.UnmarshalYAML([]byte("ports:\n  http: {host: web}\n"), &cfg)
+	assert.ErrorContains(t, err, `error decoding 'ports': invalid key "http": "http" is not an unsigned integer`)
+}
+
+func TestNullValues(t *testing.T) {
+	type pool struct {
+		Size int `json:"size"`
+	}
+	type config struct {
+		Timeout *time.Duration    `json:"timeout"`
+		Retries *int              `json:"retries"`
+		Pool    *pool             `json:"pool"`
+		Absent  *pool             `json:"absent"`
+		Zero    *int              `json:"zero"`
+		Name    string            `json:"name"`
+		Tags    []string          `json:"tags"`
+		Labels  map[string]string `json:"labels"`
+		Extra   any               `json:"extra"`
+	}
+	retries := 3
+	cfg := config{
+		Retries: &retries,
+		Name:    "kept",
+		Tags:    []string{"a"},
+		Labels:  map[string]string{"a": "b"},
+		Extra:   1,
+	}
+	require.NoError(t, jenv.UnmarshalJSON([]byte(`{
+		"timeout": null,
+		"retries": null,
+		"pool": null,
+		"zero": 0,
+		"name": null,
+		"tags": null,
+		"labels": null,
+		"extra": null
+	}`), &cfg))
+	zero := 0
+	assert.Equal(t, config{Zero: &zero, Name: "kept"}, cfg)
+
+	cfg = config{}
+	require.NoError(t, jenv.UnmarshalYAML([]byte("retries: ~\npool:\n"), &cfg))
+	assert.Nil(t, cfg.Retries)
+	assert.Nil(t, cfg.Pool)
+}
+
+func TestTimeFormatTag(t *testing.T) {
+	t.Setenv("TIMEFMT_LAUNCH", "01/03/2024")
+	type schedule struct {
+		Launch  time.Time  `yaml:"launch" format:"02/01/2006"`
+		Cutoff  *time.Time `yaml:"cutoff" format:"2006-01-02 15:04"`
+		Holiday time.Time  `yaml:"holiday" format:"2006-01-02"`
+		Review  time.Time  `yaml:"review"`
+		Zoned   time.Time  `yaml:"zoned" format:"2006-01-02T15:04Z07:00"`
+	}
+	doc := []byte(`
+launch: "${TIMEFMT_LAUNCH}"
+cutoff: "2024-03-01 18:30"
+holiday: "2024-12-25"
+review: "2024-06-01 09:00:00"
+zoned: "2024-06-01T09:00+02:00"
+`)
+	var cfg schedule
+	require.NoError(t, jenv.UnmarshalYAML(doc, &cfg))
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), cfg.Launch)
+	require.NotNil(t, cfg.Cutoff)
+	assert.Equal(t, time.Date(2024, 3, 1, 18, 30, 0, 0, time.UTC), *cfg.Cutoff)
+
+	berlin := time.FixedZone("CET", 3600)
+	require.NoError(t, jenv.UnmarshalYAML(doc, &cfg, jenv.WithLocation(berlin)))
+	assert.Equal(t, time.Date(2024, 12, 25, 0, 0, 0, 0, berlin), cfg.Holiday)
+	assert.True(t, time.Date(2024, 6, 1, 9, 0, 0, 0, berlin).Equal(cfg.Review))
+	assert.True(t, time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC).Equal(cfg.Zoned), "explicit zones win")
+
+	err := jenv.UnmarshalYAML([]byte(`holiday: "25.12.2024"`), &cfg)
+	assert.ErrorContains(t, err, `error decoding 'holiday': "25.12.2024" does not match layout "2006-01-02"`)
+}
+
+func TestSliceFromString(t *testing.T) {
+	t.Setenv("SPLIT_HOSTS", "a.internal, b.internal")
+	t.Setenv("SPLIT_PORTS", "80;443")
+	t.Setenv("SPLIT_LITERAL", "x,${NOT_EXPANDED}")
+	type config struct {
+		Hosts   []string  `yaml:"hosts"`
+		Ports   []int     `yaml:"ports" sep:";"`
+		Pair    [2]string `yaml:"pair" sep:"|"`
+		Literal []string  `yaml:"literal"`
+		Empty   []string  `yaml:"empty"`
+		Tags    []string  `yaml:"tags" default:"x,y"`
+	}
+	var cfg config
+	require.NoError(t, jenv.UnmarshalYAML([]byte(`
+hosts: "${SPLIT_HOSTS}"
+ports: "${SPLIT_PORTS}"
+pair: "left|right"
+literal: "${SPLIT_LITERAL}"
+empty: "${SPLIT_UNSET}"
+`), &cfg))
+	assert.Equal(t, config{
+		Hosts:   []string{"a.internal", "b.internal"},
+		Ports:   []int{80, 443},
+		Pair:    [2]string{"left", "right"},
+		Literal: []string{"x", "${NOT_EXPANDED}"},
+		Empty:   []string{},
+		Tags:    []string{"x", "y"},
+	}, cfg)
+
+	err := jenv.UnmarshalYAML([]byte(`ports: "80,443"`), &cfg)
+	assert.ErrorContains(t, err, `parsing "80,443": invalid syntax`, "the sep tag applies")
+}
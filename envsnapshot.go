@@ -0,0 +1,87 @@
+package jenv
+
+import (
+	"context"
+	"maps"
+	"os"
+	"reflect"
+)
+
+// EnvSnapshot holds the environment variables a decode read, by name.
+// Variables that were unset are absent.
+type EnvSnapshot map[string]string
+
+// lookupEnv reads an environment variable for a placeholder, through the
+// decode's envView.
+func (d *decoder) lookupEnv(name string) (string, bool) {
+	val, ok := d.envView().lookup(name)
+	if d.envObserver != nil {
+		d.envObserver(name, val, ok)
+	}
+	if ok && d.snapshotEnv {
+		for _, res := range d.results {
+			if res.Env == nil {
+				res.Env = EnvSnapshot{}
+			}
+			res.Env[name] = val
+		}
+	}
+	return val, ok
+}
+
+// envView is where a decode reads environment variables from. Overlays
+// attached to the decode context with WithEnv come first; then a replayed
+// snapshot takes precedence over the WithEnvSource source or the process
+// environment. An overridden Getenv is honoured, though it cannot tell
+// unset from empty.
+type envView struct {
+	overlay map[string]string
+	replay  EnvSnapshot
+	opts    *options
+}
+
+func (d *decoder) envView() envView {
+	overlay, _ := d.ctx.Value(envOverlayKey{}).(map[string]string)
+	return envView{overlay: overlay, replay: d.envReplay, opts: d.options}
+}
+
+func (v envView) lookup(name string) (string, bool) {
+	if val, ok := v.overlay[name]; ok {
+		return val, true
+	}
+	if v.replay != nil {
+		val, ok := v.replay[name]
+		return val, ok
+	}
+	return v.opts.readEnv(name)
+}
+
+// processEnv reads name with os.LookupEnv, or through Getenv when the
+// application replaced it.
+func processEnv(name string) (string, bool) {
+	if getenvOverridden() {
+		val := Getenv(name)
+		return val, val != ""
+	}
+	return os.LookupEnv(name)
+}
+
+// getenvOverridden reports whether Getenv was replaced by the application.
+func getenvOverridden() bool {
+	return reflect.ValueOf(Getenv).Pointer() != reflect.ValueOf(getenv).Pointer()
+}
+
+type envOverlayKey struct{}
+
+// WithEnv returns a context whose decodes (see WithContext) resolve env
+// placeholders from vars before the process environment, e.g. to decode a
+// document for one tenant, or in parallel tests, without touching global
+// state. Overlays nest, inner values winning.
+func WithEnv(ctx context.Context, vars map[string]string) context.Context {
+	merged := map[string]string{}
+	if parent, _ := ctx.Value(envOverlayKey{}).(map[string]string); parent != nil {
+		maps.Copy(merged, parent)
+	}
+	maps.Copy(merged, vars)
+	return context.WithValue(ctx, envOverlayKey{}, merged)
+}
@@ -0,0 +1,67 @@
+package jenv_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestEnvSnapshot(t *testing.T) {
+	type service struct {
+		Host  string `json:"host"`
+		Port  int    `json:"port"`
+		Debug string `json:"debug"`
+	}
+	doc := []byte(`{"host": "${SNAP_HOST}", "port": "${SNAP_PORT:8080}", "debug": "${SNAP_DEBUG}"}`)
+	t.Setenv("SNAP_HOST", "db.internal")
+	t.Setenv("SNAP_DEBUG", "")
+
+	var res jenv.Result
+	var cfg service
+	require.NoError(t, jenv.UnmarshalJSON(doc, &cfg, jenv.WithResult(&res), jenv.WithEnvSnapshot()))
+	assert.Equal(t, jenv.EnvSnapshot{"SNAP_HOST": "db.internal", "SNAP_DEBUG": ""}, res.Env)
+
+	t.Setenv("SNAP_HOST", "db2.internal")
+	t.Setenv("SNAP_PORT", "9090")
+	var replayed service
+	require.NoError(t, jenv.UnmarshalJSON(doc, &replayed, jenv.WithEnvReplay(res.Env)))
+	assert.Equal(t, cfg, replayed)
+	assert.Equal(t, service{Host: "db.internal", Port: 8080}, replayed)
+
+	var plain jenv.Result
+	require.NoError(t, jenv.UnmarshalJSON(doc, &cfg, jenv.WithResult(&plain)))
+	assert.Nil(t, plain.Env, "snapshots are opt-in")
+}
+
+func TestWithEnv(t *testing.T) {
+	type tenant struct {
+		Name   string `json:"name"`
+		Region string `json:"region"`
+		Tier   string `json:"tier"`
+	}
+	doc := []byte(`{"name": "${TENANT_NAME}", "region": "${TENANT_REGION:us}", "tier": "${TENANT_TIER}"}`)
+	t.Setenv("TENANT_TIER", "free")
+
+	base := jenv.WithEnv(context.Background(), map[string]string{"TENANT_NAME": "acme", "TENANT_REGION": "eu"})
+	var wg sync.WaitGroup
+	results := make([]tenant, 2)
+	for i, ctx := range []context.Context{base, jenv.WithEnv(base, map[string]string{"TENANT_NAME": "globex", "TENANT_TIER": "gold"})} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, jenv.UnmarshalJSON(doc, &results[i], jenv.WithContext(ctx)))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, tenant{Name: "acme", Region: "eu", Tier: "free"}, results[0])
+	assert.Equal(t, tenant{Name: "globex", Region: "eu", Tier: "gold"}, results[1])
+
+	var plain tenant
+	require.NoError(t, jenv.UnmarshalJSON(doc, &plain))
+	assert.Equal(t, tenant{Region: "us", Tier: "free"}, plain)
+}
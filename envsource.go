@@ -0,0 +1,66 @@
+package jenv
+
+import "os"
+
+// EnvSource supplies the environment variables a decode reads. Sources
+// are passed per decode with WithEnvSource, so concurrent decodes, and
+// parallel tests, can each see their own environment.
+type EnvSource interface {
+	LookupEnv(name string) (string, bool)
+}
+
+// EnvSourceFunc adapts an ordinary function, such as os.LookupEnv, to the
+// EnvSource interface.
+type EnvSourceFunc func(name string) (string, bool)
+
+func (f EnvSourceFunc) LookupEnv(name string) (string, bool) {
+	return f(name)
+}
+
+// EnvMap is an EnvSource serving the variables it holds.
+type EnvMap map[string]string
+
+func (m EnvMap) LookupEnv(name string) (string, bool) {
+	val, ok := m[name]
+	return val, ok
+}
+
+// OSEnv returns the process environment as an EnvSource. Unlike the
+// default, it ignores the package's Getenv variable.
+func OSEnv() EnvSource {
+	return EnvSourceFunc(os.LookupEnv)
+}
+
+// ChainEnv returns an EnvSource that looks a variable up in each source in
+// turn, the first that has it set winning, e.g.
+// ChainEnv(EnvMap{"PORT": "8080"}, OSEnv()) for overrides on top of the
+// process environment.
+func ChainEnv(sources ...EnvSource) EnvSource {
+	return EnvSourceFunc(func(name string) (string, bool) {
+		for _, src := range sources {
+			if val, ok := src.LookupEnv(name); ok {
+				return val, true
+			}
+		}
+		return "", false
+	})
+}
+
+// WithEnvSource reads environment variables, for placeholders, `env` tags
+// and LoadEnv, from src instead of the process environment. Overlays
+// attached with WithEnv and snapshots replayed with WithEnvReplay still
+// come first.
+func WithEnvSource(src EnvSource) Option {
+	return func(o *options) {
+		o.envSource = src
+	}
+}
+
+// readEnv reads name from the decode's EnvSource, or from the process
+// environment through the package variables when there is none.
+func (o *options) readEnv(name string) (string, bool) {
+	if o.envSource != nil {
+		return o.envSource.LookupEnv(name)
+	}
+	return processEnv(name)
+}
@@ -0,0 +1,51 @@
+package jenv_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestEnvSource(t *testing.T) {
+	t.Setenv("ENVSOURCE_HOST", "from-process")
+	type config struct {
+		Host  string `yaml:"host"`
+		Port  int    `yaml:"port" env:"ENVSOURCE_PORT"`
+		Token string `yaml:"token"`
+	}
+	doc := []byte("host: ${ENVSOURCE_HOST:localhost}\ntoken: ${ENVSOURCE_TOKEN}\n")
+
+	var cfg config
+	require.NoError(t, jenv.UnmarshalYAML(doc, &cfg, jenv.WithEnvSource(jenv.EnvMap{"ENVSOURCE_PORT": "8080"})))
+	assert.Equal(t, config{Host: "localhost", Port: 8080}, cfg, "the process environment is not consulted")
+
+	cfg = config{}
+	src := jenv.ChainEnv(jenv.EnvMap{"ENVSOURCE_TOKEN": "t0k"}, jenv.OSEnv())
+	require.NoError(t, jenv.UnmarshalYAML(doc, &cfg, jenv.WithEnvSource(src)))
+	assert.Equal(t, config{Host: "from-process", Token: "t0k"}, cfg)
+
+	var lenv struct {
+		Host string `json:"host"`
+	}
+	require.NoError(t, jenv.LoadEnv(&lenv, jenv.WithEnvPrefix("SVC_"), jenv.WithEnvSource(jenv.EnvMap{"SVC_HOST": "db"})))
+	assert.Equal(t, "db", lenv.Host)
+
+	// Concurrent decodes each see their own environment.
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var cfg config
+			port := fmt.Sprint(9000 + i)
+			assert.NoError(t, jenv.UnmarshalYAML(doc, &cfg, jenv.WithEnvSource(jenv.EnvMap{"ENVSOURCE_PORT": port})))
+			assert.Equal(t, 9000+i, cfg.Port)
+		}()
+	}
+	wg.Wait()
+}
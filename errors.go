@@ -0,0 +1,92 @@
+package jenv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrMissingEnvVar is matched by errors.Is when a value refers to an
+	// environment variable that is not set and must be: a `${VAR!}` or
+	// `${VAR?}` placeholder, or any placeholder under WithStrict.
+	ErrMissingEnvVar = errors.New("environment variable is not set")
+	// ErrUnsupportedType is matched by errors.Is when a field's type
+	// cannot be decoded, such as a channel or func.
+	ErrUnsupportedType = errors.New("unsupported field type")
+	// ErrRequired is matched by errors.Is when a field tagged
+	// `required:"true"` has no value.
+	ErrRequired = errors.New("required field is not set")
+	// ErrKeyNotFound is matched by errors.Is when Get reads a key the
+	// document does not have.
+	ErrKeyNotFound = errors.New("key not found")
+)
+
+// FieldError reports a field that failed to decode: its full document
+// path, such as "database.ports.replica" or "hosts[2]", the Go type it
+// was decoding into, the raw value it was decoded from, when there was
+// one, and why.
+type FieldError struct {
+	Path     string
+	Expected string
+	Got      any
+	Cause    error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("error decoding '%s': %v", e.Path, e.Cause)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Cause
+}
+
+// DecodeErrors lists every field that failed to decode under
+// WithAllErrors, in the order they were decoded.
+type DecodeErrors struct {
+	Errors []error
+}
+
+func (e *DecodeErrors) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		var fe *FieldError
+		switch {
+		case !errors.As(err, &fe):
+			msgs[i] = err.Error()
+		case fe.Got == nil:
+			msgs[i] = fmt.Sprintf("%s: %v", fe.Path, fe.Cause)
+		default:
+			msgs[i] = fmt.Sprintf("%s: %v (got %#v)", fe.Path, fe.Cause, fe.Got)
+		}
+	}
+	fields := "fields"
+	if len(e.Errors) == 1 {
+		fields = "field"
+	}
+	return fmt.Sprintf("%d %s failed to decode:\n\t%s", len(e.Errors), fields, strings.Join(msgs, "\n\t"))
+}
+
+// Unwrap lets errors.Is and errors.As match any of the errors.
+func (e *DecodeErrors) Unwrap() []error {
+	return e.Errors
+}
+
+// WithAllErrors keeps decoding past fields that fail, and then reports
+// every one of them in a *DecodeErrors, so a large configuration can be
+// fixed in one pass.
+func WithAllErrors() Option {
+	return func(o *options) {
+		o.allErrors = true
+	}
+}
+
+// fail returns err, or records it and returns nil under WithAllErrors so
+// decoding carries on.
+func (d *decoder) fail(err error) error {
+	if !d.allErrors {
+		return err
+	}
+	d.errs = append(d.errs, err)
+	return nil
+}
@@ -0,0 +1,96 @@
+package jenv_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestAllErrors(t *testing.T) {
+	type database struct {
+		Host string `yaml:"host" required:"true"`
+		Port int    `yaml:"port"`
+	}
+	type config struct {
+		Name     string        `yaml:"name"`
+		Timeout  time.Duration `yaml:"timeout"`
+		Database database      `yaml:"database"`
+		Workers  uint8         `yaml:"workers"`
+		Region   string        `yaml:"region" required:"true"`
+	}
+	doc := []byte(`
+name: api
+timeout: soon
+database:
+  port: "${ALLERRORS_PORT:fivefour}"
+workers: 300
+`)
+	var cfg config
+	err := jenv.UnmarshalYAML(doc, &cfg)
+	require.Error(t, err)
+	assert.NotErrorAs(t, err, new(*jenv.DecodeErrors), "without the option the first error is returned")
+
+	cfg = config{}
+	err = jenv.UnmarshalYAML(doc, &cfg, jenv.WithAllErrors())
+	var errs *jenv.DecodeErrors
+	require.ErrorAs(t, err, &errs)
+	var paths []string
+	for _, err := range errs.Errors {
+		var fe *jenv.FieldError
+		require.True(t, errors.As(err, &fe))
+		paths = append(paths, fe.Path)
+	}
+	assert.Equal(t, []string{"timeout", "database.host", "database.port", "workers", "region"}, paths)
+	assert.Equal(t, "api", cfg.Name, "valid fields are still decoded")
+
+	var fe *jenv.FieldError
+	require.ErrorAs(t, errs.Errors[2], &fe)
+	assert.Equal(t, "${ALLERRORS_PORT:fivefour}", fe.Got)
+	assert.Contains(t, err.Error(), "5 fields failed to decode:\n\ttimeout: ")
+	assert.Contains(t, err.Error(), "\n\tregion: required field is not set")
+}
+
+func TestTypedErrors(t *testing.T) {
+	type ports struct {
+		Primary int `yaml:"primary"`
+		Replica int `yaml:"replica"`
+	}
+	type database struct {
+		Host  string `yaml:"host"`
+		Ports ports  `yaml:"ports"`
+	}
+	type config struct {
+		Database database       `yaml:"database"`
+		Hosts    []string       `yaml:"hosts"`
+		Notify   chan string    `yaml:"notify"`
+		Limits   map[string]int `yaml:"limits"`
+	}
+	var cfg config
+	err := jenv.UnmarshalYAML([]byte("database:\n  ports:\n    replica: five\n"), &cfg)
+	var fe *jenv.FieldError
+	require.ErrorAs(t, err, &fe)
+	assert.Equal(t, "database.ports.replica", fe.Path)
+	assert.Equal(t, "int", fe.Expected)
+	assert.Equal(t, "five", fe.Got)
+	assert.EqualError(t, err, `error decoding 'database.ports.replica': strconv.Atoi: parsing "five": invalid syntax`)
+
+	err = jenv.UnmarshalYAML([]byte("limits:\n  burst: lots\n"), &cfg)
+	require.ErrorAs(t, err, &fe)
+	assert.Equal(t, "limits.burst", fe.Path)
+
+	err = jenv.UnmarshalYAML([]byte("hosts: [a, \"${TYPED_ERRORS_UNSET}\"]\n"), &cfg, jenv.WithStrict())
+	assert.ErrorIs(t, err, jenv.ErrMissingEnvVar)
+	require.ErrorAs(t, err, &fe)
+	assert.Equal(t, "hosts[1]", fe.Path)
+
+	err = jenv.UnmarshalYAML([]byte("notify: events\n"), &cfg)
+	assert.ErrorIs(t, err, jenv.ErrUnsupportedType)
+
+	err = jenv.UnmarshalYAML([]byte("database:\n  host: \"${TYPED_ERRORS_HOST!}\"\n"), &cfg)
+	assert.ErrorIs(t, err, jenv.ErrMissingEnvVar, "missing required variables match too")
+}
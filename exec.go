@@ -0,0 +1,105 @@
+package jenv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// execScheme is the placeholder scheme running a command, as in
+// `${exec:pass show db/password}`.
+const execScheme = "exec"
+
+// WithExec enables `${exec:command args...}` placeholders, which resolve
+// to the trimmed standard output of the command. Only the listed commands
+// may run, matched exactly against the first word, so `WithExec("pass")`
+// allows `${exec:pass show db/password}` but not `${exec:/usr/bin/pass ...}`.
+// The command runs without a shell; arguments are split on spaces and may
+// be quoted. Without this option exec placeholders are an error, so
+// documents from untrusted sources cannot run commands.
+func WithExec(commands ...string) Option {
+	return func(o *options) {
+		o.execCommands = append(o.execCommands, commands...)
+	}
+}
+
+// resolveExec runs the command line of an exec placeholder. Its output is
+// treated as a secret.
+func (d *decoder) resolveExec(cmdline, path string) (string, error) {
+	d.recordDependency(path, "secret", execScheme+":"+cmdline)
+	d.recordSecretValue()
+	args, err := splitCommand(cmdline)
+	if err != nil {
+		return "", fmt.Errorf("error resolving '%s:%s': %w", execScheme, cmdline, err)
+	}
+	if len(args) == 0 {
+		return "", fmt.Errorf("error resolving '%s:': empty command", execScheme)
+	}
+	if !slices.Contains(d.execCommands, args[0]) {
+		if len(d.execCommands) == 0 {
+			return "", fmt.Errorf("error resolving '%s:%s': exec placeholders are disabled; enable them with WithExec", execScheme, cmdline)
+		}
+		return "", fmt.Errorf("error resolving '%s:%s': command '%s' is not allowed", execScheme, cmdline, args[0])
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(d.resolveCtx, args[0], args[1:]...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", fmt.Errorf("error resolving '%s:%s': %w", execScheme, cmdline, err)
+	}
+	d.recordSource(path, execScheme)
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// splitCommand splits a command line into words. Single and double
+// quotes group words containing spaces; a backslash escapes the next
+// character outside single quotes.
+func splitCommand(s string) ([]string, error) {
+	var (
+		args    []string
+		word    strings.Builder
+		inWord  bool
+		quote   byte
+		escaped bool
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			word.WriteByte(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				word.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote, inWord = c, true
+		case c == ' ' || c == '\t':
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or escape")
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
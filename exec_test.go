@@ -0,0 +1,34 @@
+package jenv_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestExecPlaceholders(t *testing.T) {
+	var cfg struct {
+		Password string `json:"password"`
+		Quoted   string `json:"quoted"`
+	}
+	doc := []byte(`{"password": "${exec:echo s3cret}", "quoted": "${exec:printf '%s-%s' 'a b' c}"}`)
+
+	err := jenv.UnmarshalJSON(doc, &cfg)
+	assert.ErrorContains(t, err, "exec placeholders are disabled")
+
+	err = jenv.UnmarshalJSON(doc, &cfg, jenv.WithExec("echo"))
+	assert.ErrorContains(t, err, "command 'printf' is not allowed")
+
+	var res jenv.Result
+	require.NoError(t, jenv.UnmarshalJSON(doc, &cfg, jenv.WithExec("echo", "printf"), jenv.WithResult(&res)))
+	assert.Equal(t, "s3cret", cfg.Password)
+	assert.Equal(t, "a b-c", cfg.Quoted)
+	assert.Equal(t, "exec", res.Sources["password"])
+	assert.Equal(t, "[REDACTED]", jenv.Redact(&cfg, &res).(map[string]any)["password"])
+
+	err = jenv.UnmarshalJSON([]byte(`{"password": "${exec:false}"}`), &cfg, jenv.WithExec("false"))
+	assert.ErrorContains(t, err, "error resolving 'exec:false'")
+}
@@ -0,0 +1,10 @@
+package jenv
+
+// ExpandString resolves placeholders in s exactly as a decode would for a
+// string field: env vars with their defaults, secret store references and
+// the resolve policies, cache and strictness set through opts.
+func ExpandString(s string, opts ...Option) (string, error) {
+	d := newDecoder(opts)
+	defer d.close()
+	return d.getEnv(s, "")
+}
@@ -0,0 +1,50 @@
+package jenv_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestExpandString(t *testing.T) {
+	t.Setenv("EXPAND_HOST", "db.internal")
+	jenv.RegisterSecretStore("expandtest", mapStore{"db/password": "s3cret"})
+	defer jenv.UnregisterSecretStore("expandtest")
+
+	tests := map[string]string{
+		"${EXPAND_HOST}":              "db.internal",
+		"${EXPAND_UNSET:localhost}":   "localhost",
+		"${EXPAND_UNSET}":             "",
+		"${expandtest:db/password}":   "s3cret",
+		"${expandtest:missing:-none}": "none",
+		"plain value":                 "plain value",
+	}
+	for in, want := range tests {
+		got, err := jenv.ExpandString(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	_, err := jenv.ExpandString("${EXPAND_UNSET}", jenv.WithStrict())
+	assert.EqualError(t, err, "error resolving 'EXPAND_UNSET': environment variable is not set")
+	got, err := jenv.ExpandString("${EXPAND_UNSET:}", jenv.WithStrict())
+	require.NoError(t, err)
+	assert.Equal(t, "", got)
+
+	_, err = jenv.ExpandString("${expandtest:missing}", jenv.WithContext(context.Background()), jenv.WithResolvePolicy("*", jenv.PolicyFail))
+	assert.Error(t, err)
+}
+
+func TestWithStrict(t *testing.T) {
+	type service struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+	var cfg service
+	err := jenv.UnmarshalJSON([]byte(`{"host": "${STRICT_HOST}", "port": "${STRICT_PORT:8080}"}`), &cfg, jenv.WithStrict())
+	assert.ErrorContains(t, err, "error decoding 'host': error resolving 'STRICT_HOST': environment variable is not set")
+}
@@ -0,0 +1,103 @@
+package jenv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// HealthChecker is implemented by secret stores that can report whether
+// their back-end is currently usable.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// NamedStore is a SecretStore with the name recorded as its source.
+type NamedStore struct {
+	Name  string
+	Store SecretStore
+}
+
+// FallbackStore tries its stores in order and returns the first value
+// found, e.g. Vault, then a local cache. Stores implementing HealthChecker
+// are skipped while unhealthy; their health is re-checked at most once per
+// HealthInterval.
+type FallbackStore struct {
+	// HealthInterval is how long a health check result is trusted;
+	// defaults to 30s.
+	HealthInterval time.Duration
+
+	stores []NamedStore
+	mu     sync.Mutex
+	health []healthState
+}
+
+type healthState struct {
+	err     error
+	checked time.Time
+}
+
+// NewFallbackStore returns a store trying stores in the given order.
+func NewFallbackStore(stores ...NamedStore) *FallbackStore {
+	return &FallbackStore{stores: stores, health: make([]healthState, len(stores))}
+}
+
+func (f *FallbackStore) Get(ctx context.Context, ref string) (string, error) {
+	val, _, err := f.GetWithSource(ctx, ref)
+	return val, err
+}
+
+// GetWithSource resolves ref and also returns the name of the store that
+// answered.
+func (f *FallbackStore) GetWithSource(ctx context.Context, ref string) (string, string, error) {
+	var errs []error
+	for i, s := range f.stores {
+		if err := f.checkHealth(ctx, i); err != nil {
+			errs = append(errs, fmt.Errorf("%s: unhealthy: %w", s.Name, err))
+			continue
+		}
+		val, err := s.Store.Get(ctx, ref)
+		if err == nil {
+			return val, s.Name, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", s.Name, err))
+	}
+	if len(errs) == 0 {
+		return "", "", ErrSecretNotFound
+	}
+	return "", "", errors.Join(errs...)
+}
+
+// Healthy reports the health of each store by name, running checks that
+// are due.
+func (f *FallbackStore) Healthy(ctx context.Context) map[string]error {
+	status := make(map[string]error, len(f.stores))
+	for i, s := range f.stores {
+		status[s.Name] = f.checkHealth(ctx, i)
+	}
+	return status
+}
+
+func (f *FallbackStore) checkHealth(ctx context.Context, i int) error {
+	hc, ok := f.stores[i].Store.(HealthChecker)
+	if !ok {
+		return nil
+	}
+	interval := f.HealthInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	f.mu.Lock()
+	state := f.health[i]
+	f.mu.Unlock()
+	if !state.checked.IsZero() && time.Since(state.checked) < interval {
+		return state.err
+	}
+	err := hc.HealthCheck(ctx)
+	f.mu.Lock()
+	f.health[i] = healthState{err: err, checked: time.Now()}
+	f.mu.Unlock()
+	return err
+}
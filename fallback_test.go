@@ -0,0 +1,49 @@
+package jenv_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oarkflow/jenv"
+)
+
+type unhealthyStore struct {
+	mapStore
+	checks int
+}
+
+func (s *unhealthyStore) HealthCheck(context.Context) error {
+	s.checks++
+	return errors.New("connection refused")
+}
+
+func TestFallbackStore(t *testing.T) {
+	vault := &unhealthyStore{mapStore: mapStore{"db/password": "from-vault"}}
+	cache := mapStore{"db/password": "from-cache"}
+	jenv.RegisterSecretStore("secret", jenv.NewFallbackStore(
+		jenv.NamedStore{Name: "vault", Store: vault},
+		jenv.NamedStore{Name: "cache", Store: cache},
+	))
+	defer jenv.UnregisterSecretStore("secret")
+
+	var cfg struct {
+		Password string `json:"password"`
+		APIKey   string `json:"api_key"`
+	}
+	var res jenv.Result
+	err := jenv.UnmarshalJSON([]byte(`{
+		"password": "${secret:db/password}",
+		"api_key": "${secret:api/key:-dev-key}"
+	}`), &cfg, jenv.WithResult(&res))
+	assert.NoError(t, err)
+	assert.Equal(t, "from-cache", cfg.Password)
+	assert.Equal(t, "dev-key", cfg.APIKey)
+	assert.Equal(t, map[string]string{"password": "cache", "api_key": "default"}, res.Sources)
+	assert.Equal(t, 1, vault.checks, "health result should be reused within the interval")
+
+	err = jenv.UnmarshalJSON([]byte(`{"password": "${secret:missing}"}`), &cfg)
+	assert.ErrorIs(t, err, jenv.ErrSecretNotFound)
+}
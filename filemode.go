@@ -0,0 +1,104 @@
+package jenv
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var fileModeType = reflect.TypeOf(os.FileMode(0))
+
+// getEnvValueFileMode decodes an os.FileMode. Strings are read as octal
+// ("0644", "0o644", "644") or in ls notation ("rw-r--r--"); numbers are
+// taken as the mode's value, which is what YAML produces for an unquoted
+// 0644.
+func (d *decoder) getEnvValueFileMode(rawValue any, path string) (os.FileMode, error) {
+	switch v := rawValue.(type) {
+	case int:
+		return numericFileMode(float64(v))
+	case float64:
+		return numericFileMode(v)
+	}
+	val, err := d.getEnv(rawValue, path)
+	if err != nil {
+		return 0, err
+	}
+	if val == "" {
+		return 0, nil
+	}
+	mode, err := ParseFileMode(val)
+	if err != nil {
+		return 0, err
+	}
+	return mode, nil
+}
+
+func numericFileMode(v float64) (os.FileMode, error) {
+	if v < 0 || v > 0o7777 || v != float64(int(v)) {
+		return 0, fmt.Errorf("invalid file mode %v", v)
+	}
+	return fileModeBits(uint32(v)), nil
+}
+
+// fileModeBits maps the setuid, setgid and sticky bits of a Unix mode
+// onto their os.FileMode flags.
+func fileModeBits(unix uint32) os.FileMode {
+	mode := os.FileMode(unix & 0o777)
+	if unix&0o4000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if unix&0o2000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if unix&0o1000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+// ParseFileMode parses a permission mode written in octal ("0644",
+// "0o2755") or ls notation ("rw-r--r--", "-rwxr-xr-x", "rwsr-xr-t").
+func ParseFileMode(s string) (os.FileMode, error) {
+	s = strings.TrimSpace(s)
+	if strings.ContainsAny(s, "-rwxsStT") {
+		if len(s) == 10 {
+			return parseSymbolicMode(s[1:])
+		}
+		return parseSymbolicMode(s)
+	}
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "0o"), "0O")
+	n, err := strconv.ParseUint(digits, 8, 32)
+	if err != nil || n > 0o7777 {
+		return 0, fmt.Errorf("invalid file mode %q", s)
+	}
+	return fileModeBits(uint32(n)), nil
+}
+
+func parseSymbolicMode(s string) (os.FileMode, error) {
+	if len(s) != 9 {
+		return 0, fmt.Errorf("invalid file mode %q", s)
+	}
+	var unix uint32
+	for i, c := range s {
+		bit := uint32(1) << (8 - i)
+		switch want := "rwxrwxrwx"[i]; {
+		case c == '-':
+		case c == rune(want):
+			unix |= bit
+		case want == 'x' && i == 2 && (c == 's' || c == 'S'):
+			unix |= 0o4000
+		case want == 'x' && i == 5 && (c == 's' || c == 'S'):
+			unix |= 0o2000
+		case want == 'x' && i == 8 && (c == 't' || c == 'T'):
+			unix |= 0o1000
+		default:
+			return 0, fmt.Errorf("invalid file mode %q", s)
+		}
+		if c == 's' || c == 't' {
+			unix |= bit
+		}
+	}
+	return fileModeBits(unix), nil
+}
@@ -0,0 +1,55 @@
+package jenv_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestFileMode(t *testing.T) {
+	type output struct {
+		LogMode    os.FileMode `yaml:"log_mode"`
+		DirMode    os.FileMode `yaml:"dir_mode"`
+		SocketMode os.FileMode `yaml:"socket_mode"`
+		KeyMode    os.FileMode `yaml:"key_mode"`
+	}
+	t.Setenv("KEY_MODE", "0600")
+	var cfg output
+	require.NoError(t, jenv.UnmarshalYAML([]byte(`
+log_mode: 0644
+dir_mode: "0o2755"
+socket_mode: rw-rw----
+key_mode: "${KEY_MODE}"
+`), &cfg))
+	assert.Equal(t, os.FileMode(0o644), cfg.LogMode)
+	assert.Equal(t, os.FileMode(0o755)|os.ModeSetgid, cfg.DirMode)
+	assert.Equal(t, os.FileMode(0o660), cfg.SocketMode)
+	assert.Equal(t, os.FileMode(0o600), cfg.KeyMode)
+
+	err := jenv.UnmarshalYAML([]byte(`log_mode: "0999"`), &cfg)
+	assert.ErrorContains(t, err, `error decoding 'log_mode': invalid file mode "0999"`)
+}
+
+func TestParseFileMode(t *testing.T) {
+	tests := map[string]os.FileMode{
+		"644":        0o644,
+		"0755":       0o755,
+		"-rwxr-xr-x": 0o755,
+		"rwsr-x---":  0o750 | os.ModeSetuid,
+		"rwxrwxrwt":  0o777 | os.ModeSticky,
+		"rw-r--r-T":  0o644 | os.ModeSticky,
+	}
+	for s, want := range tests {
+		got, err := jenv.ParseFileMode(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, want, got, s)
+	}
+	for _, s := range []string{"rwxrwxrwz", "8", "017777", "r-x"} {
+		_, err := jenv.ParseFileMode(s)
+		assert.Error(t, err, s)
+	}
+}
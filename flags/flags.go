@@ -0,0 +1,122 @@
+// Package flags provides feature flags declared in jenv configuration and
+// evaluated against a live-reloaded document, so toggles can be flipped by
+// editing config rather than through a separate flag service.
+//
+// Flags are declared under a `flags:` key:
+//
+//	flags:
+//	  new_checkout:
+//	    enabled: true
+//	  beta_search:
+//	    enabled: true
+//	    rollout: 25%
+//
+// A flag with a rollout is on for that share of keys. The key (typically a
+// user or tenant ID) is attached to the context with WithKey; the same key
+// always lands in the same bucket for a given flag.
+package flags
+
+import (
+	"context"
+	"hash/fnv"
+	"sync/atomic"
+
+	"github.com/oarkflow/jenv"
+)
+
+// Flag is the definition of a single feature flag.
+type Flag struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Rollout is the share of keys the flag is on for, from 0 to 1. When
+	// unset an enabled flag is on for everyone.
+	Rollout *float64 `json:"rollout,omitempty" yaml:"rollout,omitempty" percent:"1"`
+}
+
+// Set is a collection of flags by name. It can be embedded in an
+// application's own configuration struct.
+type Set map[string]Flag
+
+// Document is the configuration document read by New.
+type Document struct {
+	Flags Set `json:"flags" yaml:"flags"`
+}
+
+type keyContext struct{}
+
+// WithKey returns a context whose rollout evaluations are bucketed by key.
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyContext{}, key)
+}
+
+// Enabled reports whether the named flag is on for the key in ctx. Unknown
+// and disabled flags are off. A partially rolled-out flag is off when ctx
+// carries no key.
+func (s Set) Enabled(ctx context.Context, name string) bool {
+	f, ok := s[name]
+	if !ok || !f.Enabled {
+		return false
+	}
+	if f.Rollout == nil {
+		return true
+	}
+	if *f.Rollout >= 1 {
+		return true
+	}
+	key, ok := ctx.Value(keyContext{}).(string)
+	if !ok {
+		return false
+	}
+	return bucket(name, key) < *f.Rollout
+}
+
+// bucket maps name and key to a stable position in [0, 1).
+func bucket(name, key string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	return float64(h.Sum32()%10000) / 10000
+}
+
+// Flags evaluates flags against a live configuration document.
+type Flags struct {
+	live *jenv.Live[Document]
+}
+
+// New loads the flag document from src. Call Live().Poll or Live().Reload
+// to pick up changes.
+func New(ctx context.Context, src jenv.Source, opts ...jenv.LiveOption) (*Flags, error) {
+	live, err := jenv.NewLive[Document](ctx, src, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Flags{live: live}, nil
+}
+
+// Live returns the underlying live configuration.
+func (f *Flags) Live() *jenv.Live[Document] {
+	return f.live
+}
+
+// Enabled reports whether the named flag is on for the key in ctx,
+// according to the most recently loaded document.
+func (f *Flags) Enabled(ctx context.Context, name string) bool {
+	return f.live.Get().Flags.Enabled(ctx, name)
+}
+
+var defaultFlags atomic.Pointer[Flags]
+
+// SetDefault makes f the flags consulted by the package-level Enabled.
+func SetDefault(f *Flags) {
+	defaultFlags.Store(f)
+}
+
+// Enabled reports whether the named flag is on in the default flags set
+// with SetDefault. Every flag is off until a default is set.
+func Enabled(ctx context.Context, name string) bool {
+	f := defaultFlags.Load()
+	if f == nil {
+		return false
+	}
+	return f.Enabled(ctx, name)
+}
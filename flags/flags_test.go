@@ -0,0 +1,63 @@
+package flags_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+	"github.com/oarkflow/jenv/flags"
+)
+
+func TestFlags(t *testing.T) {
+	var mu sync.Mutex
+	doc := `
+flags:
+  new_checkout:
+    enabled: true
+  beta_search:
+    enabled: true
+    rollout: 25%
+  old_ui:
+    enabled: false
+`
+	src := jenv.SourceFunc(func(ctx context.Context) ([]byte, string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return []byte(doc), "yaml", nil
+	})
+	ctx := context.Background()
+	f, err := flags.New(ctx, src)
+	require.NoError(t, err)
+
+	assert.False(t, flags.Enabled(ctx, "new_checkout"), "no default set")
+	flags.SetDefault(f)
+	t.Cleanup(func() { flags.SetDefault(nil) })
+
+	assert.True(t, flags.Enabled(ctx, "new_checkout"))
+	assert.False(t, flags.Enabled(ctx, "old_ui"))
+	assert.False(t, flags.Enabled(ctx, "unknown"))
+	assert.False(t, f.Enabled(ctx, "beta_search"), "rollout without key")
+
+	on := 0
+	for i := 0; i < 1000; i++ {
+		userCtx := flags.WithKey(ctx, fmt.Sprintf("user-%d", i))
+		enabled := f.Enabled(userCtx, "beta_search")
+		assert.Equal(t, enabled, f.Enabled(userCtx, "beta_search"), "bucketing must be stable")
+		if enabled {
+			on++
+		}
+	}
+	assert.InDelta(t, 250, on, 60)
+
+	mu.Lock()
+	doc = "flags:\n  old_ui:\n    enabled: true\n"
+	mu.Unlock()
+	require.NoError(t, f.Live().Reload(ctx))
+	assert.True(t, flags.Enabled(ctx, "old_ui"))
+	assert.False(t, flags.Enabled(ctx, "new_checkout"))
+}
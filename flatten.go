@@ -0,0 +1,203 @@
+package jenv
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Flatten converts a nested document into a flat map keyed by path, using
+// the same path syntax as decode errors and results: "service.timeout",
+// "database.hosts[0]". Empty maps and slices are kept as leaf values.
+func Flatten(m map[string]any) map[string]any {
+	flat := map[string]any{}
+	flattenInto(flat, "", m)
+	return flat
+}
+
+func flattenInto(flat map[string]any, prefix string, value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 0 && prefix != "" {
+			flat[prefix] = v
+		}
+		for k, child := range v {
+			flattenInto(flat, joinPath(prefix, k), child)
+		}
+	case []any:
+		if len(v) == 0 {
+			flat[prefix] = v
+		}
+		for i, child := range v {
+			flattenInto(flat, fmt.Sprintf("%s[%d]", prefix, i), child)
+		}
+	default:
+		flat[prefix] = value
+	}
+}
+
+// Unflatten is the inverse of Flatten. It fails when paths conflict, e.g.
+// "a" holding a value while "a.b" is also set, or when a list index is
+// 65536 or more.
+func Unflatten(flat map[string]any) (map[string]any, error) {
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var root any = map[string]any{}
+	for _, key := range keys {
+		segments, err := parsePath(key)
+		if err != nil {
+			return nil, err
+		}
+		root, err = setPath(root, segments, flat[key], key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return root.(map[string]any), nil
+}
+
+// pathSegment is a map key or, when index >= 0, a slice index.
+type pathSegment struct {
+	key   string
+	index int
+}
+
+// parsePath splits a path such as "servers[0].host" into segments.
+func parsePath(path string) ([]pathSegment, error) {
+	var segments []pathSegment
+	for _, part := range strings.Split(path, ".") {
+		key, rest, _ := strings.Cut(part, "[")
+		if key == "" && (rest == "" || len(segments) == 0) {
+			return nil, fmt.Errorf("invalid path %q", path)
+		}
+		if key != "" {
+			segments = append(segments, pathSegment{key: key, index: -1})
+		}
+		for rest != "" {
+			num, after, ok := strings.Cut(rest, "]")
+			i, err := strconv.Atoi(num)
+			if !ok || err != nil || i < 0 {
+				return nil, fmt.Errorf("invalid index in path %q", path)
+			}
+			segments = append(segments, pathSegment{index: i})
+			rest = strings.TrimPrefix(after, "[")
+			if after != "" && !strings.HasPrefix(after, "[") {
+				return nil, fmt.Errorf("invalid path %q", path)
+			}
+		}
+	}
+	return segments, nil
+}
+
+// String returns the segment as it is written after prefix in a path.
+func (seg pathSegment) String(prefix string) string {
+	if seg.index >= 0 {
+		return fmt.Sprintf("%s[%d]", prefix, seg.index)
+	}
+	return joinPath(prefix, seg.key)
+}
+
+// getPath returns the value at segments in node; see child.
+func getPath(node any, segments []pathSegment, fold bool) (any, bool) {
+	for _, seg := range segments {
+		var ok bool
+		if node, _, ok = child(node, seg, fold); !ok {
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+// child returns the value of node at seg, and seg as matched: a key also
+// indexes a list when it is a number, as in "servers.0", and with fold is
+// matched case-insensitively when no key matches exactly.
+func child(node any, seg pathSegment, fold bool) (any, pathSegment, bool) {
+	switch v := node.(type) {
+	case map[string]any:
+		if seg.index >= 0 {
+			return nil, seg, false
+		}
+		if next, ok := v[seg.key]; ok {
+			return next, seg, true
+		}
+		if fold {
+			if key, ok := foldKey(v, seg.key); ok {
+				return v[key], pathSegment{key: key, index: -1}, true
+			}
+		}
+	case []any:
+		if seg.index < 0 {
+			i, err := strconv.Atoi(seg.key)
+			if err != nil || i < 0 {
+				return nil, seg, false
+			}
+			seg = pathSegment{index: i}
+		}
+		if seg.index < len(v) {
+			return v[seg.index], seg, true
+		}
+	}
+	return nil, seg, false
+}
+
+// errPathConflict is returned by setPath when a value is in the way of a
+// path.
+var errPathConflict = errors.New("conflicting values")
+
+// maxPathIndex bounds the list indexes setPath accepts, since it grows
+// lists up to them.
+const maxPathIndex = 1 << 16
+
+// setPath stores value at segments in node, creating maps and lists on
+// the way, and returns the updated node. It fails when a value is in the
+// way of the path or the path would replace a map or list; a value
+// already at the path is replaced.
+func setPath(node any, segments []pathSegment, value any, path string) (any, error) {
+	if len(segments) == 0 {
+		switch node.(type) {
+		case map[string]any, []any:
+			return nil, fmt.Errorf("%w for path %q", errPathConflict, path)
+		}
+		return value, nil
+	}
+	seg := segments[0]
+	if seg.index >= 0 {
+		if node == nil {
+			node = []any{}
+		}
+		items, ok := node.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%w for path %q", errPathConflict, path)
+		}
+		if seg.index >= maxPathIndex {
+			return nil, fmt.Errorf("index %d in path %q is not below %d", seg.index, path, maxPathIndex)
+		}
+		for len(items) <= seg.index {
+			items = append(items, nil)
+		}
+		child, err := setPath(items[seg.index], segments[1:], value, path)
+		if err != nil {
+			return nil, err
+		}
+		items[seg.index] = child
+		return items, nil
+	}
+	if node == nil {
+		node = map[string]any{}
+	}
+	m, ok := node.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w for path %q", errPathConflict, path)
+	}
+	child, err := setPath(m[seg.key], segments[1:], value, path)
+	if err != nil {
+		return nil, err
+	}
+	m[seg.key] = child
+	return m, nil
+}
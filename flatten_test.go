@@ -0,0 +1,48 @@
+package jenv_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestFlattenUnflatten(t *testing.T) {
+	doc := map[string]any{
+		"service": map[string]any{"timeout": "5s", "labels": map[string]any{}},
+		"database": map[string]any{
+			"hosts":   []any{"a", "b"},
+			"servers": []any{map[string]any{"host": "x", "port": 1}},
+		},
+		"debug": true,
+	}
+	flat := jenv.Flatten(doc)
+	assert.Equal(t, map[string]any{
+		"service.timeout":          "5s",
+		"service.labels":           map[string]any{},
+		"database.hosts[0]":        "a",
+		"database.hosts[1]":        "b",
+		"database.servers[0].host": "x",
+		"database.servers[0].port": 1,
+		"debug":                    true,
+	}, flat)
+
+	nested, err := jenv.Unflatten(flat)
+	require.NoError(t, err)
+	assert.Equal(t, doc, nested)
+}
+
+func TestUnflattenConflicts(t *testing.T) {
+	_, err := jenv.Unflatten(map[string]any{"a": 1, "a.b": 2})
+	assert.Error(t, err)
+	_, err = jenv.Unflatten(map[string]any{"a[x]": 1})
+	assert.Error(t, err)
+	_, err = jenv.Unflatten(map[string]any{"a[1000000000]": 1})
+	assert.EqualError(t, err, `index 1000000000 in path "a[1000000000]" is not below 65536`)
+
+	nested, err := jenv.Unflatten(map[string]any{"a[2]": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": []any{nil, nil, 1}}, nested)
+}
@@ -0,0 +1,134 @@
+package jenv
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ParseFunc parses a document into the raw map placeholders are resolved
+// in and fields populated from: nested maps keyed by string, []any for
+// sequences, and strings, numbers and bools as scalars.
+type ParseFunc func(data []byte) (map[string]any, error)
+
+// formatParser parses a document for a decode, which carries options such
+// as the duplicate-key policy.
+type formatParser func(d *decoder, data []byte) (map[string]any, error)
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]formatParser{
+		"json": (*decoder).parseJSON,
+		"yaml": (*decoder).parseYAML,
+		"toml": parseTOML,
+
+		"ini":        parseINI,
+		"properties": parseProperties,
+	}
+	formatExtensions = map[string]string{
+		".json": "json",
+		".yaml": "yaml",
+		".yml":  "yaml",
+		".toml": "toml",
+
+		".ini":        "ini",
+		".properties": "properties",
+	}
+)
+
+// RegisterFormat makes Load, LoadFile, LoadDir and Live parse documents
+// with the given file extensions (such as ".ini") using parse, replacing
+// any format previously registered under name or for those extensions.
+// Formats register a ParseFunc rather than a function decoding into cfg,
+// as an earlier UnmarshalFunc did, so that LoadDir can merge documents of
+// different formats before decoding them.
+func RegisterFormat(name string, parse ParseFunc, extensions ...string) {
+	if name == "" {
+		panic("jenv: RegisterFormat name is empty")
+	}
+	if parse == nil {
+		panic("jenv: RegisterFormat parse is nil")
+	}
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[name] = func(_ *decoder, data []byte) (map[string]any, error) {
+		return parse(data)
+	}
+	delete(readerParsers, name)
+	for _, ext := range extensions {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		formatExtensions[strings.ToLower(ext)] = name
+	}
+}
+
+func documentFormat(uri string, data []byte) string {
+	if strings.HasPrefix(uri, "git+") {
+		if _, _, file, err := parseGitURI(uri); err == nil {
+			uri = file
+		}
+	} else if i := strings.IndexAny(uri, "?#"); i >= 0 && uriScheme(uri) != "" {
+		uri = uri[:i]
+	}
+	formatsMu.RLock()
+	format, ok := formatExtensions[strings.ToLower(path.Ext(uri))]
+	formatsMu.RUnlock()
+	if ok {
+		return format
+	}
+	return sniffFormat(data)
+}
+
+// tomlLine matches the first line of a TOML document: a table header or a
+// `key = value` assignment.
+var tomlLine = regexp.MustCompile(`^(\[\[?[\w."' -]+\]\]?|[\w."-]+\s*=)`)
+
+// sniffFormat guesses the format of a document without a known extension:
+// JSON if it parses as JSON, TOML if it starts like TOML, YAML otherwise.
+func sniffFormat(data []byte) string {
+	trimmed := bytes.TrimLeft(data, " \t\r\n\ufeff")
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[' && json.Valid(trimmed)) {
+		return "json"
+	}
+	for _, line := range strings.Split(string(trimmed), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if tomlLine.MatchString(line) {
+			return "toml"
+		}
+		break
+	}
+	return "yaml"
+}
+
+func unmarshalFormat(format string, data []byte, cfg any, opts ...Option) error {
+	d := newDecoder(opts)
+	defer d.close()
+	rawMap, err := d.parse(format, data)
+	if err != nil {
+		return err
+	}
+	return d.decode(cfg, rawMap)
+}
+
+// parse parses data in the named format.
+func (d *decoder) parse(format string, data []byte) (map[string]any, error) {
+	formatsMu.RLock()
+	parse, ok := formats[format]
+	formatsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported config format '%s'", format)
+	}
+	rawMap, err := parse(d, data)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling %s: %v", format, err)
+	}
+	return rawMap, nil
+}
@@ -0,0 +1,59 @@
+package jenv
+
+import (
+	"fmt"
+	"time"
+)
+
+// GetenvInt returns the environment variable key as an int, or def when it
+// is unset or empty. Values are parsed as they are in documents.
+func GetenvInt(key string, def int) (int, error) {
+	return getenvAs(key, def, (*decoder).getEnvValueInt)
+}
+
+// GetenvInt64 returns the environment variable key as an int64, or def
+// when it is unset or empty.
+func GetenvInt64(key string, def int64) (int64, error) {
+	return getenvAs(key, def, (*decoder).getEnvValueInt64)
+}
+
+// GetenvFloat returns the environment variable key as a float64, or def
+// when it is unset or empty.
+func GetenvFloat(key string, def float64) (float64, error) {
+	return getenvAs(key, def, (*decoder).getEnvValueFloat)
+}
+
+// GetenvBool returns the environment variable key as a bool, or def when
+// it is unset or empty.
+func GetenvBool(key string, def bool) (bool, error) {
+	return getenvAs(key, def, (*decoder).getEnvValueBool)
+}
+
+// GetenvDuration returns the environment variable key as a duration, or
+// def when it is unset or empty.
+func GetenvDuration(key string, def time.Duration) (time.Duration, error) {
+	return getenvAs(key, def, (*decoder).getEnvValueDuration)
+}
+
+// GetenvTime returns the environment variable key as a time, or def when
+// it is unset or empty. Any layout accepted by time.Time fields is
+// accepted.
+func GetenvTime(key string, def time.Time) (time.Time, error) {
+	return getenvAs(key, def, (*decoder).getEnvValueTime)
+}
+
+// getenvAs reads key through Lookup and parses it with the decoder's
+// parser for T. On a parse error def is returned along with the error.
+func getenvAs[T any](key string, def T, parse func(d *decoder, rawValue any, path string) (T, error)) (T, error) {
+	val, ok := Lookup(key)
+	if !ok || val == "" {
+		return def, nil
+	}
+	d := newDecoder(nil)
+	defer d.close()
+	v, err := parse(d, val, key)
+	if err != nil {
+		return def, fmt.Errorf("error parsing %s: %w", key, err)
+	}
+	return v, nil
+}
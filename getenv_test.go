@@ -0,0 +1,53 @@
+package jenv_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestTypedGetenv(t *testing.T) {
+	t.Setenv("TG_WORKERS", "8")
+	t.Setenv("TG_RATIO", "0.25")
+	t.Setenv("TG_DEBUG", "true")
+	t.Setenv("TG_TIMEOUT", "1m30s")
+	t.Setenv("TG_SINCE", "2024-01-02T03:04:05Z")
+	t.Setenv("TG_EMPTY", "")
+	t.Setenv("TG_BAD", "lots")
+
+	workers, err := jenv.GetenvInt("TG_WORKERS", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 8, workers)
+
+	ratio, err := jenv.GetenvFloat("TG_RATIO", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 0.25, ratio)
+
+	debug, err := jenv.GetenvBool("TG_DEBUG", false)
+	require.NoError(t, err)
+	assert.True(t, debug)
+
+	timeout, err := jenv.GetenvDuration("TG_TIMEOUT", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, timeout)
+
+	since, err := jenv.GetenvTime("TG_SINCE", time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), since.UTC())
+
+	limit, err := jenv.GetenvInt64("TG_UNSET", 42)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), limit)
+
+	workers, err = jenv.GetenvInt("TG_EMPTY", 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, workers)
+
+	workers, err = jenv.GetenvInt("TG_BAD", 3)
+	assert.ErrorContains(t, err, `error parsing TG_BAD: strconv.Atoi: parsing "lots": invalid syntax`)
+	assert.Equal(t, 3, workers)
+}
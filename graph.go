@@ -0,0 +1,106 @@
+package jenv
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Dependency is an external input a field's value was resolved from.
+type Dependency struct {
+	// Path of the field, e.g. "database.password".
+	Path string `json:"path"`
+	// Kind is "env" for environment variables and "secret" for secret
+	// store placeholders.
+	Kind string `json:"kind"`
+	// Name is the env var name or the `scheme:ref` of the secret.
+	Name string `json:"name"`
+}
+
+// Graph is the dependency graph of a load: the documents read, the fields
+// that reference external inputs, and the env vars and secrets behind them.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is a document, field, env var or secret in a Graph.
+type GraphNode struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// GraphEdge points from a node to a node it depends on.
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func (d *decoder) recordDependency(path, kind, name string) {
+	for _, res := range d.results {
+		res.Dependencies = append(res.Dependencies, Dependency{Path: path, Kind: kind, Name: name})
+	}
+}
+
+func (d *decoder) recordDocument(name string) {
+	for _, res := range d.results {
+		res.Documents = append(res.Documents, name)
+	}
+}
+
+// Graph builds the dependency graph of the recorded load.
+func (r *Result) Graph() *Graph {
+	g := &Graph{}
+	seen := map[string]bool{}
+	addNode := func(kind, name string) string {
+		id := kind + ":" + name
+		if !seen[id] {
+			seen[id] = true
+			g.Nodes = append(g.Nodes, GraphNode{ID: id, Kind: kind, Name: name})
+		}
+		return id
+	}
+	edges := map[GraphEdge]bool{}
+	addEdge := func(from, to string) {
+		e := GraphEdge{From: from, To: to}
+		if !edges[e] {
+			edges[e] = true
+			g.Edges = append(g.Edges, e)
+		}
+	}
+	var docs []string
+	for _, doc := range r.Documents {
+		docs = append(docs, addNode("document", doc))
+	}
+	for _, dep := range r.Dependencies {
+		field := addNode("field", dep.Path)
+		for _, doc := range docs {
+			addEdge(doc, field)
+		}
+		addEdge(field, addNode(dep.Kind, dep.Name))
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+	return g
+}
+
+// DOT renders the graph in Graphviz DOT format.
+func (g *Graph) DOT() string {
+	shapes := map[string]string{"document": "note", "field": "box", "env": "ellipse", "secret": "hexagon"}
+	var b strings.Builder
+	b.WriteString("digraph config {\n\trankdir=LR;\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&b, "\t%q [label=%q, shape=%s];\n", n.ID, n.Name, shapes[n.Kind])
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "\t%q -> %q;\n", e.From, e.To)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
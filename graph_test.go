@@ -0,0 +1,46 @@
+package jenv_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestResultGraph(t *testing.T) {
+	jenv.RegisterSecretStore("corp", mapStore{"db/password": "hunter2"})
+	defer jenv.UnregisterSecretStore("corp")
+	t.Setenv("DB_HOST", "db.internal")
+
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	os.WriteFile(path, []byte(`
+host: "${DB_HOST:localhost}"
+password: "${corp:db/password}"
+name: static
+`), 0o644)
+
+	var cfg struct {
+		Host     string `json:"host"`
+		Password string `json:"password"`
+		Name     string `json:"name"`
+	}
+	var res jenv.Result
+	require.NoError(t, jenv.Load(context.Background(), path, &cfg, jenv.WithResult(&res)))
+
+	g := res.Graph()
+	assert.Equal(t, []jenv.GraphNode{
+		{ID: "document:" + path, Kind: "document", Name: path},
+		{ID: "env:DB_HOST", Kind: "env", Name: "DB_HOST"},
+		{ID: "field:host", Kind: "field", Name: "host"},
+		{ID: "field:password", Kind: "field", Name: "password"},
+		{ID: "secret:corp:db/password", Kind: "secret", Name: "corp:db/password"},
+	}, g.Nodes)
+	assert.Contains(t, g.Edges, jenv.GraphEdge{From: "document:" + path, To: "field:host"})
+	assert.Contains(t, g.Edges, jenv.GraphEdge{From: "field:password", To: "secret:corp:db/password"})
+	assert.Contains(t, g.DOT(), `"field:host" -> "env:DB_HOST";`)
+}
@@ -0,0 +1,158 @@
+// Package hcl adds HCL documents to jenv. Importing it registers the
+// "hcl" format for the .hcl extension, so Load, LoadFile, LoadDir and Live
+// read HCL files too:
+//
+//	import _ "github.com/oarkflow/jenv/hcl"
+//
+// Attributes become keys and blocks become nested mappings, one level per
+// label:
+//
+//	service {
+//	  name = "${SERVICE_NAME:api}"
+//	}
+//	database "primary" {
+//	  port = 5432
+//	}
+//
+// decodes like {"service": {"name": ...}, "database": {"primary": {"port":
+// 5432}}}. An unlabeled block repeated in the same body becomes a list.
+// Expressions are evaluated without variables or functions; `${...}` in
+// strings is a jenv placeholder, not HCL interpolation.
+//
+// HCL lives in its own package so that programs which do not read it do
+// not build the HCL parser.
+package hcl
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	hclv2 "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/oarkflow/jenv"
+	"github.com/oarkflow/jenv/internal/docmap"
+)
+
+func init() {
+	jenv.RegisterFormat("hcl", Parse, ".hcl")
+}
+
+// Unmarshal decodes an HCL document into cfg, resolving placeholders as
+// jenv.UnmarshalJSON and jenv.UnmarshalYAML do.
+func Unmarshal(data []byte, cfg any, opts ...jenv.Option) error {
+	return jenv.Decode(bytes.NewReader(data), "hcl", cfg, opts...)
+}
+
+// Parse parses an HCL document into the raw map jenv decodes from; it is
+// the jenv.ParseFunc registered for the "hcl" format.
+func Parse(data []byte) (map[string]any, error) {
+	// Escape every `${` from HCL, so placeholders, and jenv's own `$${`
+	// escape, reach jenv as written.
+	src := bytes.ReplaceAll(data, []byte("${"), []byte("$${"))
+	file, diags := hclsyntax.ParseConfig(src, "config.hcl", hclv2.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return hclBody(file.Body.(*hclsyntax.Body))
+}
+
+func hclBody(body *hclsyntax.Body) (map[string]any, error) {
+	out := map[string]any{}
+	for name, attr := range body.Attributes {
+		val, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		v, err := ctyToGo(val)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", attr.NameRange, err)
+		}
+		out[name] = v
+	}
+	counts := map[string]int{}
+	for _, block := range body.Blocks {
+		if len(block.Labels) == 0 {
+			counts[block.Type]++
+		}
+	}
+	for _, block := range body.Blocks {
+		content, err := hclBody(block.Body)
+		if err != nil {
+			return nil, err
+		}
+		if len(block.Labels) == 0 && counts[block.Type] > 1 {
+			list, _ := out[block.Type].([]any)
+			out[block.Type] = append(list, content)
+			continue
+		}
+		target := out
+		key := block.Type
+		for _, label := range block.Labels {
+			next, ok := target[key].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				target[key] = next
+			}
+			target, key = next, label
+		}
+		if existing, ok := target[key].(map[string]any); ok {
+			docmap.Merge(existing, content)
+		} else {
+			target[key] = content
+		}
+	}
+	return out, nil
+}
+
+// ctyToGo converts an evaluated HCL value into the raw values the other
+// formats produce.
+func ctyToGo(val cty.Value) (any, error) {
+	if val.IsNull() {
+		return nil, nil
+	}
+	if !val.IsKnown() {
+		return nil, fmt.Errorf("value is not known")
+	}
+	typ := val.Type()
+	switch {
+	case typ == cty.String:
+		return val.AsString(), nil
+	case typ == cty.Bool:
+		return val.True(), nil
+	case typ == cty.Number:
+		bf := val.AsBigFloat()
+		if bf.IsInt() {
+			if i, acc := bf.Int64(); acc == big.Exact {
+				return i, nil
+			}
+		}
+		f, _ := bf.Float64()
+		return f, nil
+	case typ.IsListType() || typ.IsTupleType() || typ.IsSetType():
+		out := []any{}
+		for it := val.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			v, err := ctyToGo(elem)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	case typ.IsMapType() || typ.IsObjectType():
+		out := map[string]any{}
+		for it := val.ElementIterator(); it.Next(); {
+			k, elem := it.Element()
+			v, err := ctyToGo(elem)
+			if err != nil {
+				return nil, err
+			}
+			out[k.AsString()] = v
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unsupported value of type %s", typ.FriendlyName())
+}
@@ -0,0 +1,101 @@
+package hcl_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+	"github.com/oarkflow/jenv/hcl"
+)
+
+func TestUnmarshal(t *testing.T) {
+	t.Setenv("HCL_SERVICE", "billing")
+	doc := []byte(`
+name    = "${HCL_SERVICE:api}"
+timeout = "${HCL_TIMEOUT:30s}"
+ratio   = 0.25
+tags    = ["a", "b"]
+
+service {
+  enabled = true
+  port    = 8080
+}
+
+database "primary" {
+  host = "db-1"
+}
+
+database "replica" {
+  host = "db-2"
+}
+
+upstream {
+  url = "http://a"
+}
+
+upstream {
+  url = "http://b"
+}
+`)
+	type db struct {
+		Host string `json:"host"`
+	}
+	var cfg struct {
+		Name    string        `json:"name"`
+		Timeout time.Duration `json:"timeout"`
+		Ratio   float64       `json:"ratio"`
+		Tags    []string      `json:"tags"`
+		Service struct {
+			Enabled bool `json:"enabled"`
+			Port    int  `json:"port"`
+		} `json:"service"`
+		Database map[string]db `json:"database"`
+		Upstream []struct {
+			URL string `json:"url"`
+		} `json:"upstream"`
+	}
+	require.NoError(t, hcl.Unmarshal(doc, &cfg))
+	assert.Equal(t, "billing", cfg.Name)
+	assert.Equal(t, 30*time.Second, cfg.Timeout)
+	assert.Equal(t, 0.25, cfg.Ratio)
+	assert.Equal(t, []string{"a", "b"}, cfg.Tags)
+	assert.True(t, cfg.Service.Enabled)
+	assert.Equal(t, 8080, cfg.Service.Port)
+	assert.Equal(t, map[string]db{"primary": {"db-1"}, "replica": {"db-2"}}, cfg.Database)
+	require.Len(t, cfg.Upstream, 2)
+	assert.Equal(t, "http://b", cfg.Upstream[1].URL)
+
+	assert.ErrorContains(t, hcl.Unmarshal([]byte(`name = `), &cfg), "error unmarshalling hcl")
+}
+
+func TestEscapedPlaceholders(t *testing.T) {
+	t.Setenv("HCL_ESCAPED", "expanded")
+	var cfg struct {
+		Literal string `json:"literal"`
+		Mixed   string `json:"mixed"`
+	}
+	doc := []byte(`
+literal = "$${HCL_ESCAPED}"
+mixed   = "${HCL_ESCAPED}-$${HCL_ESCAPED}"
+`)
+	require.NoError(t, hcl.Unmarshal(doc, &cfg))
+	assert.Equal(t, "${HCL_ESCAPED}", cfg.Literal)
+	assert.Equal(t, "expanded-${HCL_ESCAPED}", cfg.Mixed)
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.hcl")
+	require.NoError(t, os.WriteFile(path, []byte("service {\n  name = \"${HCL_UNSET:api}\"\n}\n"), 0o644))
+	var cfg struct {
+		Service struct {
+			Name string `json:"name"`
+		} `json:"service"`
+	}
+	require.NoError(t, jenv.LoadFile(path, &cfg))
+	assert.Equal(t, "api", cfg.Service.Name)
+}
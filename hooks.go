@@ -0,0 +1,63 @@
+package jenv
+
+import (
+	"reflect"
+	"strings"
+)
+
+// DecodeHook converts data before it is decoded into a value of type to.
+// from is the type of data, which is the document's value: a string with
+// its placeholders resolved, a number or bool, or a []any or
+// map[string]any as written, whose items are hooked in turn as they are
+// decoded. A hook returns data unchanged for conversions it does not
+// handle.
+type DecodeHook func(from, to reflect.Type, data any) (any, error)
+
+// WithDecodeHook runs hooks, in order, on every value before it is decoded,
+// each receiving the previous one's result, as mapstructure's decode hooks
+// do. A result assignable to the target type is stored as it is; any
+// other is decoded as though the document held it.
+//
+//	jenv.WithDecodeHook(func(from, to reflect.Type, data any) (any, error) {
+//		if s, ok := data.(string); ok && to == reflect.TypeOf(slog.Level(0)) {
+//			var level slog.Level
+//			return level, level.UnmarshalText([]byte(s))
+//		}
+//		return data, nil
+//	})
+func WithDecodeHook(hooks ...DecodeHook) Option {
+	return func(o *options) {
+		o.decodeHooks = append(o.decodeHooks, hooks...)
+	}
+}
+
+// applyHooks runs the decode hooks on rawValue for field. It reports true
+// when a hook's result was stored in field; otherwise it returns the
+// result, with strings escaped so that placeholders in resolved values
+// stay literal.
+func (d *decoder) applyHooks(field reflect.Value, rawValue any, path string) (any, bool, error) {
+	data := rawValue
+	if s, ok := rawValue.(string); ok {
+		resolved, err := d.getEnv(s, path)
+		if err != nil {
+			return nil, false, err
+		}
+		data = resolved
+	}
+	var err error
+	for _, hook := range d.decodeHooks {
+		if data, err = hook(reflect.TypeOf(data), field.Type(), data); err != nil {
+			return nil, false, err
+		}
+	}
+	if data != nil {
+		if v := reflect.ValueOf(data); v.Type().AssignableTo(field.Type()) {
+			field.Set(v)
+			return nil, true, nil
+		}
+	}
+	if data, ok := data.(string); ok {
+		return strings.ReplaceAll(data, "${", "$${"), false, nil
+	}
+	return data, false, nil
+}
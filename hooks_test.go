@@ -0,0 +1,64 @@
+package jenv_test
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestDecodeHooks(t *testing.T) {
+	t.Setenv("HOOK_TAGS", "a;b")
+	t.Setenv("HOOK_LITERAL", "${NOT_EXPANDED}")
+	splitTags := func(from, to reflect.Type, data any) (any, error) {
+		if s, ok := data.(string); ok && to == reflect.TypeOf([]string{}) {
+			return strings.Split(s, ";"), nil
+		}
+		return data, nil
+	}
+	parseLevel := func(from, to reflect.Type, data any) (any, error) {
+		if s, ok := data.(string); ok && to == reflect.TypeOf(slog.Level(0)) {
+			var level slog.Level
+			if err := level.UnmarshalText([]byte(s)); err != nil {
+				return nil, fmt.Errorf("invalid level %q", s)
+			}
+			return level, nil
+		}
+		return data, nil
+	}
+	type logging struct {
+		Level  slog.Level   `yaml:"level"`
+		Levels []slog.Level `yaml:"levels"`
+	}
+	type config struct {
+		Tags    []string `yaml:"tags"`
+		Logging logging  `yaml:"logging"`
+		Name    string   `yaml:"name"`
+		Port    int      `yaml:"port"`
+	}
+	var cfg config
+	err := jenv.UnmarshalYAML([]byte(`
+tags: "${HOOK_TAGS}"
+logging:
+  level: "${LOG_LEVEL:warn}"
+  levels: [debug, error]
+name: "${HOOK_LITERAL}"
+port: "8080"
+`), &cfg, jenv.WithDecodeHook(splitTags, parseLevel))
+	require.NoError(t, err)
+	assert.Equal(t, config{
+		Tags:    []string{"a", "b"},
+		Logging: logging{Level: slog.LevelWarn, Levels: []slog.Level{slog.LevelDebug, slog.LevelError}},
+		Name:    "${NOT_EXPANDED}",
+		Port:    8080,
+	}, cfg)
+
+	err = jenv.UnmarshalYAML([]byte("logging: {level: loud}\n"), &cfg, jenv.WithDecodeHook(parseLevel))
+	assert.ErrorContains(t, err, `error decoding 'logging.level': invalid level "loud"`)
+}
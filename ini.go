@@ -0,0 +1,168 @@
+package jenv
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UnmarshalINI decodes an INI document into cfg, resolving placeholders as
+// UnmarshalJSON does. Section names and keys are split on dots, so
+// `[database.replica]` followed by `host = db-2` sets database.replica.host.
+// Keys before the first section are top-level; `;` and `#` start comment
+// lines, and `key: value` is accepted as well as `key = value`.
+func UnmarshalINI(iniData []byte, cfg any, opts ...Option) error {
+	return unmarshalFormat("ini", iniData, cfg, opts...)
+}
+
+// UnmarshalProperties decodes a Java-style properties document into cfg,
+// resolving placeholders as UnmarshalJSON does. Keys are split on dots, so
+// `database.host=db-1` sets database.host. Separators may be `=`, `:` or
+// whitespace; `#` and `!` start comment lines; a trailing backslash
+// continues a value on the next line, and \t, \n and \uXXXX escapes are
+// decoded.
+func UnmarshalProperties(propsData []byte, cfg any, opts ...Option) error {
+	return unmarshalFormat("properties", propsData, cfg, opts...)
+}
+
+func parseINI(_ *decoder, data []byte) (map[string]any, error) {
+	out := map[string]any{}
+	section := ""
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated section header", i+1)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		sep := strings.IndexAny(line, "=:")
+		if sep < 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", i+1)
+		}
+		key := strings.TrimSpace(line[:sep])
+		val := strings.TrimSpace(line[sep+1:])
+		if len(val) >= 2 && (val[0] == '"' || val[0] == '\'') && val[len(val)-1] == val[0] {
+			val = val[1 : len(val)-1]
+		}
+		if err := setDotted(out, joinPath(section, key), val); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+	return out, nil
+}
+
+func parseProperties(_ *decoder, data []byte) (map[string]any, error) {
+	out := map[string]any{}
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimLeft(strings.TrimRight(lines[i], "\r"), " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		for continued(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(strings.TrimRight(lines[i], "\r"), " \t\f")
+		}
+		key, val := splitProperty(line)
+		key, err := unescapeProperty(key)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if val, err = unescapeProperty(val); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if err := setDotted(out, key, val); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+	return out, nil
+}
+
+// continued reports whether line ends with an unescaped backslash.
+func continued(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// splitProperty splits a logical line at the first unescaped `=`, `:` or
+// whitespace, skipping whitespace around the separator.
+func splitProperty(line string) (string, string) {
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; c {
+		case '\\':
+			i++
+		case '=', ':', ' ', '\t', '\f':
+			key, rest := line[:i], strings.TrimLeft(line[i:], " \t\f")
+			if c == ' ' || c == '\t' || c == '\f' {
+				if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+					rest = rest[1:]
+				}
+			} else {
+				rest = rest[1:]
+			}
+			return key, strings.TrimLeft(rest, " \t\f")
+		}
+	}
+	return line, ""
+}
+
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+4 >= len(s) {
+				return "", fmt.Errorf("invalid unicode escape in %q", s)
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape in %q", s)
+			}
+			b.WriteRune(rune(r))
+			i += 4
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// setDotted stores val in m under a key path, such as "database.host" or
+// "hosts[0]", creating nested maps and lists.
+func setDotted(m map[string]any, key, val string) error {
+	segments, err := parsePath(key)
+	if err != nil {
+		return err
+	}
+	_, err = setPath(m, segments, val, key)
+	if errors.Is(err, errPathConflict) {
+		return fmt.Errorf("key '%s' is both a value and a section", key)
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package jenv_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+type flatConfig struct {
+	Name     string `json:"name"`
+	Database struct {
+		Host    string        `json:"host"`
+		Port    int           `json:"port"`
+		Timeout time.Duration `json:"timeout"`
+		Replica struct {
+			Host string `json:"host"`
+		} `json:"replica"`
+	} `json:"database"`
+	Motd string `json:"motd"`
+}
+
+func TestUnmarshalINI(t *testing.T) {
+	t.Setenv("INI_DB_HOST", "db.internal")
+	doc := []byte(`
+; top-level keys
+name = "api"
+motd: hello world
+
+[database]
+host = ${INI_DB_HOST:localhost}
+port = 5432
+timeout = ${INI_TIMEOUT:5s}
+
+# nested section
+[database.replica]
+host = 'db-2'
+`)
+	var cfg flatConfig
+	require.NoError(t, jenv.UnmarshalINI(doc, &cfg))
+	assert.Equal(t, "api", cfg.Name)
+	assert.Equal(t, "hello world", cfg.Motd)
+	assert.Equal(t, "db.internal", cfg.Database.Host)
+	assert.Equal(t, 5432, cfg.Database.Port)
+	assert.Equal(t, 5*time.Second, cfg.Database.Timeout)
+	assert.Equal(t, "db-2", cfg.Database.Replica.Host)
+
+	assert.ErrorContains(t, jenv.UnmarshalINI([]byte("[database\nhost = x\n"), &cfg), "line 1")
+	assert.ErrorContains(t, jenv.UnmarshalINI([]byte("database = x\n[database]\nhost = y\n"), &cfg), "both a value and a section")
+	err := jenv.UnmarshalINI([]byte("hosts[2000000000] = x\n"), &cfg)
+	assert.EqualError(t, err, `error unmarshalling ini: line 1: index 2000000000 in path "hosts[2000000000]" is not below 65536`)
+}
+
+func TestUnmarshalProperties(t *testing.T) {
+	t.Setenv("PROPS_DB_HOST", "db.internal")
+	doc := []byte(`
+# comment
+! another comment
+name = api
+database.host=${PROPS_DB_HOST:localhost}
+database.port: 5432
+database.replica.host db-2
+motd = hello \
+       world \u00e9\t!
+`)
+	var cfg flatConfig
+	require.NoError(t, jenv.UnmarshalProperties(doc, &cfg))
+	assert.Equal(t, "api", cfg.Name)
+	assert.Equal(t, "db.internal", cfg.Database.Host)
+	assert.Equal(t, 5432, cfg.Database.Port)
+	assert.Equal(t, "db-2", cfg.Database.Replica.Host)
+	assert.Equal(t, "hello world é\t!", cfg.Motd)
+
+	assert.ErrorContains(t, jenv.UnmarshalProperties([]byte("a.b=1\na.b.c=2\n"), &cfg), "line 2")
+}
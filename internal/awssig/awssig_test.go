@@ -0,0 +1,87 @@
+package awssig
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSignVanilla uses the "get-vanilla" case from the AWS SigV4 test suite.
+func TestSignVanilla(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	creds := Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	Sign(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"))
+}
+
+func TestRoleCredentials(t *testing.T) {
+	expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/container":
+			assert.Equal(t, "container-token", r.Header.Get("Authorization"))
+			fmt.Fprintf(w, `{"AccessKeyId": "ECS", "SecretAccessKey": "s", "Token": "t", "Expiration": %q}`, expires)
+		case r.URL.Query().Get("Action") == "AssumeRoleWithWebIdentity":
+			assert.Equal(t, "arn:aws:iam::1:role/app", r.URL.Query().Get("RoleArn"))
+			assert.Equal(t, "jwt", r.URL.Query().Get("WebIdentityToken"))
+			fmt.Fprintf(w, `<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>
+<AccessKeyId>IRSA</AccessKeyId><SecretAccessKey>s</SecretAccessKey><SessionToken>t</SessionToken><Expiration>%s</Expiration>
+</Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`, expires)
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			fmt.Fprint(w, "imds-token")
+		case r.Header.Get("X-Aws-Ec2-Metadata-Token") != "imds-token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/":
+			fmt.Fprint(w, "app-role")
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/app-role":
+			fmt.Fprintf(w, `{"AccessKeyId": "EC2", "SecretAccessKey": "s", "Token": "t", "Expiration": %q}`, expires)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "none"))
+	load := func() string {
+		roleUntil = time.Time{}
+		creds, err := LoadCredentials(t.Context())
+		require.NoError(t, err)
+		return creds.AccessKeyID
+	}
+
+	t.Setenv("AWS_EC2_METADATA_SERVICE_ENDPOINT", srv.URL)
+	assert.Equal(t, "EC2", load())
+
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", srv.URL+"/container")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "container-token")
+	assert.Equal(t, "ECS", load())
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("jwt\n"), 0o600))
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", tokenFile)
+	t.Setenv("AWS_ROLE_ARN", "arn:aws:iam::1:role/app")
+	t.Setenv("AWS_ENDPOINT_URL_STS", srv.URL)
+	assert.Equal(t, "IRSA", load())
+
+	// Cached until shortly before expiry.
+	t.Setenv("AWS_ROLE_ARN", "")
+	creds, err := LoadCredentials(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, "IRSA", creds.AccessKeyID)
+
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", "")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	roleUntil = time.Time{}
+	_, err = LoadCredentials(t.Context())
+	assert.ErrorIs(t, err, ErrNoCredentials)
+}
@@ -0,0 +1,210 @@
+package awssig
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTPClient is used to fetch role credentials. Requests to the instance
+// metadata service use a shorter timeout so hosts outside EC2 fail fast.
+var HTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+var imdsClient = &http.Client{Timeout: 2 * time.Second}
+
+var (
+	roleMu    sync.Mutex
+	roleCreds Credentials
+	roleUntil time.Time
+)
+
+// roleCredentials returns temporary credentials for the IAM role the
+// process runs as: a web identity token (EKS IRSA), the container
+// credentials endpoint (ECS, EKS Pod Identity) or the EC2 instance
+// metadata service, tried in that order. Credentials are reused until
+// shortly before they expire.
+func roleCredentials(ctx context.Context) (Credentials, error) {
+	roleMu.Lock()
+	defer roleMu.Unlock()
+	if roleCreds.AccessKeyID != "" && time.Now().Before(roleUntil) {
+		return roleCreds, nil
+	}
+	var (
+		creds   Credentials
+		expires time.Time
+		err     error
+	)
+	switch {
+	case os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE") != "" && os.Getenv("AWS_ROLE_ARN") != "":
+		creds, expires, err = webIdentityCredentials(ctx)
+	case os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != "" || os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI") != "":
+		creds, expires, err = containerCredentials(ctx)
+	case !strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true"):
+		creds, expires, err = instanceCredentials(ctx)
+	default:
+		return Credentials{}, ErrNoCredentials
+	}
+	if err != nil {
+		return Credentials{}, err
+	}
+	roleCreds, roleUntil = creds, expires.Add(-5*time.Minute)
+	return creds, nil
+}
+
+// roleResponse is the JSON document served by the container and instance
+// credential endpoints.
+type roleResponse struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	Token           string `json:"Token"`
+	Expiration      time.Time
+}
+
+func (r roleResponse) credentials() (Credentials, time.Time, error) {
+	if r.AccessKeyID == "" {
+		return Credentials{}, time.Time{}, ErrNoCredentials
+	}
+	return Credentials{AccessKeyID: r.AccessKeyID, SecretAccessKey: r.SecretAccessKey, SessionToken: r.Token}, r.Expiration, nil
+}
+
+func containerCredentials(ctx context.Context) (Credentials, time.Time, error) {
+	endpoint := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if endpoint == "" {
+		endpoint = "http://169.254.170.2" + os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return Credentials{}, time.Time{}, err
+	}
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if file := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return Credentials{}, time.Time{}, err
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	var r roleResponse
+	if err := doJSON(HTTPClient, req, &r); err != nil {
+		return Credentials{}, time.Time{}, fmt.Errorf("error fetching container credentials: %w", err)
+	}
+	return r.credentials()
+}
+
+func instanceCredentials(ctx context.Context) (Credentials, time.Time, error) {
+	base := strings.TrimSuffix(os.Getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT"), "/")
+	if base == "" {
+		base = "http://169.254.169.254"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, base+"/latest/api/token", nil)
+	if err != nil {
+		return Credentials{}, time.Time{}, err
+	}
+	req.Header.Set("X-Aws-Ec2-Metadata-Token-Ttl-Seconds", "21600")
+	token, err := doText(imdsClient, req)
+	if err != nil {
+		return Credentials{}, time.Time{}, fmt.Errorf("%w: instance metadata unavailable: %v", ErrNoCredentials, err)
+	}
+	get := func(path string) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/latest/meta-data/iam/security-credentials/"+path, nil)
+		if err == nil {
+			req.Header.Set("X-Aws-Ec2-Metadata-Token", token)
+		}
+		return req, err
+	}
+	req, err = get("")
+	if err != nil {
+		return Credentials{}, time.Time{}, err
+	}
+	role, err := doText(imdsClient, req)
+	if err != nil {
+		return Credentials{}, time.Time{}, fmt.Errorf("%w: no instance role: %v", ErrNoCredentials, err)
+	}
+	role, _, _ = strings.Cut(strings.TrimSpace(role), "\n")
+	if req, err = get(url.PathEscape(role)); err != nil {
+		return Credentials{}, time.Time{}, err
+	}
+	var r roleResponse
+	if err := doJSON(imdsClient, req, &r); err != nil {
+		return Credentials{}, time.Time{}, fmt.Errorf("error fetching instance credentials: %w", err)
+	}
+	return r.credentials()
+}
+
+func webIdentityCredentials(ctx context.Context) (Credentials, time.Time, error) {
+	token, err := os.ReadFile(os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"))
+	if err != nil {
+		return Credentials{}, time.Time{}, err
+	}
+	session := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if session == "" {
+		session = fmt.Sprintf("jenv-%d", time.Now().Unix())
+	}
+	query := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {os.Getenv("AWS_ROLE_ARN")},
+		"RoleSessionName":  {session},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	endpoint := Endpoint("sts")
+	if endpoint == "" {
+		endpoint = "https://sts." + Region() + ".amazonaws.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/?"+query.Encode(), nil)
+	if err != nil {
+		return Credentials{}, time.Time{}, err
+	}
+	body, err := doText(HTTPClient, req)
+	if err != nil {
+		return Credentials{}, time.Time{}, fmt.Errorf("error assuming role with web identity: %w", err)
+	}
+	var resp struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := xml.Unmarshal([]byte(body), &resp); err != nil {
+		return Credentials{}, time.Time{}, fmt.Errorf("error assuming role with web identity: %w", err)
+	}
+	c := resp.Credentials
+	return Credentials{AccessKeyID: c.AccessKeyID, SecretAccessKey: c.SecretAccessKey, SessionToken: c.SessionToken}, c.Expiration, nil
+}
+
+func doText(client *http.Client, req *http.Request) (string, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return string(body), nil
+}
+
+func doJSON(client *http.Client, req *http.Request, out any) error {
+	body, err := doText(client, req)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(body), out)
+}
@@ -0,0 +1,132 @@
+// Package azauth obtains Microsoft Entra ID access tokens for Azure APIs
+// from the credential sources the Azure SDKs' DefaultAzureCredential uses.
+package azauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAuthority = "https://login.microsoftonline.com"
+	imdsURL          = "http://169.254.169.254/metadata/identity/oauth2/token"
+)
+
+// HTTPClient is used for all token requests.
+var HTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+type cachedToken struct {
+	token  string
+	expiry time.Time
+}
+
+var (
+	mu     sync.Mutex
+	cached = map[string]cachedToken{}
+)
+
+// Token returns an access token for resource, such as
+// "https://vault.azure.net", reusing a cached one until shortly before it
+// expires. Sources are tried in order: a client secret
+// (AZURE_TENANT_ID, AZURE_CLIENT_ID, AZURE_CLIENT_SECRET), workload
+// identity (AZURE_FEDERATED_TOKEN_FILE) and finally managed identity.
+// AZURE_AUTHORITY_HOST selects a sovereign cloud.
+func Token(ctx context.Context, resource string) (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if c, ok := cached[resource]; ok && time.Until(c.expiry) > time.Minute {
+		return c.token, nil
+	}
+	tok, ttl, err := fetch(ctx, resource)
+	if err != nil {
+		return "", err
+	}
+	cached[resource] = cachedToken{token: tok, expiry: time.Now().Add(ttl)}
+	return tok, nil
+}
+
+func fetch(ctx context.Context, resource string) (string, time.Duration, error) {
+	tenant, client := os.Getenv("AZURE_TENANT_ID"), os.Getenv("AZURE_CLIENT_ID")
+	form := url.Values{
+		"grant_type": {"client_credentials"},
+		"client_id":  {client},
+		"scope":      {strings.TrimSuffix(resource, "/") + "/.default"},
+	}
+	switch {
+	case tenant != "" && client != "" && os.Getenv("AZURE_CLIENT_SECRET") != "":
+		form.Set("client_secret", os.Getenv("AZURE_CLIENT_SECRET"))
+	case tenant != "" && client != "" && os.Getenv("AZURE_FEDERATED_TOKEN_FILE") != "":
+		assertion, err := os.ReadFile(os.Getenv("AZURE_FEDERATED_TOKEN_FILE"))
+		if err != nil {
+			return "", 0, err
+		}
+		form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+		form.Set("client_assertion", strings.TrimSpace(string(assertion)))
+	default:
+		return managedIdentity(ctx, resource)
+	}
+	authority := strings.TrimSuffix(os.Getenv("AZURE_AUTHORITY_HOST"), "/")
+	if authority == "" {
+		authority = defaultAuthority
+	}
+	endpoint := authority + "/" + url.PathEscape(tenant) + "/oauth2/v2.0/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doToken(req)
+}
+
+// managedIdentity asks the App Service identity endpoint, when
+// IDENTITY_ENDPOINT is set, or else the VM instance metadata service.
+func managedIdentity(ctx context.Context, resource string) (string, time.Duration, error) {
+	query := url.Values{"resource": {resource}}
+	if id := os.Getenv("AZURE_CLIENT_ID"); id != "" {
+		query.Set("client_id", id)
+	}
+	endpoint, header, value := imdsURL, "Metadata", "true"
+	query.Set("api-version", "2018-02-01")
+	if e := os.Getenv("IDENTITY_ENDPOINT"); e != "" {
+		endpoint, header, value = e, "X-Identity-Header", os.Getenv("IDENTITY_HEADER")
+		query.Set("api-version", "2019-08-01")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set(header, value)
+	return doToken(req)
+}
+
+func doToken(req *http.Request) (string, time.Duration, error) {
+	resp, err := HTTPClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("error fetching azure access token: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", 0, fmt.Errorf("azure token endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		// ExpiresIn is a number from Entra ID but a string from the
+		// managed identity endpoints.
+		ExpiresIn json.RawMessage `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", 0, err
+	}
+	secs, _ := strconv.Atoi(strings.Trim(string(tok.ExpiresIn), `"`))
+	return tok.AccessToken, time.Duration(secs) * time.Second, nil
+}
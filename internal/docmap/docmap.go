@@ -0,0 +1,17 @@
+// Package docmap works on the raw documents jenv decodes from: nested
+// map[string]any values, []any lists and scalars.
+package docmap
+
+// Merge deep-merges src into dst: nested mappings are merged key by key,
+// and any other value in src replaces the one in dst.
+func Merge(dst, src map[string]any) {
+	for k, v := range src {
+		if srcMap, ok := v.(map[string]any); ok {
+			if dstMap, ok := dst[k].(map[string]any); ok {
+				Merge(dstMap, srcMap)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
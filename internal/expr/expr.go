@@ -0,0 +1,475 @@
+// Package expr evaluates the small expression language used by jenv's
+// `expr` struct tag. Expressions combine literals and dotted references
+// with arithmetic, comparison and boolean operators:
+//
+//	service.base_url + "/healthz"
+//	limits.max_conns * 2
+//	env == "prod" && replicas > 1
+//
+// Numbers are int64 when written without a fraction and float64 otherwise;
+// mixing the two yields float64. `+` concatenates when either side is a
+// string.
+package expr
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Lookup resolves a dotted reference such as "service.base_url".
+type Lookup func(path string) (any, error)
+
+// Expr is a parsed expression.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse parses src.
+func Parse(src string) (*Expr, error) {
+	toks, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// Eval parses and evaluates src.
+func Eval(src string, lookup Lookup) (any, error) {
+	e, err := Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return e.Eval(lookup)
+}
+
+// String returns the source of e.
+func (e *Expr) String() string { return e.src }
+
+// Eval evaluates e, resolving references through lookup.
+func (e *Expr) Eval(lookup Lookup) (any, error) {
+	return e.root.eval(lookup)
+}
+
+// Refs returns the references e reads, in order of appearance.
+func (e *Expr) Refs() []string {
+	var refs []string
+	e.root.walk(func(n node) {
+		if r, ok := n.(refNode); ok {
+			refs = append(refs, string(r))
+		}
+	})
+	return refs
+}
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+func tokenize(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.' || src[i] == '_') {
+				i++
+			}
+			toks = append(toks, token{tokNumber, src[start:i], start})
+		case c == '"' || c == '\'':
+			start := i
+			i++
+			for i < len(src) && src[i] != c {
+				if src[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(src) {
+				return nil, fmt.Errorf("unterminated string at offset %d", start)
+			}
+			i++
+			toks = append(toks, token{tokString, src[start:i], start})
+		case isIdentByte(c):
+			start := i
+			for i < len(src) && (src[i] == '.' || isIdentByte(src[i])) {
+				i++
+			}
+			toks = append(toks, token{tokIdent, src[start:i], start})
+		case c >= utf8.RuneSelf:
+			// Identifiers are ASCII, as isIdentByte expects.
+			r, _ := utf8.DecodeRuneInString(src[i:])
+			return nil, fmt.Errorf("unexpected %q at offset %d", r, i)
+		default:
+			op := string(c)
+			if i+1 < len(src) {
+				switch two := src[i : i+2]; two {
+				case "==", "!=", "<=", ">=", "&&", "||":
+					op = two
+				}
+			}
+			if !strings.Contains("+-*/%<>!()", op) && len(op) == 1 {
+				return nil, fmt.Errorf("unexpected %q at offset %d", op, i)
+			}
+			toks = append(toks, token{tokOp, op, i})
+			i += len(op)
+		}
+	}
+	return append(toks, token{tokEOF, "end of expression", len(src)}), nil
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) parseBinary(next func() (node, error), ops ...string) (node, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseOr() (node, error)  { return p.parseBinary(p.parseAnd, "||") }
+func (p *parser) parseAnd() (node, error) { return p.parseBinary(p.parseCmp, "&&") }
+func (p *parser) parseCmp() (node, error) {
+	return p.parseBinary(p.parseAdd, "==", "!=", "<=", ">=", "<", ">")
+}
+func (p *parser) parseAdd() (node, error) { return p.parseBinary(p.parseMul, "+", "-") }
+func (p *parser) parseMul() (node, error) { return p.parseBinary(p.parseUnary, "*", "/", "%") }
+
+func (p *parser) parseUnary() (node, error) {
+	if op, ok := p.accept("!", "-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		text := strings.ReplaceAll(t.text, "_", "")
+		if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return litNode{n}, nil
+		}
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", t.text, t.pos)
+		}
+		return litNode{f}, nil
+	case tokString:
+		s := t.text
+		if s[0] == '\'' {
+			s = `"` + strings.ReplaceAll(strings.ReplaceAll(s[1:len(s)-1], `\'`, `'`), `"`, `\"`) + `"`
+		}
+		unquoted, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s at offset %d", t.text, t.pos)
+		}
+		return litNode{unquoted}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return litNode{true}, nil
+		case "false":
+			return litNode{false}, nil
+		}
+		return refNode(t.text), nil
+	case tokOp:
+		if t.text == "(" {
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := p.accept(")"); !ok {
+				return nil, fmt.Errorf("expected ')' at offset %d", p.peek().pos)
+			}
+			return inner, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+}
+
+type node interface {
+	eval(Lookup) (any, error)
+	walk(func(node))
+}
+
+type litNode struct{ v any }
+
+func (n litNode) eval(Lookup) (any, error) { return n.v, nil }
+func (n litNode) walk(fn func(node))       { fn(n) }
+
+type refNode string
+
+func (n refNode) eval(lookup Lookup) (any, error) {
+	v, err := lookup(string(n))
+	if err != nil {
+		return nil, err
+	}
+	return normalize(v), nil
+}
+func (n refNode) walk(fn func(node)) { fn(n) }
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+func (n unaryNode) walk(fn func(node)) { fn(n); n.operand.walk(fn) }
+
+func (n unaryNode) eval(lookup Lookup) (any, error) {
+	v, err := n.operand.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "!":
+		if b, ok := v.(bool); ok {
+			return !b, nil
+		}
+	case "-":
+		switch v := v.(type) {
+		case int64:
+			return -v, nil
+		case float64:
+			return -v, nil
+		}
+	}
+	return nil, fmt.Errorf("operator %s not defined on %T", n.op, v)
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n binaryNode) walk(fn func(node)) { fn(n); n.left.walk(fn); n.right.walk(fn) }
+
+func (n binaryNode) eval(lookup Lookup) (any, error) {
+	l, err := n.left.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "&&" || n.op == "||" {
+		lb, ok := l.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s not defined on %T", n.op, l)
+		}
+		if lb == (n.op == "||") {
+			return lb, nil
+		}
+		r, err := n.right.eval(lookup)
+		if err != nil {
+			return nil, err
+		}
+		rb, ok := r.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s not defined on %T", n.op, r)
+		}
+		return rb, nil
+	}
+	r, err := n.right.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+	if ls, ok := l.(string); ok && n.op == "+" {
+		return ls + fmt.Sprint(r), nil
+	}
+	if rs, ok := r.(string); ok && n.op == "+" {
+		return fmt.Sprint(l) + rs, nil
+	}
+	switch n.op {
+	case "==", "!=":
+		eq, err := equal(l, r)
+		if err != nil {
+			return nil, err
+		}
+		return eq == (n.op == "=="), nil
+	}
+	if li, ok := l.(int64); ok {
+		if ri, ok := r.(int64); ok {
+			return intOp(n.op, li, ri)
+		}
+	}
+	lf, lok := toFloat(l)
+	rf, rok := toFloat(r)
+	if lok && rok {
+		return floatOp(n.op, lf, rf)
+	}
+	ls, lok := l.(string)
+	rs, rok := r.(string)
+	if lok && rok {
+		switch n.op {
+		case "<":
+			return ls < rs, nil
+		case "<=":
+			return ls <= rs, nil
+		case ">":
+			return ls > rs, nil
+		case ">=":
+			return ls >= rs, nil
+		}
+	}
+	return nil, fmt.Errorf("operator %s not defined on %T and %T", n.op, l, r)
+}
+
+func intOp(op string, l, r int64) (any, error) {
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/", "%":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		if op == "/" {
+			return l / r, nil
+		}
+		return l % r, nil
+	}
+	return floatOp(op, float64(l), float64(r))
+}
+
+func floatOp(op string, l, r float64) (any, error) {
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		return l / r, nil
+	case "%":
+		return math.Mod(l, r), nil
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	}
+	return nil, fmt.Errorf("operator %s not defined on numbers", op)
+}
+
+// equal compares two values; lists and maps, and structs holding them,
+// are not comparable.
+func equal(l, r any) (bool, error) {
+	lf, lok := toFloat(l)
+	rf, rok := toFloat(r)
+	if lok && rok {
+		return lf == rf, nil
+	}
+	for _, v := range []any{l, r} {
+		if v != nil && !reflect.ValueOf(v).Comparable() {
+			return false, fmt.Errorf("values of type %T are not comparable", v)
+		}
+	}
+	return l == r, nil
+}
+
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// normalize maps Go values onto the types expressions operate on, so
+// named types such as time.Duration take part in arithmetic.
+func normalize(v any) any {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	}
+	return v
+}
@@ -0,0 +1,91 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEval(t *testing.T) {
+	vars := map[string]any{
+		"service.base_url": "https://api.example.com",
+		"limits.max_conns": 50,
+		"timeout":          2 * time.Second,
+		"ratio":            float32(0.5),
+		"env":              "prod",
+		"replicas":         3,
+	}
+	lookup := func(path string) (any, error) {
+		v, ok := vars[path]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", path)
+		}
+		return v, nil
+	}
+	tests := []struct {
+		src  string
+		want any
+	}{
+		{`service.base_url + "/healthz"`, "https://api.example.com/healthz"},
+		{`service.base_url + '/v' + 2`, "https://api.example.com/v2"},
+		{`limits.max_conns * 2 + 1`, int64(101)},
+		{`limits.max_conns / 3`, int64(16)},
+		{`limits.max_conns * ratio`, 25.0},
+		{`timeout * 2`, int64(4 * time.Second)},
+		{`-(1 + 2) * 3`, int64(-9)},
+		{`env == "prod" && replicas > 1`, true},
+		{`!(env == "prod") || replicas >= 4`, false},
+		{`replicas != 3.0`, false},
+		{`1_000 % 7`, int64(6)},
+	}
+	for _, tt := range tests {
+		got, err := Eval(tt.src, lookup)
+		if err != nil {
+			t.Errorf("Eval(%q): %v", tt.src, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Eval(%q) = %#v, want %#v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	lookup := func(path string) (any, error) { return nil, fmt.Errorf("unknown field %q", path) }
+	for _, src := range []string{`1 +`, `(1 + 2`, `"open`, `1 $ 2`, `missing + 1`, `1 / 0`, `true + 1`, `!1`} {
+		if _, err := Eval(src, lookup); err == nil {
+			t.Errorf("Eval(%q): expected error", src)
+		}
+	}
+}
+
+func TestEvalNotComparable(t *testing.T) {
+	lookup := func(string) (any, error) { return []string{"a", "b"}, nil }
+	for _, src := range []string{`hosts == hosts`, `hosts != "a"`} {
+		if _, err := Eval(src, lookup); err == nil || !strings.Contains(err.Error(), "not comparable") {
+			t.Errorf("Eval(%q) = %v, want not comparable error", src, err)
+		}
+	}
+}
+
+func TestParseNonASCII(t *testing.T) {
+	for _, src := range []string{`é == 1`, `a + ü`} {
+		if _, err := Parse(src); err == nil || !strings.Contains(err.Error(), "unexpected") {
+			t.Errorf("Parse(%q) = %v, want unexpected character error", src, err)
+		}
+	}
+	if _, err := Parse(`é == 1`); err == nil || err.Error() != `unexpected 'é' at offset 0` {
+		t.Errorf("Parse error = %v", err)
+	}
+}
+
+func TestRefs(t *testing.T) {
+	e, err := Parse(`a.b + c * (d - 1)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(e.Refs()); got != "[a.b c d]" {
+		t.Errorf("Refs() = %s", got)
+	}
+}
@@ -0,0 +1,190 @@
+// Package gcpauth obtains OAuth2 access tokens for Google Cloud APIs from
+// the usual application default credential sources.
+package gcpauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scope is the OAuth2 scope requested for service account tokens.
+const Scope = "https://www.googleapis.com/auth/cloud-platform"
+
+const (
+	defaultTokenURL = "https://oauth2.googleapis.com/token"
+	metadataURL     = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// HTTPClient is used for all token requests.
+var HTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+var (
+	mu     sync.Mutex
+	cached string
+	expiry time.Time
+)
+
+// Token returns an access token, reusing a cached one until shortly before
+// it expires. Sources are tried in order: GOOGLE_OAUTH_ACCESS_TOKEN,
+// GOOGLE_APPLICATION_CREDENTIALS, the gcloud application default
+// credentials file and finally the GCE metadata server.
+func Token(ctx context.Context) (string, error) {
+	if tok := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); tok != "" {
+		return tok, nil
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if cached != "" && time.Until(expiry) > time.Minute {
+		return cached, nil
+	}
+	tok, ttl, err := fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	cached, expiry = tok, time.Now().Add(ttl)
+	return tok, nil
+}
+
+type credentialsFile struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+func fetch(ctx context.Context) (string, time.Duration, error) {
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			adc := filepath.Join(dir, "gcloud", "application_default_credentials.json")
+			if _, err := os.Stat(adc); err == nil {
+				path = adc
+			}
+		}
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", 0, err
+		}
+		var creds credentialsFile
+		if err := json.Unmarshal(data, &creds); err != nil {
+			return "", 0, fmt.Errorf("error parsing %s: %v", path, err)
+		}
+		return fromCredentials(ctx, creds)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	return doToken(req)
+}
+
+func fromCredentials(ctx context.Context, creds credentialsFile) (string, time.Duration, error) {
+	tokenURL := creds.TokenURI
+	if tokenURL == "" {
+		tokenURL = defaultTokenURL
+	}
+	var form url.Values
+	switch creds.Type {
+	case "service_account":
+		assertion, err := signJWT(creds, tokenURL)
+		if err != nil {
+			return "", 0, err
+		}
+		form = url.Values{
+			"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+			"assertion":  {assertion},
+		}
+	case "authorized_user":
+		form = url.Values{
+			"grant_type":    {"refresh_token"},
+			"client_id":     {creds.ClientID},
+			"client_secret": {creds.ClientSecret},
+			"refresh_token": {creds.RefreshToken},
+		}
+	default:
+		return "", 0, fmt.Errorf("unsupported google credentials type '%s'", creds.Type)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doToken(req)
+}
+
+func signJWT(creds credentialsFile, audience string) (string, error) {
+	block, _ := pem.Decode([]byte(creds.PrivateKey))
+	if block == nil {
+		return "", errors.New("invalid service account private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", errors.New("service account private key is not RSA")
+	}
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"iss":   creds.ClientEmail,
+		"scope": Scope,
+		"aud":   audience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
+
+func doToken(req *http.Request) (string, time.Duration, error) {
+	resp, err := HTTPClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("error fetching google access token: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", 0, fmt.Errorf("google token endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var tok tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", 0, err
+	}
+	return tok.AccessToken, time.Duration(tok.ExpiresIn) * time.Second, nil
+}
@@ -0,0 +1,33 @@
+package jenv
+
+// UnmarshalKey decodes the value at path in a document into out, so a
+// component can decode just "database" or "service.cache" into its own
+// struct. Paths are dotted keys, matched case-insensitively when there is
+// no exact match, with list items written "servers[0]" or "servers.0".
+// The format is sniffed as for Unmarshal. Placeholders, `${.path}`
+// references and document variables resolve across the whole document,
+// and errors name fields by their full path. A missing key leaves out
+// untouched, apart from the defaults of a struct.
+func UnmarshalKey(data []byte, path string, out any, opts ...Option) error {
+	d := newDecoder(opts)
+	defer d.close()
+	rawMap, err := d.parse(sniffFormat(data), data)
+	if err != nil {
+		return err
+	}
+	return d.decodeKey(out, rawMap, path)
+}
+
+// lookupPath returns the raw value at a dotted path, such as
+// "service.cache" or "servers[0].host", matching keys case-insensitively
+// when there is no exact match.
+func lookupPath(raw any, path string) (any, bool) {
+	if path == "" {
+		return raw, true
+	}
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, false
+	}
+	return getPath(raw, segments, true)
+}
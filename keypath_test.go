@@ -0,0 +1,64 @@
+package jenv_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestUnmarshalKey(t *testing.T) {
+	t.Setenv("KEYPATH_CACHE_TTL", "5m")
+	doc := []byte(`
+vars:
+  region: eu-west-1
+service:
+  name: api
+  cache:
+    addr: "redis.${vars.region}.internal:6379"
+    ttl: ${KEYPATH_CACHE_TTL}
+database:
+  host: db
+  port: fivefour
+servers:
+  - {host: a, port: 80}
+  - {host: b, port: 81}
+`)
+	type cache struct {
+		Addr string        `yaml:"addr" validate:"omitempty,hostname_port"`
+		TTL  time.Duration `yaml:"ttl"`
+		Size int           `yaml:"size" default:"128"`
+	}
+	var c cache
+	require.NoError(t, jenv.UnmarshalKey(doc, "service.cache", &c))
+	assert.Equal(t, cache{Addr: "redis.eu-west-1.internal:6379", TTL: 5 * time.Minute, Size: 128}, c)
+
+	type server struct {
+		Host string `yaml:"host"`
+		Port int    `yaml:"port"`
+	}
+	var s server
+	require.NoError(t, jenv.UnmarshalKey(doc, "servers[1]", &s))
+	assert.Equal(t, server{Host: "b", Port: 81}, s)
+	var all []server
+	require.NoError(t, jenv.UnmarshalKey(doc, "servers", &all))
+	assert.Len(t, all, 2)
+	var name string
+	require.NoError(t, jenv.UnmarshalKey(doc, "Service.Name", &name))
+	assert.Equal(t, "api", name)
+
+	c = cache{}
+	require.NoError(t, jenv.UnmarshalKey(doc, "service.queue", &c))
+	assert.Equal(t, cache{Size: 128}, c, "a missing key still gets defaults")
+
+	var db struct {
+		Port int `yaml:"port"`
+	}
+	err := jenv.UnmarshalKey(doc, "database", &db)
+	var fe *jenv.FieldError
+	require.ErrorAs(t, err, &fe)
+	assert.Equal(t, "database.port", fe.Path)
+}
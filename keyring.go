@@ -0,0 +1,35 @@
+package jenv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrKeyringUnsupported is returned when the OS credential store cannot be
+// used on the current platform.
+var ErrKeyringUnsupported = errors.New("keyring is not supported on this platform")
+
+// lookupKeyring resolves a `service/account` reference against the OS
+// credential store (Keychain, Secret Service or Windows Credential Manager).
+func lookupKeyring(_ context.Context, ref string) (string, error) {
+	service, account, err := splitKeyringRef(ref)
+	if err != nil {
+		return "", err
+	}
+	secret, err := keyringGet(service, account)
+	if err != nil {
+		return "", fmt.Errorf("error reading keyring: %w", err)
+	}
+	return secret, nil
+}
+
+func splitKeyringRef(ref string) (string, string, error) {
+	ref = strings.TrimSpace(ref)
+	i := strings.LastIndex(ref, "/")
+	if i <= 0 || i == len(ref)-1 {
+		return "", "", fmt.Errorf("invalid keyring reference '%s', expected service/account", ref)
+	}
+	return ref[:i], ref[i+1:], nil
+}
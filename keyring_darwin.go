@@ -0,0 +1,16 @@
+//go:build darwin
+
+package jenv
+
+import (
+	"os/exec"
+	"strings"
+)
+
+func keyringGet(service, account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w").Output()
+	if err != nil {
+		return "", commandError(err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
@@ -0,0 +1,7 @@
+//go:build !darwin && !windows && !linux && !freebsd && !openbsd && !netbsd && !dragonfly
+
+package jenv
+
+func keyringGet(service, account string) (string, error) {
+	return "", ErrKeyringUnsupported
+}
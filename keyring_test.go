@@ -0,0 +1,19 @@
+package jenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitKeyringRef(t *testing.T) {
+	service, account, err := splitKeyringRef("myapp/prod/db")
+	assert.NoError(t, err)
+	assert.Equal(t, "myapp/prod", service)
+	assert.Equal(t, "db", account)
+
+	_, _, err = splitKeyringRef("myapp")
+	assert.Error(t, err)
+	_, _, err = splitKeyringRef("myapp/")
+	assert.Error(t, err)
+}
@@ -0,0 +1,18 @@
+//go:build linux || freebsd || openbsd || netbsd || dragonfly
+
+package jenv
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// keyringGet reads from the Secret Service API through secret-tool, using
+// the same service/account attributes that most Linux keyring clients set.
+func keyringGet(service, account string) (string, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", service, "account", account).Output()
+	if err != nil {
+		return "", commandError(err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
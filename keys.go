@@ -0,0 +1,31 @@
+package jenv
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SnakeCase converts camelCase, PascalCase, kebab-case and snake_case keys
+// to snake_case, keeping acronyms together: "HTTPPort" -> "http_port".
+func SnakeCase(key string) string {
+	var b strings.Builder
+	runes := []rune(key)
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == '_' || r == ' ' || r == '.':
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+				b.WriteByte('_')
+			}
+			continue
+		case unicode.IsUpper(r):
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			acronymEnd := i > 0 && unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if (prevLower || acronymEnd) && !strings.HasSuffix(b.String(), "_") {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
@@ -0,0 +1,48 @@
+package jenv_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestSnakeCase(t *testing.T) {
+	for in, want := range map[string]string{
+		"maxConns":      "max_conns",
+		"MaxConns":      "max_conns",
+		"max-conns":     "max_conns",
+		"max_conns":     "max_conns",
+		"HTTPPort":      "http_port",
+		"useTLS":        "use_tls",
+		"retry2Backoff": "retry2_backoff",
+		"a--b":          "a_b",
+	} {
+		assert.Equal(t, want, jenv.SnakeCase(in), in)
+	}
+}
+
+func TestWithKeyNormalizer(t *testing.T) {
+	type pool struct {
+		MaxConns    int    `json:"max_conns"`
+		IdleTimeout string `json:"idle-timeout"`
+		HTTPPort    int    `json:"httpPort"`
+	}
+	doc := []byte(`{"maxConns": 10, "idle_timeout": "30s", "http-port": 8080}`)
+
+	var cfg pool
+	assert.NoError(t, jenv.UnmarshalJSON(doc, &cfg))
+	assert.Equal(t, pool{}, cfg)
+
+	assert.NoError(t, jenv.UnmarshalJSON(doc, &cfg, jenv.WithKeyNormalizer(jenv.SnakeCase)))
+	assert.Equal(t, pool{MaxConns: 10, IdleTimeout: "30s", HTTPPort: 8080}, cfg)
+
+	doc = []byte(`{"maxConns": 10, "max-conns": 20}`)
+	err := jenv.UnmarshalJSON(doc, &pool{}, jenv.WithKeyNormalizer(jenv.SnakeCase))
+	assert.EqualError(t, err, "error decoding 'max_conns': keys 'max-conns', 'maxConns' match the same field after normalization")
+	cfg = pool{}
+	doc = []byte(`{"max_conns": 1, "maxConns": 10, "max-conns": 20}`)
+	assert.NoError(t, jenv.UnmarshalJSON(doc, &cfg, jenv.WithKeyNormalizer(jenv.SnakeCase)))
+	assert.Equal(t, 1, cfg.MaxConns, "an exact match wins")
+}
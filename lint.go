@@ -0,0 +1,198 @@
+package jenv
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LintIssue is a problem found by Lint.
+type LintIssue struct {
+	// Rule is the name of the rule that reported the issue.
+	Rule    string `json:"rule"`
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// secretKey matches keys whose values should come from a placeholder
+// rather than being written into the document.
+var secretKey = regexp.MustCompile(`(password|passwd|secret|token|apikey|privatekey|accesskey|secretkey|credentials?)$`)
+
+// Lint checks a JSON or YAML document for common mistakes:
+//
+//   - "placeholder-without-default": an env placeholder with no default
+//     that is not marked required with `${VAR:?message}`.
+//   - "duplicate-key": a key defined twice in the same mapping.
+//   - "plaintext-secret": a secret-looking key holding a literal value.
+//   - "unused-default": a placeholder default under a key that no field
+//     of cfg decodes, so it can never take effect.
+//   - "deprecated-key": a key decoded by a field tagged `deprecated`; the
+//     tag's value explains what to use instead.
+//
+// cfg is optional; rules that need the target struct are skipped when it
+// is nil.
+func Lint(data []byte, cfg any) ([]LintIssue, error) {
+	l := &linter{checkFields: cfg != nil}
+	if err := l.lint(data, cfg); err != nil {
+		return nil, err
+	}
+	return l.issues, nil
+}
+
+func (l *linter) lint(data []byte, cfg any) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("error parsing document: %v", err)
+	}
+	if len(doc.Content) > 0 {
+		var typ reflect.Type
+		if cfg != nil {
+			typ = reflect.TypeOf(cfg)
+		}
+		l.walk(doc.Content[0], "", "", typ, true)
+	}
+	return nil
+}
+
+type linter struct {
+	checkFields bool
+	// lookupEnv, when set, reports placeholders without a default whose
+	// variable is unset as unresolved.
+	lookupEnv func(string) (string, bool)
+	issues    []LintIssue
+}
+
+func (l *linter) report(rule string, node *yaml.Node, path, format string, args ...any) {
+	l.issues = append(l.issues, LintIssue{Rule: rule, Path: path, Line: node.Line, Message: fmt.Sprintf(format, args...)})
+}
+
+// walk visits node, tracking the Go type it decodes into. typ is nil when
+// it is unknown or irrelevant; mapped is false under keys no field decodes.
+func (l *linter) walk(node *yaml.Node, path, key string, typ reflect.Type, mapped bool) {
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch node.Kind {
+	case yaml.AliasNode:
+		l.walk(node.Alias, path, key, typ, mapped)
+	case yaml.MappingNode:
+		seen := map[string]int{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			childPath := joinPath(path, k.Value)
+			if line, ok := seen[k.Value]; ok {
+				l.report("duplicate-key", k, childPath, "key already defined at line %d", line)
+			}
+			seen[k.Value] = k.Line
+			l.checkDeprecated(k, childPath, typ, k.Value, mapped)
+			childType, childMapped := l.childType(typ, k.Value, mapped)
+			l.walk(v, childPath, k.Value, childType, childMapped)
+		}
+	case yaml.SequenceNode:
+		var elem reflect.Type
+		if typ != nil && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+			elem = typ.Elem()
+		}
+		for i, item := range node.Content {
+			l.walk(item, fmt.Sprintf("%s[%d]", path, i), key, elem, mapped)
+		}
+	case yaml.ScalarNode:
+		l.checkScalar(node, path, key, mapped)
+	}
+}
+
+func (l *linter) childType(typ reflect.Type, key string, mapped bool) (reflect.Type, bool) {
+	if !l.checkFields || !mapped || typ == nil {
+		return nil, mapped
+	}
+	switch typ.Kind() {
+	case reflect.Map:
+		return typ.Elem(), true
+	case reflect.Struct:
+		if typ == reflect.TypeOf(time.Time{}) {
+			return nil, true
+		}
+		if f, ok := lintField(typ, key); ok {
+			return f.Type, true
+		}
+		return nil, false
+	}
+	return nil, true
+}
+
+// lintField returns the field of the struct type typ that decodes key.
+func lintField(typ reflect.Type, key string) (reflect.StructField, bool) {
+	for _, f := range structFields(typ, defaultTagOrder) {
+		if fieldKey(f, defaultTagOrder) == key || !hasKeyTag(f, defaultTagOrder) && strings.EqualFold(f.Name, key) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// checkDeprecated reports key when the field of typ it sets carries a
+// `deprecated` tag.
+func (l *linter) checkDeprecated(node *yaml.Node, path string, typ reflect.Type, key string, mapped bool) {
+	if !l.checkFields || !mapped || typ == nil || typ.Kind() != reflect.Struct {
+		return
+	}
+	f, ok := lintField(typ, key)
+	if !ok {
+		return
+	}
+	if msg, ok := f.Tag.Lookup("deprecated"); ok {
+		if msg == "" {
+			msg = "no replacement"
+		}
+		l.report("deprecated-key", node, path, "key is deprecated: %s", msg)
+	}
+}
+
+func (l *linter) checkScalar(node *yaml.Node, path, key string, mapped bool) {
+	refs := findPlaceholders(node.Value)
+	if len(refs) == 0 {
+		if node.Tag == "!!str" && node.Value != "" && isSecretKey(key) {
+			l.report("plaintext-secret", node, path, "secret-looking value is written in plain text; use a placeholder")
+		}
+		return
+	}
+	hasDefault := false
+	for _, p := range refs {
+		// References to document variables, other keys and commands are not
+		// env vars.
+		if strings.HasPrefix(p.body, ".") || strings.HasPrefix(p.body, varsKey+".") || strings.HasPrefix(p.body, execScheme+":") {
+			continue
+		}
+		if scheme, ref, ok := strings.Cut(p.body, ":"); ok {
+			if _, ok := secretStore(scheme); ok {
+				hasDefault = hasDefault || strings.Contains(ref, ":-")
+				continue
+			}
+		}
+		ref := parseEnvRef(p.body)
+		switch ref.op {
+		case '-':
+			hasDefault = true
+			continue
+		case '+':
+			continue
+		}
+		if l.lookupEnv != nil {
+			if _, set := l.lookupEnv(ref.name); !set {
+				l.report("unresolved-placeholder", node, path, "${%s} is not set and has no default", ref.name)
+				continue
+			}
+		}
+		if ref.op == 0 && !ref.required {
+			l.report("placeholder-without-default", node, path, "placeholder ${%s} has no default", ref.name)
+		}
+	}
+	if hasDefault && l.checkFields && !mapped {
+		l.report("unused-default", node, path, "default is never used: no field decodes this key")
+	}
+}
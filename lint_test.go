@@ -0,0 +1,61 @@
+package jenv_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestLint(t *testing.T) {
+	doc := []byte(`
+service:
+  name: "${SERVICE_NAME}"
+  timeout: "${TIMEOUT:30s}"
+  api_token: abc123
+  token_ttl: 5m
+  legacy_url: "${LEGACY_URL:http://old}"
+database:
+  hosts: ["${DB_HOST:localhost}"]
+  password: "${DB_PASSWORD:changeme}"
+  hosts: []
+`)
+	issues, err := jenv.Lint(doc, &Config{})
+	require.NoError(t, err)
+
+	rules := map[string]string{}
+	for _, issue := range issues {
+		rules[issue.Path] = issue.Rule
+	}
+	assert.Equal(t, map[string]string{
+		"service.name":       "placeholder-without-default",
+		"service.api_token":  "plaintext-secret",
+		"service.legacy_url": "unused-default",
+		"database.password":  "unused-default",
+		"database.hosts":     "duplicate-key",
+	}, rules)
+
+	for _, issue := range issues {
+		if issue.Rule == "duplicate-key" {
+			assert.Equal(t, 11, issue.Line)
+		}
+	}
+}
+
+func TestLintWithoutStruct(t *testing.T) {
+	issues, err := jenv.Lint([]byte(`{"name": "${NAME:x}", "extra": "${EXTRA:y}"}`), nil)
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+}
+
+func TestLintInterpolated(t *testing.T) {
+	issues, err := jenv.Lint([]byte(`{"dsn": "postgres://${DB_USER}:${DB_PASS:x}@${DB_HOST}/app"}`), nil)
+	require.NoError(t, err)
+	var messages []string
+	for _, issue := range issues {
+		messages = append(messages, issue.Message)
+	}
+	assert.Equal(t, []string{"placeholder ${DB_USER} has no default", "placeholder ${DB_HOST} has no default"}, messages)
+}
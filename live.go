@@ -0,0 +1,410 @@
+package jenv
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Source fetches the raw document a Live configuration is decoded from,
+// along with its format ("json" or "yaml"). Sources may return
+// ErrNotModified when the document is known not to have changed.
+type Source interface {
+	Fetch(ctx context.Context) (data []byte, format string, err error)
+}
+
+// SourceFunc adapts an ordinary function to the Source interface.
+type SourceFunc func(ctx context.Context) ([]byte, string, error)
+
+func (f SourceFunc) Fetch(ctx context.Context) ([]byte, string, error) {
+	return f(ctx)
+}
+
+// URISource returns a Source that reads uri through the registered
+// loaders. Loaders implementing ConditionalLoader are queried with the
+// version of the last fetched document, so unchanged remote documents are
+// not transferred again.
+func URISource(uri string) Source {
+	return &uriSource{uri: uri}
+}
+
+type uriSource struct {
+	uri     string
+	mu      sync.Mutex
+	version string
+}
+
+func (s *uriSource) Fetch(ctx context.Context) ([]byte, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, version, err := loadIfChanged(ctx, s.uri, s.version)
+	if err != nil {
+		return nil, "", err
+	}
+	s.version = version
+	return data, documentFormat(s.uri, data), nil
+}
+
+// Live holds a configuration that is re-decoded from its Source on
+// Reload. Readers always see a complete value: a reload decodes into a
+// fresh T and only swaps it in once decoding succeeded, so a failed reload
+// keeps the previous configuration in service.
+type Live[T any] struct {
+	src   Source
+	opts  liveOptions
+	cache *ResolutionCache
+
+	mu        sync.RWMutex
+	current   *T
+	data      []byte
+	format    string
+	stale     bool
+	env       envReadings
+	result    *Result
+	subs      []func(old, new *T)
+	errorSubs []func(error)
+	stats     LiveStats
+	history   []ReloadEvent
+
+	reloadMu sync.Mutex
+}
+
+// LiveStats reports the reload history of a Live configuration.
+type LiveStats struct {
+	Reloads     int
+	Failures    int
+	LastSuccess time.Time
+	LastFailure time.Time
+	LastError   error
+	// LastKnownGood is true while the configuration in use was restored
+	// from the last-known-good file because the source was unavailable.
+	LastKnownGood bool
+}
+
+// ReloadEvent records one reload of a Live configuration.
+type ReloadEvent struct {
+	Time time.Time
+	// Changed is true when the reload swapped in a new configuration.
+	Changed bool
+	Err     error
+}
+
+// maxHistory is the number of reloads Live.History remembers.
+const maxHistory = 50
+
+// LiveOption configures a Live configuration.
+type LiveOption func(*liveOptions)
+
+type liveOptions struct {
+	decode        []Option
+	lastKnownGood string
+}
+
+// WithDecodeOptions sets the options used for every decode.
+func WithDecodeOptions(opts ...Option) LiveOption {
+	return func(o *liveOptions) {
+		o.decode = append(o.decode, opts...)
+	}
+}
+
+// WithLastKnownGood persists every successfully decoded document to path.
+// When the initial load fails, the document stored there is used instead,
+// so a service can start while its config source is down.
+func WithLastKnownGood(path string) LiveOption {
+	return func(o *liveOptions) {
+		o.lastKnownGood = path
+	}
+}
+
+// NewLive fetches and decodes the initial configuration from src. If that
+// fails and a last-known-good document is available, it is used and the
+// failure is reported through Stats.
+func NewLive[T any](ctx context.Context, src Source, opts ...LiveOption) (*Live[T], error) {
+	l := &Live[T]{src: src, cache: NewResolutionCache()}
+	for _, opt := range opts {
+		opt(&l.opts)
+	}
+	err := l.Reload(ctx)
+	if err == nil {
+		return l, nil
+	}
+	if l.opts.lastKnownGood == "" {
+		return nil, err
+	}
+	data, readErr := os.ReadFile(l.opts.lastKnownGood)
+	if readErr != nil {
+		return nil, err
+	}
+	cfg := new(T)
+	res, env, decodeErr := l.decode(ctx, documentFormat(l.opts.lastKnownGood, data), data, cfg)
+	if decodeErr != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	l.current, l.data, l.format, l.env, l.result = cfg, data, documentFormat(l.opts.lastKnownGood, data), env, res
+	l.stats.LastKnownGood = true
+	l.mu.Unlock()
+	return l, nil
+}
+
+// Get returns the current configuration. The returned value must be
+// treated as read-only; it is replaced, not mutated, on reload.
+func (l *Live[T]) Get() *T {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.current
+}
+
+// Result returns the Result recorded while decoding the current
+// configuration. Like Get's value, it must be treated as read-only.
+func (l *Live[T]) Result() *Result {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.result
+}
+
+// Stats returns the reload statistics.
+func (l *Live[T]) Stats() LiveStats {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.stats
+}
+
+// History returns the most recent reloads, oldest first.
+func (l *Live[T]) History() []ReloadEvent {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append([]ReloadEvent(nil), l.history...)
+}
+
+// Invalidate forgets the resolved secret placeholders matching patterns
+// (see ResolutionCache.Invalidate), so the next reload fetches them again
+// even if the document itself did not change. Other placeholders keep
+// their resolved values across reloads.
+func (l *Live[T]) Invalidate(patterns ...string) {
+	l.cache.Invalidate(patterns...)
+	l.mu.Lock()
+	l.stale = true
+	l.mu.Unlock()
+}
+
+// OnError registers fn to be called whenever a reload fails.
+func (l *Live[T]) OnError(fn func(error)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errorSubs = append(l.errorSubs, fn)
+}
+
+// OnChange registers fn to be called after each reload that changed the
+// configuration.
+func (l *Live[T]) OnChange(fn func(old, new *T)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.subs = append(l.subs, fn)
+}
+
+// Reload fetches the document again and swaps in the newly decoded
+// configuration if the document changed. On failure the previous
+// configuration stays in place and the error is passed to OnError
+// callbacks.
+func (l *Live[T]) Reload(ctx context.Context) error {
+	l.reloadMu.Lock()
+	defer l.reloadMu.Unlock()
+
+	changed, err := l.reload(ctx)
+	now := time.Now()
+	l.mu.Lock()
+	if err != nil {
+		l.stats.Failures++
+		l.stats.LastFailure, l.stats.LastError = now, err
+	} else {
+		l.stats.Reloads++
+		l.stats.LastSuccess = now
+		l.stats.LastKnownGood = false
+	}
+	l.history = append(l.history, ReloadEvent{Time: now, Changed: changed, Err: err})
+	if len(l.history) > maxHistory {
+		l.history = l.history[len(l.history)-maxHistory:]
+	}
+	errorSubs := append([]func(error){}, l.errorSubs...)
+	l.mu.Unlock()
+	if err != nil {
+		for _, fn := range errorSubs {
+			fn(err)
+		}
+	}
+	return err
+}
+
+func (l *Live[T]) reload(ctx context.Context) (bool, error) {
+	data, format, err := l.src.Fetch(ctx)
+	l.mu.RLock()
+	stale := l.stale
+	if errors.Is(err, ErrNotModified) && stale {
+		data, format, err = l.data, l.format, nil
+	}
+	unchanged := l.current != nil && bytes.Equal(data, l.data)
+	l.mu.RUnlock()
+	if errors.Is(err, ErrNotModified) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if unchanged && !stale {
+		return false, nil
+	}
+	cfg := new(T)
+	res, env, err := l.decode(ctx, format, data, cfg)
+	if err != nil {
+		return false, err
+	}
+	l.cache.retainTouched()
+	if l.opts.lastKnownGood != "" {
+		if err := writeFileAtomic(l.opts.lastKnownGood, data); err != nil {
+			return false, fmt.Errorf("error saving last-known-good config: %w", err)
+		}
+	}
+
+	l.mu.Lock()
+	old := l.current
+	l.current, l.data, l.format, l.env, l.result, l.stale = cfg, data, format, env, res, false
+	subs := append([]func(old, new *T){}, l.subs...)
+	l.mu.Unlock()
+	if old != nil {
+		for _, fn := range subs {
+			fn(old, cfg)
+		}
+	}
+	return true, nil
+}
+
+// envReading is the value of an environment variable as a decode saw it.
+type envReading struct {
+	val string
+	ok  bool
+}
+
+// envReadings are the environment variables a decode read, and where it
+// read them from, which WatchEnv reads them from again.
+type envReadings struct {
+	vars map[string]envReading
+	view envView
+}
+
+// decode decodes data into cfg and returns the decode's Result and the
+// environment variables it read.
+func (l *Live[T]) decode(ctx context.Context, format string, data []byte, cfg *T) (*Result, envReadings, error) {
+	res := &Result{}
+	env := envReadings{vars: map[string]envReading{}}
+	observe := withEnvObserver(func(name, val string, ok bool) {
+		env.vars[name] = envReading{val, ok}
+	})
+	opts := append([]Option{WithContext(ctx), WithResolutionCache(l.cache), WithResult(res), observe}, l.opts.decode...)
+	d := newDecoder(opts)
+	defer d.close()
+	env.view = d.envView()
+	rawMap, err := d.parse(format, data)
+	if err != nil {
+		return res, env, err
+	}
+	return res, env, d.decode(cfg, rawMap)
+}
+
+// writeFileAtomic replaces path with data without exposing a partially
+// written file to concurrent readers.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// PollOptions control how often Poll checks the source for changes.
+type PollOptions struct {
+	// Interval between checks; defaults to 30s.
+	Interval time.Duration
+	// Jitter adds a random delay of up to this duration to each interval,
+	// so a fleet of instances does not hit the source in lockstep.
+	Jitter time.Duration
+	// OnError is called when a reload started by Poll fails, in addition
+	// to the callbacks registered with Live.OnError.
+	OnError func(error)
+}
+
+// Poll reloads the configuration periodically until ctx is cancelled.
+func (l *Live[T]) Poll(ctx context.Context, opts PollOptions) {
+	pollLoop(ctx, opts, func() error {
+		return l.Reload(ctx)
+	})
+}
+
+// WatchEnv checks the environment variables referenced by the current
+// configuration at each interval, reading them where its decode did, and
+// reloads it when any of them changed, until ctx is cancelled. This picks
+// up variables rewritten in-process, e.g. re-exported from files an
+// orchestrator updates. Failed reloads are reported like those started by
+// Poll.
+func (l *Live[T]) WatchEnv(ctx context.Context, opts PollOptions) {
+	pollLoop(ctx, opts, func() error {
+		if !l.envChanged() {
+			return nil
+		}
+		l.mu.Lock()
+		l.stale = true
+		l.mu.Unlock()
+		return l.Reload(ctx)
+	})
+}
+
+func (l *Live[T]) envChanged() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for name, seen := range l.env.vars {
+		val, ok := l.env.view.lookup(name)
+		if val != seen.val || ok != seen.ok {
+			return true
+		}
+	}
+	return false
+}
+
+// pollLoop calls check at the intervals described by opts until ctx is
+// cancelled, passing its errors to opts.OnError.
+func pollLoop(ctx context.Context, opts PollOptions, check func() error) {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	for {
+		wait := interval
+		if opts.Jitter > 0 {
+			wait += rand.N(opts.Jitter)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if err := check(); err != nil && opts.OnError != nil && ctx.Err() == nil {
+			opts.OnError(err)
+		}
+	}
+}
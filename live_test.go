@@ -0,0 +1,228 @@
+package jenv_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+type configServer struct {
+	mu          sync.Mutex
+	body        string
+	etag        string
+	notModified atomic.Int32
+}
+
+func (s *configServer) set(body, etag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body, s.etag = body, etag
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Header.Get("If-None-Match") == s.etag {
+		s.notModified.Add(1)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", s.etag)
+	w.Write([]byte(s.body))
+}
+
+func TestLiveReload(t *testing.T) {
+	cs := &configServer{}
+	cs.set(`{"service": {"name": "v1"}}`, `"1"`)
+	srv := httptest.NewServer(cs)
+	defer srv.Close()
+
+	live, err := jenv.NewLive[Config](context.Background(), jenv.URISource(srv.URL+"/config.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", live.Get().Service.Name)
+
+	var changes atomic.Int32
+	live.OnChange(func(old, new *Config) {
+		assert.Equal(t, "v1", old.Service.Name)
+		assert.Equal(t, "v2", new.Service.Name)
+		changes.Add(1)
+	})
+
+	require.NoError(t, live.Reload(context.Background()))
+	assert.Equal(t, int32(1), cs.notModified.Load())
+	assert.Equal(t, int32(0), changes.Load())
+
+	cs.set(`{"service": {"name": "v2"}}`, `"2"`)
+	require.NoError(t, live.Reload(context.Background()))
+	assert.Equal(t, "v2", live.Get().Service.Name)
+	assert.Equal(t, int32(1), changes.Load())
+}
+
+func TestLivePoll(t *testing.T) {
+	cs := &configServer{}
+	cs.set(`{"service": {"name": "v1"}}`, `"1"`)
+	srv := httptest.NewServer(cs)
+	defer srv.Close()
+
+	live, err := jenv.NewLive[Config](context.Background(), jenv.URISource(srv.URL+"/config.json"))
+	require.NoError(t, err)
+	changed := make(chan string, 1)
+	live.OnChange(func(_, new *Config) { changed <- new.Service.Name })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go live.Poll(ctx, jenv.PollOptions{Interval: 10 * time.Millisecond, Jitter: 5 * time.Millisecond})
+
+	cs.set(`{"service": {"name": "v2"}}`, `"2"`)
+	select {
+	case name := <-changed:
+		assert.Equal(t, "v2", name)
+	case <-time.After(2 * time.Second):
+		t.Fatal("poll did not pick up the change")
+	}
+}
+
+func TestLiveKeepsConfigOnDecodeError(t *testing.T) {
+	cs := &configServer{}
+	cs.set(`{"service": {"name": "v1"}}`, `"1"`)
+	srv := httptest.NewServer(cs)
+	defer srv.Close()
+
+	live, err := jenv.NewLive[Config](context.Background(), jenv.URISource(srv.URL+"/config.json"))
+	require.NoError(t, err)
+	cs.set(`{"service": {"name": "v2", "timeout": "soon"}}`, `"2"`)
+	assert.Error(t, live.Reload(context.Background()))
+	assert.Equal(t, "v1", live.Get().Service.Name)
+}
+
+func TestLiveLastKnownGood(t *testing.T) {
+	cs := &configServer{}
+	cs.set(`{"service": {"name": "v1"}}`, `"1"`)
+	srv := httptest.NewServer(cs)
+	lkg := filepath.Join(t.TempDir(), "last-known-good.json")
+
+	live, err := jenv.NewLive[Config](context.Background(), jenv.URISource(srv.URL+"/config.json"), jenv.WithLastKnownGood(lkg))
+	require.NoError(t, err)
+	var failures []error
+	live.OnError(func(err error) { failures = append(failures, err) })
+
+	srv.Close()
+	assert.Error(t, live.Reload(context.Background()))
+	assert.Equal(t, "v1", live.Get().Service.Name)
+	assert.Len(t, failures, 1)
+	stats := live.Stats()
+	assert.Equal(t, 1, stats.Reloads)
+	assert.Equal(t, 1, stats.Failures)
+	assert.Error(t, stats.LastError)
+
+	// a fresh instance starts from the persisted document while the source is down
+	restarted, err := jenv.NewLive[Config](context.Background(), jenv.URISource(srv.URL+"/config.json"), jenv.WithLastKnownGood(lkg))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", restarted.Get().Service.Name)
+	assert.True(t, restarted.Stats().LastKnownGood)
+}
+
+type countingStore struct {
+	mu    sync.Mutex
+	calls map[string]int
+	value string
+}
+
+func (s *countingStore) Get(_ context.Context, ref string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[ref]++
+	return s.value + "-" + ref, nil
+}
+
+func TestLiveIncrementalResolution(t *testing.T) {
+	store := &countingStore{calls: map[string]int{}, value: "v1"}
+	jenv.RegisterSecretStore("counted", store)
+	defer jenv.UnregisterSecretStore("counted")
+
+	type secrets struct {
+		DB    string `json:"db"`
+		Cache string `json:"cache"`
+	}
+	cs := &configServer{}
+	cs.set(`{"db": "${counted:db}", "cache": "${counted:cache}"}`, `"1"`)
+	srv := httptest.NewServer(cs)
+	defer srv.Close()
+
+	live, err := jenv.NewLive[secrets](context.Background(), jenv.URISource(srv.URL+"/secrets.json"))
+	require.NoError(t, err)
+
+	// a changed key only resolves its new placeholder
+	cs.set(`{"db": "${counted:db}", "cache": "${counted:cache2}"}`, `"2"`)
+	require.NoError(t, live.Reload(context.Background()))
+	assert.Equal(t, map[string]int{"db": 1, "cache": 1, "cache2": 1}, store.calls)
+
+	// an invalidated entry is fetched again even though the document is unchanged
+	store.value = "v2"
+	live.Invalidate("counted:db")
+	require.NoError(t, live.Reload(context.Background()))
+	assert.Equal(t, map[string]int{"db": 2, "cache": 1, "cache2": 1}, store.calls)
+	assert.Equal(t, "v2-db", live.Get().DB)
+	assert.Equal(t, "v1-cache2", live.Get().Cache)
+}
+
+func TestLiveWatchEnv(t *testing.T) {
+	t.Setenv("LIVE_SERVICE_NAME", "v1")
+	cs := &configServer{}
+	cs.set(`{"service": {"name": "${LIVE_SERVICE_NAME}", "enabled": "${LIVE_ENABLED:false}"}}`, `"1"`)
+	srv := httptest.NewServer(cs)
+	defer srv.Close()
+
+	live, err := jenv.NewLive[Config](context.Background(), jenv.URISource(srv.URL+"/config.json"))
+	require.NoError(t, err)
+	changed := make(chan *Config, 2)
+	live.OnChange(func(_, new *Config) { changed <- new })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go live.WatchEnv(ctx, jenv.PollOptions{Interval: 10 * time.Millisecond})
+
+	t.Setenv("LIVE_SERVICE_NAME", "v2")
+	select {
+	case cfg := <-changed:
+		assert.Equal(t, "v2", cfg.Service.Name)
+	case <-time.After(2 * time.Second):
+		t.Fatal("env change was not picked up")
+	}
+
+	t.Setenv("LIVE_ENABLED", "true")
+	select {
+	case cfg := <-changed:
+		assert.True(t, cfg.Service.Enabled, "newly set variables are detected too")
+	case <-time.After(2 * time.Second):
+		t.Fatal("env change was not picked up")
+	}
+	assert.Positive(t, cs.notModified.Load(), "the document itself is not fetched again")
+}
+
+func TestLiveWatchEnvUsesDecodeSource(t *testing.T) {
+	t.Setenv("LIVE_REPLAY_NAME", "process")
+	src := jenv.SourceFunc(func(context.Context) ([]byte, string, error) {
+		return []byte(`{"service": {"name": "${LIVE_REPLAY_NAME}"}}`), "json", nil
+	})
+	replay := jenv.WithEnvReplay(jenv.EnvSnapshot{"LIVE_REPLAY_NAME": "replayed"})
+	live, err := jenv.NewLive[Config](context.Background(), src, jenv.WithDecodeOptions(replay))
+	require.NoError(t, err)
+	assert.Equal(t, "replayed", live.Get().Service.Name)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go live.WatchEnv(ctx, jenv.PollOptions{Interval: 10 * time.Millisecond})
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	assert.Equal(t, 1, live.Stats().Reloads, "the replayed value has not changed")
+}
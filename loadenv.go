@@ -0,0 +1,119 @@
+package jenv
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// LoadEnv populates cfg from environment variables alone, for services
+// without a configuration file. Each field reads the variable named after
+// its document key, upper-cased, or after its Go name split into words:
+// DatabaseURL reads DATABASE_URL. Fields of nested structs are prefixed
+// with the struct's name, so Database.Host reads DATABASE_HOST, or with
+// its `envPrefix` tag when it has one. WithEnvPrefix prefixes every name,
+// as in APP_DATABASE_HOST. Fields with an `env` tag read exactly the
+// variable it names, after the `envPrefix` tags of enclosing structs and
+// any WithEnvTagPrefix prefix.
+//
+// Values decode as they would from a document, slices split on commas and
+// maps written as "k1=v1,k2=v2" unless other separators are given, and the
+// `default` and `required` tags apply.
+func LoadEnv(cfg any, opts ...Option) error {
+	opts = append([]Option{WithMapSeparators(",", "=")}, opts...)
+	d := newDecoder(opts)
+	defer d.close()
+	d.fromEnv = true
+	rawMap := d.envMap(reflect.TypeOf(cfg).Elem(), d.envPrefix, "", map[reflect.Type]bool{})
+	return d.decode(cfg, rawMap)
+}
+
+// WithEnvPrefix sets the prefix LoadEnv puts before variable names, such
+// as "APP_".
+func WithEnvPrefix(prefix string) Option {
+	return func(o *options) {
+		o.envPrefix = prefix
+	}
+}
+
+// WithEnvTagPrefix sets the prefix put before the variables named by `env`
+// tags, in documents and in LoadEnv, so WithEnvTagPrefix("APP_") binds
+// `env:"PORT"` to APP_PORT.
+func WithEnvTagPrefix(prefix string) Option {
+	return func(o *options) {
+		o.envTagPrefix = prefix
+	}
+}
+
+// WithEnvNameFunc sets how LoadEnv turns a field into a segment of a
+// variable name. fn receives the field's document key, or its Go name
+// when untagged; the default upper-cases it and splits camel case into
+// words separated by underscores.
+func WithEnvNameFunc(fn func(name string) string) Option {
+	return func(o *options) {
+		o.envNameFunc = fn
+	}
+}
+
+// envMap reads the variables for the fields of typ into a raw document.
+// Structs already being read further up are skipped, so recursive types
+// terminate.
+func (d *decoder) envMap(typ reflect.Type, prefix, path string, visiting map[reflect.Type]bool) map[string]any {
+	visiting[typ] = true
+	defer delete(visiting, typ)
+	out := map[string]any{}
+	for _, field := range structFields(typ, d.tagOrder) {
+		if field.Tag.Get("env") != "" {
+			continue
+		}
+		key := fieldKey(field, d.tagOrder)
+		name := prefix + d.envName(key)
+		if nested := nestedStruct(field.Type); nested != nil {
+			if visiting[nested] {
+				continue
+			}
+			childPrefix := name + "_"
+			if p, ok := field.Tag.Lookup("envPrefix"); ok {
+				childPrefix = prefix + p
+			}
+			if m := d.envMap(nested, childPrefix, joinPath(path, key), visiting); len(m) > 0 {
+				out[key] = m
+			}
+			continue
+		}
+		if v, ok := d.bindEnv(name, joinPath(path, key)); ok {
+			out[key] = v
+		}
+	}
+	return out
+}
+
+func (d *decoder) envName(key string) string {
+	if d.envNameFunc != nil {
+		return d.envNameFunc(key)
+	}
+	return envWords(key)
+}
+
+// envWords turns a key or Go name into an upper-case variable name,
+// splitting camel case into words: "DatabaseURL" becomes "DATABASE_URL"
+// and "db-host" becomes "DB_HOST".
+func envWords(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if r == '-' || r == '.' || r == ' ' {
+			b.WriteByte('_')
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && nextLower {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
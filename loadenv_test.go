@@ -0,0 +1,67 @@
+package jenv_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestLoadEnv(t *testing.T) {
+	type database struct {
+		Host string `json:"host"`
+		Port int    `json:"port" default:"5432"`
+	}
+	type config struct {
+		ServiceName string            `json:"service_name" required:"true"`
+		DatabaseURL string            // untagged: DATABASE_URL
+		HTTPPort    int               // untagged: HTTP_PORT
+		Timeout     time.Duration     `json:"timeout" default:"30s"`
+		Hosts       []string          `json:"hosts"`
+		Labels      map[string]string `json:"labels"`
+		Token       string            `json:"token" env:"LOADENV_TOKEN"`
+		Database    database          `json:"database"`
+		Replica     *database         `json:"replica"`
+		Cache       database          `json:"cache" envPrefix:"REDIS_"`
+		Next        *config           `json:"next"`
+	}
+	t.Setenv("APP_SERVICE_NAME", "billing")
+	t.Setenv("APP_DATABASE_URL", "postgres://db/${NOT_EXPANDED}")
+	t.Setenv("APP_HTTP_PORT", "8080")
+	t.Setenv("APP_HOSTS", "a, b")
+	t.Setenv("APP_LABELS", "team=payments,tier=1")
+	t.Setenv("APP_DATABASE_HOST", "db.internal")
+	t.Setenv("LOADENV_TOKEN", "s3cret")
+	t.Setenv("APP_REDIS_HOST", "redis.internal")
+
+	var cfg config
+	require.NoError(t, jenv.LoadEnv(&cfg, jenv.WithEnvPrefix("APP_")))
+	assert.Equal(t, "billing", cfg.ServiceName)
+	assert.Equal(t, "postgres://db/${NOT_EXPANDED}", cfg.DatabaseURL)
+	assert.Equal(t, 8080, cfg.HTTPPort)
+	assert.Equal(t, 30*time.Second, cfg.Timeout)
+	assert.Equal(t, []string{"a", "b"}, cfg.Hosts)
+	assert.Equal(t, map[string]string{"team": "payments", "tier": "1"}, cfg.Labels)
+	assert.Equal(t, "s3cret", cfg.Token)
+	assert.Equal(t, database{Host: "db.internal", Port: 5432}, cfg.Database)
+	assert.Nil(t, cfg.Replica)
+	assert.Equal(t, database{Host: "redis.internal", Port: 5432}, cfg.Cache)
+	// The env binding inside Next keeps it, but the recursion stops there.
+	require.NotNil(t, cfg.Next)
+	assert.Equal(t, "s3cret", cfg.Next.Token)
+	assert.Nil(t, cfg.Next.Next)
+
+	err := jenv.LoadEnv(&config{}, jenv.WithEnvPrefix("OTHER_"))
+	assert.EqualError(t, err, "error decoding 'service_name': required field is not set")
+
+	t.Setenv("APP_service-name", "custom")
+	cfg = config{}
+	require.NoError(t, jenv.LoadEnv(&cfg, jenv.WithEnvPrefix("APP_"), jenv.WithEnvNameFunc(func(name string) string {
+		return strings.ReplaceAll(name, "_", "-")
+	})))
+	assert.Equal(t, "custom", cfg.ServiceName)
+}
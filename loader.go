@@ -0,0 +1,174 @@
+package jenv
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oarkflow/jenv/internal/docmap"
+)
+
+// Loader fetches a raw configuration document identified by a URI.
+type Loader interface {
+	Load(ctx context.Context, uri string) ([]byte, error)
+}
+
+// LoaderFunc adapts an ordinary function to the Loader interface.
+type LoaderFunc func(ctx context.Context, uri string) ([]byte, error)
+
+func (f LoaderFunc) Load(ctx context.Context, uri string) ([]byte, error) {
+	return f(ctx, uri)
+}
+
+var loaderHTTPClient = &http.Client{Timeout: time.Minute}
+
+var (
+	loadersMu sync.RWMutex
+	loaders   = map[string]Loader{
+		"file":  LoaderFunc(loadLocalFile),
+		"s3":    s3Loader{},
+		"gs":    gcsLoader{},
+		"http":  httpLoader{},
+		"https": httpLoader{},
+
+		"git+https": LoaderFunc(loadGit),
+		"git+http":  LoaderFunc(loadGit),
+		"git+ssh":   LoaderFunc(loadGit),
+		"git+file":  LoaderFunc(loadGit),
+	}
+)
+
+// RegisterLoader makes loader fetch documents whose URI uses the given
+// scheme, replacing any loader previously registered for it.
+func RegisterLoader(scheme string, loader Loader) {
+	if scheme == "" || strings.ContainsAny(scheme, ":/ ") {
+		panic(fmt.Sprintf("jenv: invalid loader scheme %q", scheme))
+	}
+	if loader == nil {
+		panic("jenv: RegisterLoader loader is nil")
+	}
+	loadersMu.Lock()
+	defer loadersMu.Unlock()
+	loaders[scheme] = loader
+}
+
+// Load fetches the document at uri and decodes it into cfg. URIs such as
+// `s3://bucket/key` or `gs://bucket/key` are fetched by the loader
+// registered for their scheme and plain paths are read from disk. The
+// format is taken from the file extension, falling back to sniffing the
+// content.
+func Load(ctx context.Context, uri string, cfg any, opts ...Option) error {
+	data, err := fetchDocument(ctx, uri)
+	if err != nil {
+		return err
+	}
+	opts = append([]Option{WithContext(ctx), withDocument(uri)}, opts...)
+	return unmarshalFormat(documentFormat(uri, data), data, cfg, opts...)
+}
+
+// LoadFile reads the document at path and decodes it into cfg, choosing
+// the format from the extension or, failing that, from the content.
+func LoadFile(path string, cfg any, opts ...Option) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	opts = append([]Option{withDocument(path)}, opts...)
+	return unmarshalFormat(documentFormat(path, data), data, cfg, opts...)
+}
+
+// LoadDir decodes every configuration file in dir into cfg, conf.d style.
+// Files are read in lexical order (such as 00-base.yaml, then
+// 10-overrides.json) and deep-merged before decoding: mappings are merged
+// key by key, and any other value in a later file replaces the earlier
+// one. Files whose extension is not a known format, hidden files and
+// subdirectories are skipped.
+func LoadDir(dir string, cfg any, opts ...Option) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	d := newDecoder(opts)
+	defer d.close()
+	merged := map[string]any{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		formatsMu.RLock()
+		format, ok := formatExtensions[strings.ToLower(filepath.Ext(name))]
+		formatsMu.RUnlock()
+		if !ok {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := d.mergeDocument(merged, path, format, data); err != nil {
+			return err
+		}
+	}
+	return d.decode(cfg, merged)
+}
+
+// mergeDocument parses the document read from path and deep-merges it
+// into merged.
+func (d *decoder) mergeDocument(merged map[string]any, path, format string, data []byte) error {
+	rawMap, err := d.parse(format, data)
+	if err != nil {
+		return fmt.Errorf("error loading '%s': %w", path, err)
+	}
+	d.recordDocument(path)
+	docmap.Merge(merged, rawMap)
+	return nil
+}
+
+func fetchDocument(ctx context.Context, uri string) ([]byte, error) {
+	scheme := uriScheme(uri)
+	if scheme == "" {
+		return loadLocalFile(ctx, uri)
+	}
+	loadersMu.RLock()
+	loader, ok := loaders[scheme]
+	loadersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no loader registered for scheme '%s'", scheme)
+	}
+	data, err := loader.Load(ctx, uri)
+	if err != nil {
+		return nil, fmt.Errorf("error loading '%s': %w", uri, err)
+	}
+	return data, nil
+}
+
+// uriScheme returns the scheme of uri, or "" when uri is a plain path.
+// Single letters are treated as Windows drive names rather than schemes.
+func uriScheme(uri string) string {
+	i := strings.Index(uri, "://")
+	if i <= 1 {
+		return ""
+	}
+	return uri[:i]
+}
+
+func loadLocalFile(_ context.Context, uri string) ([]byte, error) {
+	return os.ReadFile(strings.TrimPrefix(uri, "file://"))
+}
+
+// splitBucketURI splits `scheme://bucket/key` into bucket and key.
+func splitBucketURI(uri string) (string, string, error) {
+	rest := uri[strings.Index(uri, "://")+3:]
+	bucket, key, _ := strings.Cut(rest, "/")
+	if bucket == "" || key == "" {
+		return "", "", fmt.Errorf("invalid object URI '%s', expected scheme://bucket/key", uri)
+	}
+	return bucket, key, nil
+}
@@ -0,0 +1,56 @@
+package jenv
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/oarkflow/jenv/internal/gcpauth"
+)
+
+// gcsLoader fetches `gs://bucket/object` through the Cloud Storage JSON
+// API using application default credentials. STORAGE_EMULATOR_HOST points
+// the loader at an emulator, without authentication, like the official
+// SDK. Object generations serve as document versions.
+type gcsLoader struct{}
+
+func (l gcsLoader) Load(ctx context.Context, uri string) ([]byte, error) {
+	data, _, err := l.LoadIfChanged(ctx, uri, "")
+	return data, err
+}
+
+func (gcsLoader) LoadIfChanged(ctx context.Context, uri, version string) ([]byte, string, error) {
+	bucket, object, err := splitBucketURI(uri)
+	if err != nil {
+		return nil, "", err
+	}
+	base, token := "https://storage.googleapis.com", ""
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		base = host
+		if uriScheme(host) == "" {
+			base = "http://" + host
+		}
+	} else if token, err = gcpauth.Token(ctx); err != nil {
+		return nil, "", err
+	}
+	query := url.Values{"alt": {"media"}}
+	if version != "" {
+		query.Set("ifGenerationNotMatch", version)
+	}
+	endpoint := base + "/storage/v1/b/" + url.PathEscape(bucket) + "/o/" + url.PathEscape(object) + "?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := loaderHTTPClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	generation := resp.Header.Get("X-Goog-Generation")
+	data, _, err := readVersionedResponse(resp, nil)
+	return data, generation, err
+}
@@ -0,0 +1,80 @@
+package jenv
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// immutableRef matches refs that are safe to serve from the local cache
+// without contacting the remote: full commit hashes and version tags.
+var immutableRef = regexp.MustCompile(`^([0-9a-f]{40}|v?\d+(\.\d+)*([-+][0-9A-Za-z.-]+)?)$`)
+
+// loadGit fetches a file from a git repository addressed as
+// `git+https://host/repo.git#ref:path/to/file`. Fetched refs are kept in a
+// bare repository under the user cache dir, so pinned refs are only
+// downloaded once.
+func loadGit(ctx context.Context, uri string) ([]byte, error) {
+	repo, ref, file, err := parseGitURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(repo))
+	dir := filepath.Join(cacheDir, "jenv", "git", hex.EncodeToString(sum[:8]))
+	if _, err := os.Stat(filepath.Join(dir, "HEAD")); err != nil {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+		if _, err := runGit(ctx, dir, "init", "--bare", "--quiet"); err != nil {
+			return nil, err
+		}
+	}
+	local := "refs/jenv/" + strings.ReplaceAll(ref, "..", "_")
+	if _, err := runGit(ctx, dir, "rev-parse", "--verify", "--quiet", local); err != nil || !immutableRef.MatchString(ref) {
+		if _, err := runGit(ctx, dir, "fetch", "--quiet", "--depth", "1", "--no-tags", repo, "+"+ref+":"+local); err != nil {
+			return nil, err
+		}
+	}
+	return runGit(ctx, dir, "show", local+":"+file)
+}
+
+// parseGitURI splits a git loader URI into repository URL, ref and path.
+// The ref defaults to HEAD when the fragment only names a file.
+func parseGitURI(uri string) (string, string, string, error) {
+	repo, fragment, ok := strings.Cut(strings.TrimPrefix(uri, "git+"), "#")
+	if !ok || fragment == "" {
+		return "", "", "", fmt.Errorf("invalid git URI '%s', expected git+<url>#<ref>:<path>", uri)
+	}
+	ref, file, ok := strings.Cut(fragment, ":")
+	if !ok {
+		ref, file = "HEAD", fragment
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+	file = strings.TrimPrefix(file, "/")
+	if file == "" {
+		return "", "", "", fmt.Errorf("invalid git URI '%s', missing file path", uri)
+	}
+	return repo, ref, file, nil
+}
+
+func runGit(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w", args[0], commandError(err))
+	}
+	return out, nil
+}
@@ -0,0 +1,53 @@
+package jenv_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestLoadGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	repo := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	writeConfig := func(name string) {
+		os.MkdirAll(filepath.Join(repo, "configs"), 0o755)
+		os.WriteFile(filepath.Join(repo, "configs", "app.yaml"), []byte("service:\n  name: "+name+"\n"), 0o644)
+	}
+	git("init", "--quiet")
+	writeConfig("v1-service")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "v1")
+	git("tag", "v1.0.0")
+	writeConfig("v2-service")
+	git("commit", "--quiet", "-am", "v2")
+
+	var config Config
+	require.NoError(t, jenv.Load(context.Background(), "git+file://"+repo+"#v1.0.0:configs/app.yaml", &config))
+	assert.Equal(t, "v1-service", config.Service.Name)
+
+	// pinned tags are served from the cache even when the remote is gone
+	os.RemoveAll(filepath.Join(repo, ".git"))
+	config = Config{}
+	require.NoError(t, jenv.Load(context.Background(), "git+file://"+repo+"#v1.0.0:configs/app.yaml", &config))
+	assert.Equal(t, "v1-service", config.Service.Name)
+}
+
+func TestLoadGitInvalidURI(t *testing.T) {
+	var config Config
+	assert.Error(t, jenv.Load(context.Background(), "git+https://example.com/repo.git", &config))
+}
@@ -0,0 +1,243 @@
+package jenv
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrNotModified is returned by conditional loads when the document has
+// not changed since the version the caller already has.
+var ErrNotModified = errors.New("document not modified")
+
+// ConditionalLoader is implemented by loaders that can avoid transferring
+// a document which has not changed, e.g. through HTTP ETags. The version
+// is an opaque token returned by a previous call; an empty version always
+// loads the document.
+type ConditionalLoader interface {
+	Loader
+	LoadIfChanged(ctx context.Context, uri, version string) (data []byte, newVersion string, err error)
+}
+
+type httpLoader struct{}
+
+func (l httpLoader) Load(ctx context.Context, uri string) ([]byte, error) {
+	data, _, err := l.LoadIfChanged(ctx, uri, "")
+	return data, err
+}
+
+func (httpLoader) LoadIfChanged(ctx context.Context, uri, version string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if version != "" {
+		req.Header.Set("If-None-Match", version)
+	}
+	return readVersionedResponse(loaderHTTPClient.Do(req))
+}
+
+// readVersionedResponse reads a response that may answer a conditional
+// request, returning its ETag as the document version.
+func readVersionedResponse(resp *http.Response, err error) ([]byte, string, error) {
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	version := resp.Header.Get("ETag")
+	switch resp.StatusCode {
+	case http.StatusOK:
+		data, err := io.ReadAll(resp.Body)
+		return data, version, err
+	case http.StatusNotModified:
+		return nil, version, ErrNotModified
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, "", fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
+
+// loadIfChanged loads uri conditionally when its loader supports it.
+func loadIfChanged(ctx context.Context, uri, version string) ([]byte, string, error) {
+	loadersMu.RLock()
+	loader, ok := loaders[uriScheme(uri)]
+	loadersMu.RUnlock()
+	if cl, isConditional := loader.(ConditionalLoader); ok && isConditional {
+		data, newVersion, err := cl.LoadIfChanged(ctx, uri, version)
+		if err != nil && !errors.Is(err, ErrNotModified) {
+			return nil, "", fmt.Errorf("error loading '%s': %w", uri, err)
+		}
+		return data, newVersion, err
+	}
+	data, err := fetchDocument(ctx, uri)
+	return data, "", err
+}
+
+// LoadURL fetches the document at an http(s) URL and decodes it into cfg,
+// for services that bootstrap from a config service. The format is taken
+// from the URL's extension, then the response's Content-Type, then the
+// content. Placeholders resolve as they do in any document. WithHeader,
+// WithHTTPTimeout, WithRetry, WithHTTPClient and WithETag configure the
+// request:
+//
+//	var etag string
+//	err := jenv.LoadURL(ctx, "https://config.internal/app.yaml", &cfg,
+//		jenv.WithHeader("Authorization", "Bearer "+token),
+//		jenv.WithHTTPTimeout(5*time.Second),
+//		jenv.WithRetry(3, time.Second),
+//		jenv.WithETag(&etag))
+//
+// With WithETag, a document that has not changed returns ErrNotModified
+// and leaves cfg untouched.
+func LoadURL(ctx context.Context, url string, cfg any, opts ...Option) error {
+	opts = append([]Option{WithContext(ctx), withDocument(url)}, opts...)
+	d := newDecoder(opts)
+	defer d.close()
+	data, contentType, version, err := d.fetchURL(ctx, url)
+	if err != nil {
+		return err
+	}
+	format := documentFormat(url, data)
+	if f, ok := contentTypeFormat(contentType); ok && !knownFormat(urlPath(url)) {
+		format = f
+	}
+	rawMap, err := d.parse(format, data)
+	if err != nil {
+		return err
+	}
+	if err := d.decode(cfg, rawMap); err != nil {
+		return err
+	}
+	// Only a version that decoded is recorded, so a broken one is fetched
+	// again next time rather than answered with ErrNotModified.
+	if d.etag != nil {
+		*d.etag = version
+	}
+	return nil
+}
+
+// WithHeader adds a header to the requests LoadURL makes, such as
+// WithHeader("Authorization", "Bearer "+token). Repeated calls add to the
+// set.
+func WithHeader(key, value string) Option {
+	return func(o *options) {
+		if o.httpHeader == nil {
+			o.httpHeader = http.Header{}
+		}
+		o.httpHeader.Add(key, value)
+	}
+}
+
+// WithHTTPTimeout bounds each attempt LoadURL makes to fetch a document.
+// Without it the default client's one-minute timeout applies.
+func WithHTTPTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.httpTimeout = d
+	}
+}
+
+// WithHTTPClient makes LoadURL send its requests through client, e.g. for
+// mutual TLS.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithRetry makes LoadURL retry a failed request up to retries more times:
+// connection errors, 429 Too Many Requests and 5xx responses are retried,
+// other statuses are not. The wait starts at backoff and doubles with each
+// attempt.
+func WithRetry(retries int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.retries, o.retryBackoff = retries, backoff
+	}
+}
+
+// WithETag makes LoadURL fetch conditionally: a non-empty *etag is sent as
+// If-None-Match, and the response's ETag is stored back into it once the
+// document has decoded. When the document has not changed LoadURL returns
+// ErrNotModified.
+func WithETag(etag *string) Option {
+	return func(o *options) {
+		o.etag = etag
+	}
+}
+
+// fetchURL fetches url with the decode's request options, retrying as
+// WithRetry allows, and returns the body, its Content-Type and its ETag.
+func (d *decoder) fetchURL(ctx context.Context, url string) (data []byte, contentType, version string, err error) {
+	for attempt := 0; ; attempt++ {
+		data, contentType, version, retry, err := d.fetchURLOnce(ctx, url)
+		if err == nil || errors.Is(err, ErrNotModified) {
+			return data, contentType, version, err
+		}
+		if !retry || attempt >= d.retries {
+			return nil, "", "", fmt.Errorf("error loading '%s': %w", url, err)
+		}
+		wait := cmp.Or(d.retryBackoff, time.Second) << attempt
+		select {
+		case <-ctx.Done():
+			return nil, "", "", fmt.Errorf("error loading '%s': %w", url, ctx.Err())
+		case <-time.After(wait):
+		}
+	}
+}
+
+// fetchURLOnce makes a single request, reporting whether a failure may be
+// retried.
+func (d *decoder) fetchURLOnce(ctx context.Context, url string) (data []byte, contentType, version string, retry bool, err error) {
+	if d.httpTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.httpTimeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", "", false, err
+	}
+	for key, values := range d.httpHeader {
+		req.Header[key] = values
+	}
+	if d.etag != nil && *d.etag != "" {
+		req.Header.Set("If-None-Match", *d.etag)
+	}
+	resp, err := cmp.Or(d.httpClient, loaderHTTPClient).Do(req)
+	if err != nil {
+		return nil, "", "", true, err
+	}
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	contentType = resp.Header.Get("Content-Type")
+	data, version, err = readVersionedResponse(resp, nil)
+	return data, contentType, version, retry, err
+}
+
+// contentTypeFormat returns the format named by a Content-Type header.
+func contentTypeFormat(contentType string) (string, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return "json", true
+	case strings.Contains(mediaType, "yaml"):
+		return "yaml", true
+	case strings.Contains(mediaType, "toml"):
+		return "toml", true
+	}
+	return "", false
+}
+
+// urlPath returns url without its query and fragment.
+func urlPath(url string) string {
+	if i := strings.IndexAny(url, "?#"); i >= 0 {
+		return url[:i]
+	}
+	return url
+}
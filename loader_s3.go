@@ -0,0 +1,45 @@
+package jenv
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/oarkflow/jenv/internal/awssig"
+)
+
+// s3Loader fetches `s3://bucket/key` using credentials discovered the same
+// way as the AWS SDKs. AWS_ENDPOINT_URL_S3 switches to path-style requests
+// against an S3-compatible endpoint such as MinIO.
+type s3Loader struct{}
+
+func (l s3Loader) Load(ctx context.Context, uri string) ([]byte, error) {
+	data, _, err := l.LoadIfChanged(ctx, uri, "")
+	return data, err
+}
+
+func (s3Loader) LoadIfChanged(ctx context.Context, uri, version string) ([]byte, string, error) {
+	bucket, key, err := splitBucketURI(uri)
+	if err != nil {
+		return nil, "", err
+	}
+	creds, err := awssig.LoadCredentials(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	region := awssig.Region()
+	endpoint := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, awssig.EscapeKey(key))
+	if e := awssig.Endpoint("s3"); e != "" {
+		endpoint = fmt.Sprintf("%s/%s/%s", e, bucket, awssig.EscapeKey(key))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if version != "" {
+		req.Header.Set("If-None-Match", version)
+	}
+	awssig.Sign(req, nil, creds, region, "s3", time.Now())
+	return readVersionedResponse(loaderHTTPClient.Do(req))
+}
@@ -0,0 +1,233 @@
+package jenv_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestLoadFile(t *testing.T) {
+	t.Setenv("SERVICE_NAME", "FileService")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte("service:\n  name: \"${SERVICE_NAME:Default}\"\n"), 0o644)
+
+	var config Config
+	assert.NoError(t, jenv.Load(context.Background(), path, &config))
+	assert.Equal(t, "FileService", config.Service.Name)
+
+	docs := map[string]string{
+		"app.json":      `{"service": {"name": "${SERVICE_NAME}"}}`,
+		"app.yml":       "service:\n  name: \"${SERVICE_NAME}\"\n",
+		"app.toml":      "[service]\nname = \"${SERVICE_NAME}\"\n",
+		"json-config":   ` {"service": {"name": "${SERVICE_NAME}"}}`,
+		"yaml-config":   "# app\nservice:\n  name: \"${SERVICE_NAME}\"\n",
+		"toml-config":   "# app\n[service]\nname = \"${SERVICE_NAME}\"\n",
+		"toml-assigned": "service.name = \"${SERVICE_NAME}\"\n",
+	}
+	for name, doc := range docs {
+		path := filepath.Join(t.TempDir(), name)
+		os.WriteFile(path, []byte(doc), 0o644)
+		var config Config
+		if assert.NoError(t, jenv.LoadFile(path, &config), name) {
+			assert.Equal(t, "FileService", config.Service.Name, name)
+		}
+	}
+	assert.Error(t, jenv.LoadFile(filepath.Join(t.TempDir(), "missing.json"), &config))
+}
+
+func TestRegisterFormat(t *testing.T) {
+	jenv.RegisterFormat("props", func(data []byte) (map[string]any, error) {
+		service := map[string]any{}
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			k, v, _ := strings.Cut(line, "=")
+			service[k] = v
+		}
+		return map[string]any{"service": service}, nil
+	}, ".props")
+	t.Setenv("PROPS_NAME", "props-service")
+	path := filepath.Join(t.TempDir(), "app.props")
+	os.WriteFile(path, []byte("name=${PROPS_NAME}\n"), 0o644)
+
+	var config Config
+	assert.NoError(t, jenv.LoadFile(path, &config))
+	assert.Equal(t, "props-service", config.Service.Name)
+}
+
+func TestLoadS3(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		assert.Equal(t, "/configs/prod/app.json", r.URL.Path)
+		w.Write([]byte(`{"service": {"name": "from-s3", "timeout": "5s"}}`))
+	}))
+	defer srv.Close()
+	t.Setenv("AWS_ENDPOINT_URL_S3", srv.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "eu-west-1")
+
+	var config Config
+	assert.NoError(t, jenv.Load(context.Background(), "s3://configs/prod/app.json", &config))
+	assert.Equal(t, "from-s3", config.Service.Name)
+}
+
+func TestLoadGCS(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/storage/v1/b/configs/o/prod/app.yaml", r.URL.Path)
+		assert.Equal(t, "media", r.URL.Query().Get("alt"))
+		w.Write([]byte("service:\n  name: from-gcs\n"))
+	}))
+	defer srv.Close()
+	t.Setenv("STORAGE_EMULATOR_HOST", srv.URL)
+
+	var config Config
+	assert.NoError(t, jenv.Load(context.Background(), "gs://configs/prod/app.yaml", &config))
+	assert.Equal(t, "from-gcs", config.Service.Name)
+}
+
+func TestLoadUnknownScheme(t *testing.T) {
+	var config Config
+	assert.Error(t, jenv.Load(context.Background(), "ftp://host/config.json", &config))
+}
+
+func TestLoadDir(t *testing.T) {
+	t.Setenv("DIR_TIMEOUT", "9s")
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "00-base.yaml"), []byte("service:\n  name: base\n  timeout: 5s\ndatabase:\n  hosts: [a, b]\n  ports: {primary: 5432, replica: 5433}\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "10-overrides.json"), []byte(`{"service": {"timeout": "${DIR_TIMEOUT}"}, "database": {"hosts": ["c"], "ports": {"replica": 6433}}}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "20-local.toml"), []byte("[service]\nname = \"local\"\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# not config"), 0o644)
+	os.WriteFile(filepath.Join(dir, ".99-hidden.yaml"), []byte("service:\n  name: hidden\n"), 0o644)
+	os.Mkdir(filepath.Join(dir, "sub.yaml"), 0o755)
+
+	var config Config
+	var res jenv.Result
+	assert.NoError(t, jenv.LoadDir(dir, &config, jenv.WithResult(&res)))
+	assert.Equal(t, "local", config.Service.Name)
+	assert.Equal(t, 9*time.Second, config.Service.Timeout)
+	assert.Equal(t, []string{"c"}, config.Database.Hosts)
+	assert.Equal(t, map[string]int{"primary": 5432, "replica": 6433}, config.Database.Ports)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "00-base.yaml"),
+		filepath.Join(dir, "10-overrides.json"),
+		filepath.Join(dir, "20-local.toml"),
+	}, res.Documents)
+
+	os.WriteFile(filepath.Join(dir, "30-broken.json"), []byte(`{`), 0o644)
+	assert.ErrorContains(t, jenv.LoadDir(dir, &config), "30-broken.json")
+}
+
+func TestLoadURL(t *testing.T) {
+	t.Setenv("LOADURL_REGION", "eu-west-1")
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if r.Header.Get("Authorization") != "Bearer t0k" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/flaky" && n%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write([]byte("name: api\nregion: ${LOADURL_REGION}\n"))
+	}))
+	defer srv.Close()
+	type config struct {
+		Name   string `yaml:"name"`
+		Region string `yaml:"region"`
+	}
+	ctx := context.Background()
+	auth := jenv.WithHeader("Authorization", "Bearer t0k")
+
+	var etag string
+	var cfg config
+	require.NoError(t, jenv.LoadURL(ctx, srv.URL+"/config", &cfg, auth, jenv.WithETag(&etag)))
+	assert.Equal(t, config{Name: "api", Region: "eu-west-1"}, cfg, "the Content-Type gives the format")
+	assert.Equal(t, `"v1"`, etag)
+	cfg = config{}
+	err := jenv.LoadURL(ctx, srv.URL+"/config", &cfg, auth, jenv.WithETag(&etag))
+	assert.ErrorIs(t, err, jenv.ErrNotModified)
+	assert.Zero(t, cfg)
+
+	calls.Store(0)
+	require.NoError(t, jenv.LoadURL(ctx, srv.URL+"/flaky", &cfg, auth, jenv.WithRetry(2, time.Millisecond)))
+	assert.Equal(t, int32(3), calls.Load())
+	calls.Store(0)
+	err = jenv.LoadURL(ctx, srv.URL+"/flaky", &cfg, auth, jenv.WithRetry(1, time.Millisecond))
+	assert.ErrorContains(t, err, "503")
+
+	calls.Store(0)
+	err = jenv.LoadURL(ctx, srv.URL+"/config", &cfg, jenv.WithRetry(3, time.Millisecond))
+	assert.ErrorContains(t, err, "401")
+	assert.Equal(t, int32(1), calls.Load(), "client errors are not retried")
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer slow.Close()
+	err = jenv.LoadURL(ctx, slow.URL, &cfg, jenv.WithHTTPTimeout(20*time.Millisecond))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestLoadURLETagAfterFailedDecode(t *testing.T) {
+	var body, etag atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag.Load() {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag.Load().(string))
+		_, _ = w.Write([]byte(body.Load().(string)))
+	}))
+	defer srv.Close()
+	type config struct {
+		Name string `yaml:"name" required:"true"`
+	}
+	ctx := context.Background()
+	var version string
+	var cfg config
+	body.Store("name: v1\n")
+	etag.Store(`"v1"`)
+	require.NoError(t, jenv.LoadURL(ctx, srv.URL+"/app.yaml", &cfg, jenv.WithETag(&version)))
+	assert.Equal(t, `"v1"`, version)
+
+	for _, broken := range []string{"name: [\n", "other: x\n"} {
+		body.Store(broken)
+		etag.Store(`"v2"`)
+		cfg = config{}
+		err := jenv.LoadURL(ctx, srv.URL+"/app.yaml", &cfg, jenv.WithETag(&version))
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, jenv.ErrNotModified)
+		assert.Equal(t, `"v1"`, version, "a revision that fails is not recorded")
+		err = jenv.LoadURL(ctx, srv.URL+"/app.yaml", &cfg, jenv.WithETag(&version))
+		assert.NotErrorIs(t, err, jenv.ErrNotModified, "so it is fetched again")
+	}
+
+	body.Store("name: v2\n")
+	require.NoError(t, jenv.LoadURL(ctx, srv.URL+"/app.yaml", &cfg, jenv.WithETag(&version)))
+	assert.Equal(t, config{Name: "v2"}, cfg)
+	assert.Equal(t, `"v2"`, version)
+}
@@ -0,0 +1,80 @@
+package jenv
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+)
+
+// LoadFS decodes the configuration files in fsys matching pattern into
+// cfg, such as the defaults shipped in a binary with go:embed:
+//
+//	//go:embed config
+//	var defaults embed.FS
+//
+//	err := jenv.LoadFS(defaults, "config/*.yaml", &cfg)
+//
+// The pattern is matched as by fs.Glob. A matching directory contributes
+// the files directly inside it, so LoadFS(defaults, "config", &cfg) loads
+// a whole directory of layered files. Files are read in lexical order of
+// their paths and deep-merged before decoding, as LoadDir does; hidden
+// files are skipped, as are files in a directory whose extension is not a
+// known format. Files matched by name have their format sniffed when the
+// extension is unknown. It is an error for nothing to match.
+func LoadFS(fsys fs.FS, pattern string, cfg any, opts ...Option) error {
+	matches, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("no configuration files match '%s': %w", pattern, fs.ErrNotExist)
+	}
+	d := newDecoder(opts)
+	defer d.close()
+	var files []string
+	for _, match := range matches {
+		info, err := fs.Stat(fsys, match)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			files = append(files, match)
+			continue
+		}
+		entries, err := fs.ReadDir(fsys, match)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() && knownFormat(entry.Name()) {
+				files = append(files, path.Join(match, entry.Name()))
+			}
+		}
+	}
+	slices.Sort(files)
+	files = slices.Compact(files)
+	merged := map[string]any{}
+	for _, name := range files {
+		if strings.HasPrefix(path.Base(name), ".") {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		if err := d.mergeDocument(merged, name, documentFormat(name, data), data); err != nil {
+			return err
+		}
+	}
+	return d.decode(cfg, merged)
+}
+
+// knownFormat reports whether name has the extension of a known format.
+func knownFormat(name string) bool {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	_, ok := formatExtensions[strings.ToLower(path.Ext(name))]
+	return ok
+}
@@ -0,0 +1,51 @@
+package jenv_test
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestLoadFS(t *testing.T) {
+	t.Setenv("LOADFS_PORT", "9090")
+	fsys := fstest.MapFS{
+		"config/00-base.yaml":     {Data: []byte("name: api\nserver:\n  host: localhost\n  port: 8080\n")},
+		"config/10-prod.json":     {Data: []byte(`{"server": {"port": "${LOADFS_PORT}"}}`)},
+		"config/.20-hidden.yaml":  {Data: []byte("name: hidden\n")},
+		"config/README.md":        {Data: []byte("# not a config\n")},
+		"config/nested/99.yaml":   {Data: []byte("name: nested\n")},
+		"overrides/local.conf":    {Data: []byte(`{"name": "local"}`)},
+		"overrides/.ignored.yaml": {Data: []byte("name: ignored\n")},
+	}
+	type config struct {
+		Name   string `yaml:"name"`
+		Server struct {
+			Host string `yaml:"host"`
+			Port int    `yaml:"port"`
+		} `yaml:"server"`
+	}
+
+	var cfg config
+	require.NoError(t, jenv.LoadFS(fsys, "config", &cfg))
+	assert.Equal(t, "api", cfg.Name)
+	assert.Equal(t, "localhost", cfg.Server.Host)
+	assert.Equal(t, 9090, cfg.Server.Port)
+
+	cfg = config{}
+	require.NoError(t, jenv.LoadFS(fsys, "config/*.yaml", &cfg))
+	assert.Equal(t, 8080, cfg.Server.Port)
+
+	cfg = config{}
+	require.NoError(t, jenv.LoadFS(fsys, "overrides/*", &cfg))
+	assert.Equal(t, "local", cfg.Name, "files matched by name are sniffed")
+
+	err := jenv.LoadFS(fsys, "configs/*.yaml", &cfg)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	err = jenv.LoadFS(fstest.MapFS{"bad.json": {Data: []byte("{")}}, "*.json", &cfg)
+	assert.ErrorContains(t, err, "error loading 'bad.json'")
+}
@@ -0,0 +1,20 @@
+package jenv
+
+import (
+	"context"
+	"strings"
+)
+
+// Lookup returns the value of key and whether it is set, unlike Getenv,
+// which reports unset and empty variables alike. A key of the form
+// "scheme:ref" naming a registered secret store is looked up there; any
+// store error, not only ErrSecretNotFound, reports the key as unset.
+func Lookup(key string) (string, bool) {
+	if scheme, ref, ok := strings.Cut(key, ":"); ok {
+		if store, ok := secretStore(scheme); ok {
+			val, err := store.Get(context.Background(), ref)
+			return val, err == nil
+		}
+	}
+	return processEnv(key)
+}
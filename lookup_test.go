@@ -0,0 +1,47 @@
+package jenv_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestLookup(t *testing.T) {
+	t.Setenv("LOOKUP_SET", "on")
+	t.Setenv("LOOKUP_EMPTY", "")
+	jenv.RegisterSecretStore("lookuptest", mapStore{"flags/beta": "yes"})
+	defer jenv.UnregisterSecretStore("lookuptest")
+
+	val, ok := jenv.Lookup("LOOKUP_SET")
+	assert.True(t, ok)
+	assert.Equal(t, "on", val)
+
+	val, ok = jenv.Lookup("LOOKUP_EMPTY")
+	assert.True(t, ok, "empty but set")
+	assert.Equal(t, "", val)
+
+	_, ok = jenv.Lookup("LOOKUP_UNSET")
+	assert.False(t, ok)
+
+	val, ok = jenv.Lookup("lookuptest:flags/beta")
+	assert.True(t, ok)
+	assert.Equal(t, "yes", val)
+	_, ok = jenv.Lookup("lookuptest:flags/missing")
+	assert.False(t, ok)
+}
+
+func TestLookupEnvSource(t *testing.T) {
+	t.Setenv("APP_EMPTY", "")
+	_, ok := jenv.Lookup("APP_EMPTY")
+	assert.True(t, ok)
+
+	src := jenv.EnvMap{"APP_NAME": "from-vault-agent", "APP_EMPTY": ""}
+	var cfg struct {
+		Name  string `json:"name"`
+		Empty string `json:"empty"`
+	}
+	assert.NoError(t, jenv.UnmarshalJSON([]byte(`{"name": "${APP_NAME}", "empty": "${APP_EMPTY}"}`), &cfg, jenv.WithStrict(), jenv.WithEnvSource(src)))
+	assert.Equal(t, "from-vault-agent", cfg.Name)
+}
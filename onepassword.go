@@ -0,0 +1,99 @@
+package jenv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 1Password Connect settings are read from the same variables used by the
+// official Connect SDKs.
+const (
+	opConnectHostEnv  = "OP_CONNECT_HOST"
+	opConnectTokenEnv = "OP_CONNECT_TOKEN"
+)
+
+var opHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// lookupOnePassword resolves a `vault/item/field` reference through the
+// 1Password Connect API. Vaults and items may be given by name or by ID.
+func lookupOnePassword(ctx context.Context, ref string) (string, error) {
+	parts := strings.SplitN(strings.TrimSpace(ref), "/", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", fmt.Errorf("invalid 1password reference '%s', expected vault/item/field", ref)
+	}
+	host, token := Getenv(opConnectHostEnv), Getenv(opConnectTokenEnv)
+	if host == "" || token == "" {
+		return "", fmt.Errorf("1password connect requires %s and %s to be set", opConnectHostEnv, opConnectTokenEnv)
+	}
+	c := &opClient{host: strings.TrimSuffix(host, "/"), token: token}
+
+	vaultID, err := c.findID(ctx, "/v1/vaults", "name", parts[0])
+	if err != nil {
+		return "", err
+	}
+	itemID, err := c.findID(ctx, "/v1/vaults/"+url.PathEscape(vaultID)+"/items", "title", parts[1])
+	if err != nil {
+		return "", err
+	}
+	var item struct {
+		Fields []struct {
+			ID    string `json:"id"`
+			Label string `json:"label"`
+			Value string `json:"value"`
+		} `json:"fields"`
+	}
+	if err := c.get(ctx, "/v1/vaults/"+url.PathEscape(vaultID)+"/items/"+url.PathEscape(itemID), &item); err != nil {
+		return "", err
+	}
+	for _, field := range item.Fields {
+		if field.Label == parts[2] || field.ID == parts[2] {
+			return field.Value, nil
+		}
+	}
+	return "", fmt.Errorf("1password field '%s' not found in item '%s': %w", parts[2], parts[1], ErrSecretNotFound)
+}
+
+type opClient struct {
+	host  string
+	token string
+}
+
+// findID looks an object up by its name, falling back to treating the name
+// as an ID when no object matches.
+func (c *opClient) findID(ctx context.Context, path, attr, name string) (string, error) {
+	var objects []struct {
+		ID string `json:"id"`
+	}
+	filter := url.Values{"filter": {fmt.Sprintf("%s eq %q", attr, name)}}
+	if err := c.get(ctx, path+"?"+filter.Encode(), &objects); err != nil {
+		return "", err
+	}
+	if len(objects) > 0 {
+		return objects[0].ID, nil
+	}
+	return name, nil
+}
+
+func (c *opClient) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.host+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	resp, err := opHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling 1password connect: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("1password connect returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
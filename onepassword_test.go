@@ -0,0 +1,50 @@
+package jenv_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestOnePasswordProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/vaults":
+			assert.Equal(t, `name eq "dev"`, r.URL.Query().Get("filter"))
+			json.NewEncoder(w).Encode([]map[string]string{{"id": "v1"}})
+		case "/v1/vaults/v1/items":
+			json.NewEncoder(w).Encode([]map[string]string{{"id": "i1"}})
+		case "/v1/vaults/v1/items/i1":
+			json.NewEncoder(w).Encode(map[string]any{
+				"fields": []map[string]string{
+					{"id": "username", "label": "username", "value": "admin"},
+					{"id": "password", "label": "password", "value": "s3cr3t"},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("OP_CONNECT_HOST", srv.URL)
+	t.Setenv("OP_CONNECT_TOKEN", "test-token")
+
+	var cfg struct {
+		Password string `json:"password"`
+	}
+	err := jenv.UnmarshalJSON([]byte(`{"password": "${op:dev/database/password}"}`), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", cfg.Password)
+
+	err = jenv.UnmarshalJSON([]byte(`{"password": "${op:dev/database/missing}"}`), &cfg)
+	assert.Error(t, err)
+}
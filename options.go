@@ -0,0 +1,257 @@
+package jenv
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"text/template"
+	"time"
+)
+
+// Option configures a single decode.
+type Option func(*options)
+
+type options struct {
+	ctx            context.Context
+	results        []*Result
+	resolveTimeout time.Duration
+	policies       []resolvePolicy
+
+	resolutionCache *ResolutionCache
+	cache           Cache
+	cacheTTL        time.Duration
+	document        string
+	strictTypes     bool
+	strict          bool
+	sliceSeparator  string
+	duplicateKeys   DuplicateKeyPolicy
+
+	pairSeparator     string
+	keyValueSeparator string
+
+	keyNormalizer func(string) string
+	tagOrder      []string
+
+	templateFuncs template.FuncMap
+
+	relativeTime bool
+	clock        func() time.Time
+	location     *time.Location
+
+	snapshotEnv bool
+	envReplay   EnvSnapshot
+	envObserver func(name, val string, ok bool)
+
+	execCommands []string
+
+	recursiveExpansion bool
+	maxDepth           int
+
+	envPrefix string
+	// envTagPrefix is set by WithEnvTagPrefix.
+	envTagPrefix string
+	envNameFunc  func(string) string
+
+	decoders    map[reflect.Type]DecodeFunc
+	decodeHooks []DecodeHook
+	unions      map[reflect.Type]union
+
+	allErrors bool
+	postLoad  []func(cfg any) error
+
+	stores map[string]SecretStore
+
+	envSource EnvSource
+
+	httpHeader   http.Header
+	httpTimeout  time.Duration
+	httpClient   *http.Client
+	retries      int
+	retryBackoff time.Duration
+	etag         *string
+}
+
+// withEnvObserver reports every environment variable read while resolving
+// placeholders to fn.
+func withEnvObserver(fn func(name, val string, ok bool)) Option {
+	return func(o *options) {
+		o.envObserver = fn
+	}
+}
+
+// withDocument names the document being decoded in the Result.
+func withDocument(name string) Option {
+	return func(o *options) {
+		o.document = name
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{ctx: context.Background(), tagOrder: defaultTagOrder, clock: time.Now}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithContext sets the context passed to secret stores while resolving
+// placeholders.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// WithResult records details about how values were resolved into res.
+// Given several times, each Result receives the same records.
+func WithResult(res *Result) Option {
+	return func(o *options) {
+		o.results = append(o.results, res)
+	}
+}
+
+// WithStrictTypes rejects quoted literals such as `port: "80"` for number
+// and bool fields instead of converting them. Placeholders still convert,
+// since env values are always strings.
+func WithStrictTypes() Option {
+	return func(o *options) {
+		o.strictTypes = true
+	}
+}
+
+// WithStrict rejects env placeholders whose variable is unset and that
+// carry no default, instead of resolving them to an empty string. Write
+// `${VAR:}` to allow an unset variable explicitly.
+func WithStrict() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// WithSliceSeparator sets the separator a single string is split on for
+// slice and array fields, with each element converted to the element
+// type. This is mostly useful for values coming from one env var, e.g.
+// `hosts: "${HOSTS}"` with HOSTS=a;b;c. The default is a comma, and a
+// `sep` tag overrides it for one field.
+func WithSliceSeparator(sep string) Option {
+	return func(o *options) {
+		o.sliceSeparator = sep
+	}
+}
+
+// WithMapSeparators lets map fields accept a single string of key/value
+// pairs, e.g. "us=10,eu=20" with WithMapSeparators(",", "="). Values are
+// converted to the map's element type.
+func WithMapSeparators(pairSep, keyValueSep string) Option {
+	return func(o *options) {
+		o.pairSeparator, o.keyValueSeparator = pairSep, keyValueSep
+	}
+}
+
+// WithKeyNormalizer matches document keys and field keys that are equal
+// after normalization by fn, when no key matches exactly. With SnakeCase,
+// `maxConns`, `max-conns` and `MaxConns` all match a `max_conns` tag; a
+// document holding more than one of them is an error for that field.
+func WithKeyNormalizer(fn func(string) string) Option {
+	return func(o *options) {
+		o.keyNormalizer = fn
+	}
+}
+
+// WithTagOrder sets the struct tags consulted for key names, in order,
+// e.g. WithTagOrder("jenv", "json", "yaml", "mapstructure"). The default
+// is json, then yaml.
+func WithTagOrder(tags ...string) Option {
+	return func(o *options) {
+		o.tagOrder = tags
+	}
+}
+
+// WithTagName matches fields to document keys through the named struct
+// tag alone, such as `config:"listen_addr"`, in place of json and yaml.
+// It is short for WithTagOrder(name).
+func WithTagName(name string) Option {
+	return WithTagOrder(name)
+}
+
+// WithTemplateFuncs makes funcs available to *template.Template fields.
+// Functions must be registered before decoding, since templates are parsed
+// as they are decoded. Repeated calls add to the set.
+func WithTemplateFuncs(funcs template.FuncMap) Option {
+	return func(o *options) {
+		if o.templateFuncs == nil {
+			o.templateFuncs = template.FuncMap{}
+		}
+		for name, fn := range funcs {
+			o.templateFuncs[name] = fn
+		}
+	}
+}
+
+// WithRelativeTime lets time.Time fields be written relative to the time
+// of decoding: "now", "now+2h", "now-30m", or just "+2h" and "-30m".
+func WithRelativeTime() Option {
+	return func(o *options) {
+		o.relativeTime = true
+	}
+}
+
+// WithClock sets the clock relative times are resolved against, so tests
+// can pin "now".
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.clock = now
+	}
+}
+
+// WithLocation sets the location of time.Time values written without a
+// zone, such as "2024-03-01 09:00" or a date with a `format:"2006-01-02"`
+// tag. The default is UTC.
+func WithLocation(loc *time.Location) Option {
+	return func(o *options) {
+		o.location = loc
+	}
+}
+
+// WithRecursiveExpansion expands placeholders found in the values of
+// environment variables, so PRIMARY_URL="${FALLBACK_URL}" resolves through
+// FALLBACK_URL. A variable that refers back to itself, directly or not, is
+// an error.
+func WithRecursiveExpansion() Option {
+	return func(o *options) {
+		o.recursiveExpansion = true
+	}
+}
+
+// WithMaxDepth limits how deeply placeholders may nest, in defaults such
+// as `${A:${B:${C}}}` or through recursively expanded values. The default
+// is 16. It panics if n is less than 1.
+func WithMaxDepth(n int) Option {
+	if n < 1 {
+		panic(fmt.Sprintf("jenv: WithMaxDepth depth %d is less than 1", n))
+	}
+	return func(o *options) {
+		o.maxDepth = n
+	}
+}
+
+// WithEnvSnapshot records the environment variables read while resolving
+// placeholders into the Result's Env, so the decode can be reproduced
+// later. It has no effect without WithResult.
+func WithEnvSnapshot() Option {
+	return func(o *options) {
+		o.snapshotEnv = true
+	}
+}
+
+// WithEnvReplay resolves env placeholders from snap instead of the process
+// environment. Variables missing from snap are treated as unset.
+func WithEnvReplay(snap EnvSnapshot) Option {
+	return func(o *options) {
+		if snap == nil {
+			snap = EnvSnapshot{}
+		}
+		o.envReplay = snap
+	}
+}
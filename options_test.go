@@ -0,0 +1,172 @@
+package jenv_test
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestWithStrictTypes(t *testing.T) {
+	type server struct {
+		Port    int     `yaml:"port"`
+		Debug   bool    `yaml:"debug"`
+		Ratio   float64 `yaml:"ratio"`
+		Timeout string  `yaml:"timeout"`
+	}
+	t.Setenv("PORT", "8080")
+
+	var cfg server
+	assert.NoError(t, jenv.UnmarshalYAML([]byte("port: \"80\"\ndebug: \"true\""), &cfg))
+	assert.Equal(t, 80, cfg.Port)
+
+	err := jenv.UnmarshalYAML([]byte(`port: "80"`), &cfg, jenv.WithStrictTypes())
+	assert.ErrorContains(t, err, `expected number, got string "80"`)
+	err = jenv.UnmarshalYAML([]byte(`debug: "true"`), &cfg, jenv.WithStrictTypes())
+	assert.ErrorContains(t, err, `expected bool, got string "true"`)
+
+	cfg = server{}
+	err = jenv.UnmarshalYAML([]byte("port: \"${PORT:80}\"\ndebug: true\nratio: 0.5\ntimeout: \"5s\""), &cfg, jenv.WithStrictTypes())
+	assert.NoError(t, err)
+	assert.Equal(t, server{Port: 8080, Debug: true, Ratio: 0.5, Timeout: "5s"}, cfg)
+}
+
+func TestWithSliceSeparator(t *testing.T) {
+	type cluster struct {
+		Hosts []string `json:"hosts"`
+		Ports []int    `json:"ports"`
+		Tags  []string `json:"tags"`
+	}
+	t.Setenv("HOSTS", "a.example.com, b.example.com")
+
+	doc := []byte(`{"hosts": "${HOSTS}", "ports": "80;443", "tags": ""}`)
+	var cfg cluster
+	assert.Error(t, jenv.UnmarshalJSON(doc, &cfg))
+
+	assert.NoError(t, jenv.UnmarshalJSON([]byte(`{"hosts": "${HOSTS}"}`), &cfg, jenv.WithSliceSeparator(",")))
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, cfg.Hosts)
+
+	cfg = cluster{}
+	assert.NoError(t, jenv.UnmarshalJSON([]byte(`{"ports": "80;443", "tags": ""}`), &cfg, jenv.WithSliceSeparator(";")))
+	assert.Equal(t, cluster{Ports: []int{80, 443}, Tags: []string{}}, cfg)
+}
+
+func TestWithMapSeparators(t *testing.T) {
+	type quotas struct {
+		Regions map[string]int    `json:"regions"`
+		Labels  map[string]string `json:"labels"`
+	}
+	t.Setenv("REGION_QUOTAS", "us=10, eu=20")
+
+	doc := []byte(`{"regions": "${REGION_QUOTAS}", "labels": "team:core;tier:gold"}`)
+	var cfg quotas
+	assert.Error(t, jenv.UnmarshalJSON(doc, &cfg))
+
+	assert.NoError(t, jenv.UnmarshalJSON([]byte(`{"regions": "${REGION_QUOTAS}"}`), &cfg, jenv.WithMapSeparators(",", "=")))
+	assert.Equal(t, map[string]int{"us": 10, "eu": 20}, cfg.Regions)
+
+	assert.NoError(t, jenv.UnmarshalJSON([]byte(`{"labels": "team:core;tier:gold"}`), &cfg, jenv.WithMapSeparators(";", ":")))
+	assert.Equal(t, map[string]string{"team": "core", "tier": "gold"}, cfg.Labels)
+
+	err := jenv.UnmarshalJSON([]byte(`{"labels": "team"}`), &cfg, jenv.WithMapSeparators(",", "="))
+	assert.ErrorContains(t, err, `invalid pair "team"`)
+}
+
+func TestWithTagOrder(t *testing.T) {
+	type shared struct {
+		Endpoint string `json:"endpoint" mapstructure:"url"`
+		Retries  int    `yaml:"retries" mapstructure:"max_retries"`
+		Region   string
+	}
+	doc := []byte("endpoint: a\nurl: b\nretries: 1\nmax_retries: 2\nregion: eu\n")
+
+	var cfg shared
+	assert.NoError(t, jenv.UnmarshalYAML(doc, &cfg))
+	assert.Equal(t, shared{Endpoint: "a", Retries: 1, Region: "eu"}, cfg)
+
+	cfg = shared{}
+	assert.NoError(t, jenv.UnmarshalYAML(doc, &cfg, jenv.WithTagOrder("mapstructure", "json")))
+	assert.Equal(t, shared{Endpoint: "b", Retries: 2, Region: "eu"}, cfg)
+}
+
+func TestWithTemplateFuncs(t *testing.T) {
+	type notifications struct {
+		Welcome *template.Template `yaml:"welcome"`
+		Alert   *template.Template `yaml:"alert"`
+	}
+	doc := []byte(`
+welcome: "Hello {{ .Name }}!"
+alert: "{{ upper .Level }}: {{ .Message }}"
+`)
+	var cfg notifications
+	err := jenv.UnmarshalYAML(doc, &cfg)
+	assert.ErrorContains(t, err, `error decoding 'alert': template: alert:1: function "upper" not defined`)
+
+	require.NoError(t, jenv.UnmarshalYAML(doc, &cfg, jenv.WithTemplateFuncs(template.FuncMap{"upper": strings.ToUpper})))
+	var out strings.Builder
+	require.NoError(t, cfg.Welcome.Execute(&out, map[string]string{"Name": "Ada"}))
+	assert.Equal(t, "Hello Ada!", out.String())
+	out.Reset()
+	require.NoError(t, cfg.Alert.Execute(&out, map[string]string{"Level": "warn", "Message": "disk full"}))
+	assert.Equal(t, "WARN: disk full", out.String())
+}
+
+func TestWithRelativeTime(t *testing.T) {
+	type window struct {
+		Start  time.Time `yaml:"start"`
+		End    time.Time `yaml:"end"`
+		Expiry time.Time `yaml:"expiry"`
+		Fixed  time.Time `yaml:"fixed"`
+	}
+	doc := []byte(`
+start: now
+end: now+2h
+expiry: "-30m"
+fixed: 2024-01-01T00:00:00Z
+`)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	var cfg window
+	assert.Error(t, jenv.UnmarshalYAML(doc, &cfg), "relative times are opt-in")
+
+	require.NoError(t, jenv.UnmarshalYAML(doc, &cfg, jenv.WithRelativeTime(), jenv.WithClock(func() time.Time { return now })))
+	assert.Equal(t, now, cfg.Start)
+	assert.Equal(t, now.Add(2*time.Hour), cfg.End)
+	assert.Equal(t, now.Add(-30*time.Minute), cfg.Expiry)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), cfg.Fixed)
+
+	err := jenv.UnmarshalYAML([]byte("end: now+2 hours"), &cfg, jenv.WithRelativeTime())
+	assert.ErrorContains(t, err, `invalid relative time "now+2 hours"`)
+}
+
+func TestUnmarshalOptions(t *testing.T) {
+	t.Setenv("OPTS_PORT", "9090")
+	type config struct {
+		Listen string `config:"listen_addr"`
+		Port   int    `config:"port" env:"PORT"`
+		Token  string `config:"token"`
+		Name   string `json:"app_name"`
+	}
+	resolver := jenv.ResolverFunc(func(scheme, key string) (string, error) {
+		return scheme + "/" + key, nil
+	})
+	docs := map[string]string{
+		"json": `{"listen_addr": ":80", "port": 80, "token": "${optsvault:api}", "app_name": "x"}`,
+		"yaml": "listen_addr: \":80\"\nport: 80\ntoken: ${optsvault:api}\napp_name: x\n",
+		"toml": "listen_addr = \":80\"\nport = 80\ntoken = \"${optsvault:api}\"\napp_name = \"x\"\n",
+	}
+	for format, doc := range docs {
+		var cfg config
+		require.NoError(t, jenv.Unmarshal([]byte(doc), &cfg, jenv.WithTagName("config"), jenv.WithEnvTagPrefix("OPTS_"), jenv.WithResolver(resolver, "optsvault")), format)
+		assert.Equal(t, config{Listen: ":80", Port: 9090, Token: "optsvault/api"}, cfg, format)
+	}
+
+	assert.NotContains(t, jenv.SecretStores(), "optsvault", "WithResolver leaves the registered stores alone")
+	var cfg config
+	err := jenv.Unmarshal([]byte(`{"listen_addr": "${OPTS_UNSET}"}`), &cfg, jenv.WithTagName("config"), jenv.WithStrict())
+	assert.ErrorIs(t, err, jenv.ErrMissingEnvVar)
+}
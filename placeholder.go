@@ -0,0 +1,106 @@
+package jenv
+
+import "strings"
+
+// MissingEnvError reports the required variables, written `${VAR!}`, that
+// were unset or empty. A decode collects every missing variable before
+// failing, so they can all be fixed at once.
+type MissingEnvError struct {
+	Names []string
+}
+
+func (e *MissingEnvError) Error() string {
+	return "missing required environment variables: " + strings.Join(e.Names, ", ")
+}
+
+// Is reports whether target is ErrMissingEnvVar.
+func (e *MissingEnvError) Is(target error) bool {
+	return target == ErrMissingEnvVar
+}
+
+// placeholder is a `${...}` occurrence in a value.
+type placeholder struct {
+	// start and end are the offsets of the opening `$` and just past the
+	// closing brace.
+	start, end int
+	body       string
+}
+
+// findPlaceholders returns the placeholders in s, in order. Braces nest,
+// so a default may itself contain braces; an unterminated `${` is left as
+// literal text, and so is an escaped `$${`.
+func findPlaceholders(s string) []placeholder {
+	var refs []placeholder
+	for i := 0; i+1 < len(s); i++ {
+		if strings.HasPrefix(s[i:], "$${") {
+			i += 2
+			continue
+		}
+		if s[i] != '$' || s[i+1] != '{' {
+			continue
+		}
+		depth := 0
+		for j := i + 1; j < len(s); j++ {
+			switch s[j] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+			if depth == 0 {
+				refs = append(refs, placeholder{start: i, end: j + 1, body: strings.TrimSpace(s[i+2 : j])})
+				i = j
+				break
+			}
+		}
+		if depth > 0 {
+			break
+		}
+	}
+	return refs
+}
+
+// unescapePlaceholders turns the escaped `$${` in literal text into `${`.
+func unescapePlaceholders(s string) string {
+	return strings.ReplaceAll(s, "$${", "${")
+}
+
+// placeholderBody returns the inside of s when the whole of s is a single
+// placeholder.
+func placeholderBody(s string) (string, bool) {
+	refs := findPlaceholders(s)
+	if len(refs) == 1 && refs[0].start == 0 && refs[0].end == len(s) {
+		return refs[0].body, true
+	}
+	return "", false
+}
+
+// envRef is an env var placeholder split into the variable name and an
+// optional operator with its argument:
+//
+//	${VAR}            the value of VAR
+//	${VAR:default}    default when VAR is unset or empty (also ${VAR:-default})
+//	${VAR:?message}   an error carrying message when VAR is unset or empty
+//	${VAR:+alternate} alternate when VAR is set and not empty, else empty
+//	${VAR!}           a MissingEnvError when VAR is unset or empty
+type envRef struct {
+	name string
+	// op is 0 without an operator, else '-', '?' or '+'.
+	op       byte
+	arg      string
+	required bool
+}
+
+func parseEnvRef(body string) envRef {
+	name, rest, ok := strings.Cut(body, ":")
+	if !ok {
+		if name, ok := strings.CutSuffix(name, "!"); ok {
+			return envRef{name: name, required: true}
+		}
+		return envRef{name: name}
+	}
+	if rest != "" && strings.IndexByte("-?+", rest[0]) >= 0 {
+		return envRef{name: name, op: rest[0], arg: rest[1:]}
+	}
+	return envRef{name: name, op: '-', arg: rest}
+}
@@ -0,0 +1,99 @@
+package jenv
+
+import (
+	"path"
+	"sync"
+	"time"
+)
+
+// ResolvePolicy decides what happens when a secret store fails to resolve
+// a placeholder, e.g. because it is down or the resolve budget ran out.
+type ResolvePolicy int
+
+const (
+	// PolicyAuto uses the placeholder default when one is given and fails
+	// otherwise. It is the behaviour without any policy option.
+	PolicyAuto ResolvePolicy = iota
+	// PolicyFail fails the decode even when the placeholder has a default.
+	PolicyFail
+	// PolicyDefault uses the placeholder default, or an empty value when
+	// there is none.
+	PolicyDefault
+	// PolicyCached uses the value this process last resolved for the same
+	// placeholder, then the placeholder default, and fails otherwise. Only
+	// the last 1024 placeholders resolved are remembered.
+	PolicyCached
+)
+
+type resolvePolicy struct {
+	pattern string
+	policy  ResolvePolicy
+}
+
+// WithResolveTimeout bounds the total time a decode may spend waiting on
+// secret stores. Lookups still pending when the budget is spent fail with
+// context.DeadlineExceeded and are handled by the resolve policy.
+func WithResolveTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.resolveTimeout = d
+	}
+}
+
+// WithResolvePolicy applies policy to placeholders whose `scheme:ref`
+// matches pattern (path.Match syntax, e.g. "vault:*" or
+// "ssm:/app/prod/api-key"). The first matching pattern wins.
+func WithResolvePolicy(pattern string, policy ResolvePolicy) Option {
+	return func(o *options) {
+		o.policies = append(o.policies, resolvePolicy{pattern: pattern, policy: policy})
+	}
+}
+
+func (o *options) policyFor(key string) ResolvePolicy {
+	for _, p := range o.policies {
+		if ok, _ := path.Match(p.pattern, key); ok {
+			return p.policy
+		}
+	}
+	return PolicyAuto
+}
+
+// maxResolvedSecrets bounds how many placeholders resolvedSecrets
+// remembers, so processes resolving ever new refs do not grow without
+// limit.
+const maxResolvedSecrets = 1024
+
+// resolvedSecrets remembers the last value resolved for each placeholder
+// in this process, for PolicyCached.
+var resolvedSecrets = lastValues{max: maxResolvedSecrets}
+
+// lastValues maps keys to values, forgetting the keys stored first once
+// it holds max of them.
+type lastValues struct {
+	mu     sync.Mutex
+	max    int
+	values map[string]string
+	order  []string
+}
+
+func (v *lastValues) load(key string) (string, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	val, ok := v.values[key]
+	return val, ok
+}
+
+func (v *lastValues) store(key, val string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.values == nil {
+		v.values = map[string]string{}
+	}
+	if _, ok := v.values[key]; !ok {
+		if len(v.order) == v.max {
+			delete(v.values, v.order[0])
+			v.order = v.order[1:]
+		}
+		v.order = append(v.order, key)
+	}
+	v.values[key] = val
+}
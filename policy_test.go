@@ -0,0 +1,106 @@
+package jenv_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+// slowStore answers immediately while up and blocks until the context
+// expires while down.
+type slowStore struct {
+	down atomic.Bool
+}
+
+func (s *slowStore) Get(ctx context.Context, ref string) (string, error) {
+	if !s.down.Load() {
+		return "live-" + ref, nil
+	}
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func TestResolveTimeoutAndPolicies(t *testing.T) {
+	store := &slowStore{}
+	jenv.RegisterSecretStore("slow", store)
+	defer jenv.UnregisterSecretStore("slow")
+
+	type secrets struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+		APIKey   string `json:"api_key"`
+	}
+	doc := []byte(`{
+		"token": "${slow:token}",
+		"password": "${slow:password:-fallback}",
+		"api_key": "${slow:api-key}"
+	}`)
+
+	var cfg secrets
+	assert.NoError(t, jenv.UnmarshalJSON(doc, &cfg))
+	assert.Equal(t, "live-token", cfg.Token)
+
+	store.down.Store(true)
+	start := time.Now()
+	cfg = secrets{}
+	var res jenv.Result
+	err := jenv.UnmarshalJSON(doc, &cfg,
+		jenv.WithResolveTimeout(50*time.Millisecond),
+		jenv.WithResolvePolicy("slow:token", jenv.PolicyCached),
+		jenv.WithResolvePolicy("slow:*", jenv.PolicyDefault),
+		jenv.WithResult(&res),
+	)
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, "live-token", cfg.Token)
+	assert.Equal(t, "fallback", cfg.Password)
+	assert.Equal(t, "", cfg.APIKey)
+	assert.Equal(t, map[string]string{"token": "cache", "password": "default", "api_key": "default"}, res.Sources)
+
+	err = jenv.UnmarshalJSON(doc, &cfg,
+		jenv.WithResolveTimeout(10*time.Millisecond),
+		jenv.WithResolvePolicy("slow:password", jenv.PolicyFail),
+	)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestPolicyCachedIsBounded(t *testing.T) {
+	var down atomic.Bool
+	jenv.RegisterSecretStore("bounded", jenv.SecretStoreFunc(func(_ context.Context, ref string) (string, error) {
+		if down.Load() {
+			return "", errors.New("store down")
+		}
+		return "value-of-" + ref, nil
+	}))
+	defer jenv.UnregisterSecretStore("bounded")
+
+	var refs []string
+	for i := range 1100 {
+		refs = append(refs, fmt.Sprintf("${bounded:%d}", i))
+	}
+	doc, err := json.Marshal(map[string]any{"refs": refs})
+	require.NoError(t, err)
+	var cfg struct {
+		Refs []string `json:"refs"`
+	}
+	require.NoError(t, jenv.UnmarshalJSON(doc, &cfg))
+
+	down.Store(true)
+	cached := jenv.WithResolvePolicy("bounded:*", jenv.PolicyCached)
+	var one struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, jenv.UnmarshalJSON([]byte(`{"token": "${bounded:1099}"}`), &one, cached))
+	assert.Equal(t, "value-of-1099", one.Token)
+	err = jenv.UnmarshalJSON([]byte(`{"token": "${bounded:0}"}`), &one, cached)
+	assert.ErrorContains(t, err, "store down", "the oldest values are forgotten")
+}
@@ -0,0 +1,49 @@
+package jenv
+
+import (
+	"context"
+	"reflect"
+)
+
+// AfterLoader is implemented by configuration structs that derive fields
+// or normalize values once they are decoded, such as building a DSN from
+// its parts. AfterLoad is called with the decode's context, after the
+// AfterLoad methods of the structs inside it.
+type AfterLoader interface {
+	AfterLoad(ctx context.Context) error
+}
+
+// WithPostLoad runs fns, in order, on the decoded configuration, a pointer
+// to the struct passed in, after any AfterLoad methods. Like them, they
+// run after `expr` fields are computed and before `validate` tags and
+// Validate methods are checked, so what they derive is validated too.
+func WithPostLoad(fns ...func(cfg any) error) Option {
+	return func(o *options) {
+		o.postLoad = append(o.postLoad, fns...)
+	}
+}
+
+// afterLoad calls the AfterLoad methods in cfg, decoded from the key at
+// path, then the WithPostLoad functions, stopping at the first error.
+func (d *decoder) afterLoad(cfg any, path string) error {
+	var err error
+	d.walkNested(reflect.ValueOf(cfg).Elem(), path, nil, func(val reflect.Value, path string) {
+		if err != nil || !val.CanAddr() {
+			return
+		}
+		if a, ok := val.Addr().Interface().(AfterLoader); ok {
+			if err = a.AfterLoad(d.ctx); err != nil && path != "" {
+				err = &FieldError{Path: path, Expected: val.Type().String(), Cause: err}
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for _, fn := range d.postLoad {
+		if err := fn(cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
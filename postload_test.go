@@ -0,0 +1,65 @@
+package jenv_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+type postLoadDatabase struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+	Name string `yaml:"name"`
+	DSN  string `yaml:"-"`
+}
+
+func (d *postLoadDatabase) AfterLoad(ctx context.Context) error {
+	if ctx == nil {
+		return errors.New("no context")
+	}
+	if d.Host == "" {
+		return errors.New("no host to connect to")
+	}
+	d.DSN = fmt.Sprintf("postgres://%s:%d/%s", d.Host, d.Port, d.Name)
+	return nil
+}
+
+type postLoadConfig struct {
+	Env      string           `yaml:"env" validate:"oneof=dev prod"`
+	Database postLoadDatabase `yaml:"database"`
+	Summary  string           `yaml:"-"`
+}
+
+func (c *postLoadConfig) AfterLoad(context.Context) error {
+	c.Env = strings.ToLower(c.Env)
+	c.Summary = c.Env + " " + c.Database.DSN
+	return nil
+}
+
+func TestPostLoad(t *testing.T) {
+	doc := []byte("env: PROD\ndatabase: {host: db, port: 5432, name: app}\n")
+	var calls []string
+	var cfg postLoadConfig
+	require.NoError(t, jenv.UnmarshalYAML(doc, &cfg, jenv.WithPostLoad(func(cfg any) error {
+		calls = append(calls, cfg.(*postLoadConfig).Summary)
+		return nil
+	})))
+	assert.Equal(t, "postgres://db:5432/app", cfg.Database.DSN)
+	assert.Equal(t, "prod", cfg.Env, "normalized before validation")
+	assert.Equal(t, []string{"prod postgres://db:5432/app"}, calls, "nested AfterLoad methods run first")
+
+	cfg = postLoadConfig{}
+	err := jenv.UnmarshalYAML([]byte("env: dev\n"), &cfg)
+	assert.EqualError(t, err, "error decoding 'database': no host to connect to")
+
+	errStop := errors.New("stop")
+	err = jenv.UnmarshalYAML(doc, &cfg, jenv.WithPostLoad(func(any) error { return errStop }))
+	assert.ErrorIs(t, err, errStop)
+}
@@ -0,0 +1,93 @@
+package jenv
+
+import (
+	"context"
+	"strings"
+)
+
+// BatchSecretStore is implemented by secret stores whose back-end can
+// resolve several references in one round trip, such as SSM GetParameters
+// or a Vault batch read. Before populating fields, a decode gathers the
+// placeholders of each such store and resolves them with one GetMany call.
+type BatchSecretStore interface {
+	SecretStore
+	// GetMany returns the values of the refs that exist; missing refs are
+	// left out of the map.
+	GetMany(ctx context.Context, refs []string) (map[string]string, error)
+}
+
+// prefetchSecrets resolves the secret placeholders of rawMap from the
+// Cache, then in batches where the store supports it. Refs the batch did
+// not return, including all of them when GetMany fails, are looked up one
+// by one as usual, so resolve policies and defaults apply unchanged.
+func (d *decoder) prefetchSecrets(rawMap map[string]any) {
+	refs := map[string][]string{}
+	seen := map[string]bool{}
+	d.collectSecretRefs(rawMap, func(scheme, ref string) {
+		key := scheme + ":" + ref
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		if d.resolutionCache != nil {
+			if _, ok := d.resolutionCache.get(key); ok {
+				return
+			}
+		}
+		if val, ok := d.cacheGet(key); ok {
+			d.prefetch(key, resolvedEntry{value: val, source: "cache"})
+			return
+		}
+		refs[scheme] = append(refs[scheme], ref)
+	})
+	for scheme, list := range refs {
+		store, _ := d.secretStore(scheme)
+		batch, ok := store.(BatchSecretStore)
+		if !ok {
+			continue
+		}
+		if err := waitRateLimit(d.resolveCtx, scheme); err != nil {
+			continue
+		}
+		vals, err := batch.GetMany(d.resolveCtx, list)
+		if err != nil {
+			continue
+		}
+		for ref, val := range vals {
+			d.prefetch(scheme+":"+ref, resolvedEntry{value: val, source: scheme})
+			d.cacheSet(scheme+":"+ref, val)
+		}
+	}
+}
+
+func (d *decoder) prefetch(key string, e resolvedEntry) {
+	if d.prefetched == nil {
+		d.prefetched = map[string]resolvedEntry{}
+	}
+	d.prefetched[key] = e
+}
+
+// collectSecretRefs calls fn for every secret store placeholder in value.
+func (d *decoder) collectSecretRefs(value any, fn func(scheme, ref string)) {
+	switch v := value.(type) {
+	case string:
+		for _, p := range findPlaceholders(v) {
+			scheme, ref, ok := strings.Cut(p.body, ":")
+			if !ok {
+				continue
+			}
+			if _, ok := d.secretStore(scheme); ok {
+				ref, _, _ = strings.Cut(ref, ":-")
+				fn(scheme, ref)
+			}
+		}
+	case map[string]any:
+		for _, child := range v {
+			d.collectSecretRefs(child, fn)
+		}
+	case []any:
+		for _, child := range v {
+			d.collectSecretRefs(child, fn)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package jenv_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+type batchStore struct {
+	mapStore
+	fail    bool
+	batches [][]string
+	gets    atomic.Int32
+}
+
+func (b *batchStore) Get(ctx context.Context, ref string) (string, error) {
+	b.gets.Add(1)
+	return b.mapStore.Get(ctx, ref)
+}
+
+func (b *batchStore) GetMany(_ context.Context, refs []string) (map[string]string, error) {
+	sorted := append([]string{}, refs...)
+	sort.Strings(sorted)
+	b.batches = append(b.batches, sorted)
+	if b.fail {
+		return nil, errors.New("batch endpoint unavailable")
+	}
+	out := map[string]string{}
+	for _, ref := range refs {
+		if v, ok := b.mapStore[ref]; ok {
+			out[ref] = v
+		}
+	}
+	return out, nil
+}
+
+func TestBatchPrefetch(t *testing.T) {
+	type db struct {
+		User     string   `yaml:"user"`
+		Password string   `yaml:"password"`
+		Replicas []string `yaml:"replicas"`
+		Token    string   `yaml:"token"`
+	}
+	doc := []byte(`
+user: "${ssmtest:/db/user}"
+password: "${ssmtest:/db/password}"
+replicas: ["${ssmtest:/db/replica}", "${ssmtest:/db/replica}"]
+token: "${ssmtest:/db/token:-none}"
+`)
+	store := &batchStore{mapStore: mapStore{"/db/user": "app", "/db/password": "s3cret", "/db/replica": "r1"}}
+	jenv.RegisterSecretStore("ssmtest", store)
+	defer jenv.UnregisterSecretStore("ssmtest")
+
+	var cfg db
+	var res jenv.Result
+	require.NoError(t, jenv.UnmarshalYAML(doc, &cfg, jenv.WithResult(&res)))
+	assert.Equal(t, db{User: "app", Password: "s3cret", Replicas: []string{"r1", "r1"}, Token: "none"}, cfg)
+	assert.Equal(t, [][]string{{"/db/password", "/db/replica", "/db/token", "/db/user"}}, store.batches)
+	assert.Equal(t, int32(1), store.gets.Load(), "only the ref missing from the batch is fetched again")
+	assert.Equal(t, "ssmtest", res.Sources["password"])
+	assert.Equal(t, "default", res.Sources["token"])
+
+	store.fail, store.batches = true, nil
+	store.gets.Store(0)
+	cfg = db{}
+	require.NoError(t, jenv.UnmarshalYAML(doc, &cfg))
+	assert.Equal(t, "s3cret", cfg.Password)
+	assert.Equal(t, int32(5), store.gets.Load(), "a failed batch falls back to single lookups")
+}
@@ -0,0 +1,291 @@
+package jenv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrSecretNotFound is returned by secret stores when a reference does not
+// exist in the backend.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretStore resolves the reference part of a `${scheme:reference}`
+// placeholder against a secret back-end.
+type SecretStore interface {
+	Get(ctx context.Context, ref string) (string, error)
+}
+
+// SecretStoreFunc adapts an ordinary function to the SecretStore interface.
+type SecretStoreFunc func(ctx context.Context, ref string) (string, error)
+
+func (f SecretStoreFunc) Get(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// Resolver resolves `${scheme:key}` placeholders for the schemes it is
+// registered under with RegisterResolver. It is the context-free
+// counterpart of SecretStore, for providers that serve several schemes.
+type Resolver interface {
+	Resolve(scheme, key string) (string, error)
+}
+
+// ResolverFunc adapts an ordinary function to the Resolver interface.
+type ResolverFunc func(scheme, key string) (string, error)
+
+func (f ResolverFunc) Resolve(scheme, key string) (string, error) {
+	return f(scheme, key)
+}
+
+// resolverStore serves one scheme of a Resolver as a SecretStore.
+type resolverStore struct {
+	scheme   string
+	resolver Resolver
+}
+
+func (s resolverStore) Get(_ context.Context, ref string) (string, error) {
+	return s.resolver.Resolve(s.scheme, ref)
+}
+
+var (
+	storesMu sync.RWMutex
+	stores   = map[string]SecretStore{
+		"keyring": SecretStoreFunc(lookupKeyring),
+		"op":      SecretStoreFunc(lookupOnePassword),
+
+		"ssm":        ssmStore{},
+		"aws-secret": awsSecretStore{},
+		"gcp-secret": gcpSecretStore{},
+		"akv":        akvStore{},
+	}
+)
+
+// RegisterSecretStore makes store resolve placeholders using the given
+// scheme, replacing any store previously registered for it. It panics if
+// the scheme is invalid or the store is nil.
+func RegisterSecretStore(scheme string, store SecretStore) {
+	if scheme == "" || strings.ContainsAny(scheme, ": \t${}") {
+		panic(fmt.Sprintf("jenv: invalid secret store scheme %q", scheme))
+	}
+	if store == nil {
+		panic("jenv: RegisterSecretStore store is nil")
+	}
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	stores[scheme] = store
+}
+
+// RegisterResolver makes resolver handle placeholders using any of the
+// given schemes, such as `${vault:secret/db#password}` or
+// `${ssm:/app/prod/api-key}`. Each scheme is registered as a secret store,
+// so caching, resolve policies and defaults apply as they do to stores.
+func RegisterResolver(resolver Resolver, schemes ...string) {
+	if resolver == nil {
+		panic("jenv: RegisterResolver resolver is nil")
+	}
+	for _, scheme := range schemes {
+		RegisterSecretStore(scheme, resolverStore{scheme: scheme, resolver: resolver})
+	}
+}
+
+// WithResolver makes resolver handle placeholders using any of the given
+// schemes for this decode alone, ahead of the stores registered with
+// RegisterSecretStore or RegisterResolver. Unlike those it changes no
+// package state, so libraries embedding jenv can use it freely: its
+// lookups are never shared with, or remembered for, other decodes.
+func WithResolver(resolver Resolver, schemes ...string) Option {
+	if resolver == nil {
+		panic("jenv: WithResolver resolver is nil")
+	}
+	return func(o *options) {
+		if o.stores == nil {
+			o.stores = map[string]SecretStore{}
+		}
+		for _, scheme := range schemes {
+			o.stores[scheme] = resolverStore{scheme: scheme, resolver: resolver}
+		}
+	}
+}
+
+// UnregisterSecretStore removes the store registered for scheme, after
+// which such placeholders are treated as ordinary env vars again.
+func UnregisterSecretStore(scheme string) {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	delete(stores, scheme)
+}
+
+// SecretStores returns the schemes that currently have a registered store.
+func SecretStores() []string {
+	storesMu.RLock()
+	defer storesMu.RUnlock()
+	schemes := make([]string, 0, len(stores))
+	for scheme := range stores {
+		schemes = append(schemes, scheme)
+	}
+	return schemes
+}
+
+// secretStore returns the store for scheme, preferring those given to the
+// decode with WithResolver.
+func (d *decoder) secretStore(scheme string) (SecretStore, bool) {
+	if store, ok := d.stores[scheme]; ok {
+		return store, true
+	}
+	return secretStore(scheme)
+}
+
+func secretStore(scheme string) (SecretStore, bool) {
+	storesMu.RLock()
+	defer storesMu.RUnlock()
+	store, ok := stores[scheme]
+	return store, ok
+}
+
+// fileScheme is the placeholder scheme reading a mounted secret file, as
+// in `${file:/run/secrets/db_password}`.
+const fileScheme = "file"
+
+// WithSecretFiles enables `${file:/run/secrets/db_password}` placeholders,
+// which resolve to the contents of the file without surrounding
+// whitespace, the way Docker and Kubernetes mount secrets. Only files
+// inside the listed directories may be read, after following symlinks.
+// Without this option file placeholders are an error, so documents from
+// untrusted sources cannot read local files.
+func WithSecretFiles(dirs ...string) Option {
+	return func(o *options) {
+		if o.stores == nil {
+			o.stores = map[string]SecretStore{}
+		}
+		store, _ := o.stores[fileScheme].(secretFileStore)
+		o.stores[fileScheme] = append(store, dirs...)
+	}
+}
+
+// secretFileStore reads secret files inside its directories.
+type secretFileStore []string
+
+func (dirs secretFileStore) Get(_ context.Context, path string) (string, error) {
+	path, err := filepath.Abs(strings.TrimSpace(path))
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("%w: %v", ErrSecretNotFound, err)
+	}
+	if err != nil {
+		return "", err
+	}
+	if !dirs.contain(resolved) {
+		return "", fmt.Errorf("'%s' is outside the directories allowed by WithSecretFiles", path)
+	}
+	data, err := os.ReadFile(resolved)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// contain reports whether path lies inside one of the directories.
+func (dirs secretFileStore) contain(path string) bool {
+	for _, dir := range dirs {
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = resolved
+		}
+		if rel, err := filepath.Rel(dir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceReporter is implemented by composite stores, such as
+// FallbackStore, that can tell which of their members supplied a value.
+type sourceReporter interface {
+	GetWithSource(ctx context.Context, ref string) (value, source string, err error)
+}
+
+// resolveSecret looks ref up in the store registered for scheme. The
+// reference may carry a default as `${scheme:ref:-default}`; what happens
+// when the store fails is decided by the resolve policy.
+func (d *decoder) resolveSecret(scheme, ref, path string) (string, error) {
+	ref, def, hasDefault := strings.Cut(ref, ":-")
+	key := scheme + ":" + ref
+	d.recordDependency(path, "secret", key)
+	d.recordSecretValue()
+	if d.resolutionCache != nil {
+		if e, ok := d.resolutionCache.get(key); ok {
+			d.recordSource(path, e.source)
+			return e.value, nil
+		}
+	}
+	var val, source string
+	var err error
+	if e, ok := d.prefetched[key]; ok {
+		val, source = e.value, e.source
+	} else if cached, ok := d.cacheGet(key); ok {
+		val, source = cached, "cache"
+	} else if val, source, err = d.fetchSecret(scheme, ref); err == nil {
+		d.cacheSet(key, val)
+	}
+	_, local := d.stores[scheme]
+	if err == nil {
+		if !local {
+			resolvedSecrets.store(key, val)
+		}
+		if d.resolutionCache != nil {
+			d.resolutionCache.set(key, resolvedEntry{value: val, source: source})
+		}
+		d.recordSource(path, source)
+		return val, nil
+	}
+
+	switch d.policyFor(key) {
+	case PolicyFail:
+		hasDefault = false
+	case PolicyDefault:
+		hasDefault = true
+	case PolicyCached:
+		if cached, ok := resolvedSecrets.load(key); ok && !local {
+			d.recordSource(path, "cache")
+			return cached, nil
+		}
+	}
+	if !hasDefault {
+		return "", fmt.Errorf("error resolving '%s': %w", key, err)
+	}
+	d.recordSource(path, "default")
+	return def, nil
+}
+
+// fetchSecret asks the store registered for scheme for ref, once its rate
+// limit allows, and reports which store answered. Concurrent fetches of
+// the same secret from a registered store share one store call; stores
+// given with WithResolver are asked directly.
+func (d *decoder) fetchSecret(scheme, ref string) (val, source string, err error) {
+	store, _ := d.secretStore(scheme)
+	fetch := func() (string, string, error) {
+		if err := waitRateLimit(d.resolveCtx, scheme); err != nil {
+			return "", "", err
+		}
+		if sr, ok := store.(sourceReporter); ok {
+			return sr.GetWithSource(d.resolveCtx, ref)
+		}
+		val, err := store.Get(d.resolveCtx, ref)
+		return val, scheme, err
+	}
+	if _, ok := d.stores[scheme]; ok {
+		return fetch()
+	}
+	return secretFlights.do(d.resolveCtx, scheme+":"+ref, fetch)
+}
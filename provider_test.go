@@ -0,0 +1,157 @@
+package jenv_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+type mapStore map[string]string
+
+func (m mapStore) Get(_ context.Context, ref string) (string, error) {
+	if v, ok := m[ref]; ok {
+		return v, nil
+	}
+	return "", jenv.ErrSecretNotFound
+}
+
+func TestRegisterSecretStore(t *testing.T) {
+	jenv.RegisterSecretStore("corp", mapStore{"db/password": "hunter2"})
+	defer jenv.UnregisterSecretStore("corp")
+	assert.Contains(t, jenv.SecretStores(), "corp")
+
+	var cfg struct {
+		Password string `json:"password"`
+	}
+	err := jenv.UnmarshalJSON([]byte(`{"password": "${corp:db/password}"}`), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", cfg.Password)
+
+	err = jenv.UnmarshalJSON([]byte(`{"password": "${corp:db/missing}"}`), &cfg)
+	assert.True(t, errors.Is(err, jenv.ErrSecretNotFound))
+}
+
+func TestSecretStoreFunc(t *testing.T) {
+	jenv.RegisterSecretStore("upper", jenv.SecretStoreFunc(func(_ context.Context, ref string) (string, error) {
+		return "value-of-" + ref, nil
+	}))
+	jenv.UnregisterSecretStore("upper")
+
+	var cfg struct {
+		Name string `json:"name"`
+	}
+	err := jenv.UnmarshalJSON([]byte(`{"name": "${upper:fallback}"}`), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "fallback", cfg.Name)
+}
+
+func TestRegisterResolver(t *testing.T) {
+	jenv.RegisterResolver(jenv.ResolverFunc(func(scheme, key string) (string, error) {
+		if key == "missing" {
+			return "", jenv.ErrSecretNotFound
+		}
+		return scheme + "/" + key, nil
+	}), "rvault", "rssm")
+	defer jenv.UnregisterSecretStore("rvault")
+	defer jenv.UnregisterSecretStore("rssm")
+
+	var cfg struct {
+		Password string `json:"password"`
+		APIKey   string `json:"api_key"`
+		Missing  string `json:"missing"`
+	}
+	err := jenv.UnmarshalJSON([]byte(`{
+		"password": "${rvault:secret/db#password}",
+		"api_key": "${rssm:/app/prod/api-key}",
+		"missing": "${rssm:missing:-none}"
+	}`), &cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "rvault/secret/db#password", cfg.Password)
+	assert.Equal(t, "rssm//app/prod/api-key", cfg.APIKey)
+	assert.Equal(t, "none", cfg.Missing)
+
+	assert.Panics(t, func() { jenv.RegisterResolver(nil, "x") })
+}
+
+func TestFileSecretStore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "db_password")
+	require.NoError(t, os.WriteFile(path, []byte("s3cret\n"), 0o600))
+	files := jenv.WithSecretFiles(dir)
+
+	var cfg struct {
+		Password string `json:"password"`
+		Missing  string `json:"missing"`
+	}
+	doc := fmt.Sprintf(`{"password": "${file:%s}", "missing": "${file:%s-missing:-none}"}`, path, path)
+	require.NoError(t, jenv.UnmarshalJSON([]byte(doc), &cfg, files))
+	assert.Equal(t, "s3cret", cfg.Password)
+	assert.Equal(t, "none", cfg.Missing)
+
+	err := jenv.UnmarshalJSON([]byte(fmt.Sprintf(`{"password": "${file:%s-missing}"}`, path)), &cfg, files)
+	assert.ErrorIs(t, err, jenv.ErrSecretNotFound)
+
+	err = jenv.UnmarshalJSON([]byte(doc), &cfg)
+	assert.ErrorContains(t, err, "file placeholders are disabled")
+	_, ok := jenv.Lookup("file:" + path)
+	assert.False(t, ok)
+
+	outside := filepath.Join(t.TempDir(), "other")
+	require.NoError(t, os.WriteFile(outside, []byte("x"), 0o600))
+	link := filepath.Join(dir, "link")
+	require.NoError(t, os.Symlink(outside, link))
+	for _, p := range []string{outside, link, filepath.Join(dir, "..", filepath.Base(filepath.Dir(outside)), "other")} {
+		err = jenv.UnmarshalJSON([]byte(fmt.Sprintf(`{"password": "${file:%s}"}`, p)), &cfg, files)
+		assert.ErrorContains(t, err, "outside the directories", p)
+	}
+}
+
+func TestRegisterSecretStoreInvalid(t *testing.T) {
+	assert.Panics(t, func() { jenv.RegisterSecretStore("", mapStore{}) })
+	assert.Panics(t, func() { jenv.RegisterSecretStore("a:b", mapStore{}) })
+	assert.Panics(t, func() { jenv.RegisterSecretStore("ok", nil) })
+}
+
+func TestWithResolverIsolated(t *testing.T) {
+	release := make(chan struct{})
+	slow := jenv.ResolverFunc(func(_, key string) (string, error) {
+		<-release
+		return "tenant-A-" + key, nil
+	})
+	fast := jenv.ResolverFunc(func(_, key string) (string, error) {
+		return "tenant-B-" + key, nil
+	})
+	type config struct {
+		Password string `json:"password"`
+	}
+	doc := []byte(`{"password": "${isovault:secret}"}`)
+
+	var a config
+	errA := make(chan error, 1)
+	go func() {
+		errA <- jenv.UnmarshalJSON(doc, &a, jenv.WithResolver(slow, "isovault"))
+	}()
+	time.Sleep(20 * time.Millisecond)
+	var b config
+	require.NoError(t, jenv.UnmarshalJSON(doc, &b, jenv.WithResolver(fast, "isovault"), jenv.WithResolveTimeout(time.Second)))
+	close(release)
+	require.NoError(t, <-errA)
+	assert.Equal(t, "tenant-A-secret", a.Password)
+	assert.Equal(t, "tenant-B-secret", b.Password)
+
+	failing := jenv.ResolverFunc(func(_, _ string) (string, error) {
+		return "", errors.New("down")
+	})
+	var c config
+	err := jenv.UnmarshalJSON(doc, &c, jenv.WithResolver(failing, "isovault"), jenv.WithResolvePolicy("isovault:*", jenv.PolicyCached))
+	assert.ErrorContains(t, err, "down", "values resolved by other decodes are not reused")
+}
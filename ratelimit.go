@@ -0,0 +1,86 @@
+package jenv
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	limitsMu sync.RWMutex
+	limits   = map[string]*tokenBucket{}
+)
+
+// SetRateLimit limits calls to the secret store registered for scheme to
+// rate per second, with bursts of up to burst calls, so a fleet restarting
+// at once does not overwhelm the back-end. Lookups wait for a token; one
+// that could not get it before the decode's context is done fails like any
+// other store error. A batched lookup takes a single token. A rate of zero
+// removes the limit.
+func SetRateLimit(scheme string, rate float64, burst int) {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	if rate <= 0 {
+		delete(limits, scheme)
+		return
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	limits[scheme] = &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// waitRateLimit blocks until the rate limit of scheme, if any, admits a
+// call.
+func waitRateLimit(ctx context.Context, scheme string) error {
+	limitsMu.RLock()
+	b := limits[scheme]
+	limitsMu.RUnlock()
+	if b == nil {
+		return nil
+	}
+	if err := b.wait(ctx); err != nil {
+		return fmt.Errorf("rate limit for %s: %w", scheme, err)
+	}
+	return nil
+}
+
+// tokenBucket hands out tokens at a steady rate. Waiters reserve a token
+// up front, so they are served in order.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	delay := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	if deadline, ok := ctx.Deadline(); ok && delay > 0 && now.Add(delay).After(deadline) {
+		b.tokens++
+		b.mu.Unlock()
+		return context.DeadlineExceeded
+	}
+	b.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
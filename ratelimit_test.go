@@ -0,0 +1,42 @@
+package jenv_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestSetRateLimit(t *testing.T) {
+	jenv.RegisterSecretStore("ratetest", mapStore{"a": "1", "b": "2", "c": "3", "d": "4"})
+	defer jenv.UnregisterSecretStore("ratetest")
+	type creds struct {
+		A string `json:"a"`
+		B string `json:"b"`
+		C string `json:"c"`
+		D string `json:"d"`
+	}
+	doc := []byte(`{"a": "${ratetest:a}", "b": "${ratetest:b}", "c": "${ratetest:c}", "d": "${ratetest:d}"}`)
+
+	jenv.SetRateLimit("ratetest", 20, 2)
+	defer jenv.SetRateLimit("ratetest", 0, 0)
+	var cfg creds
+	start := time.Now()
+	require.NoError(t, jenv.UnmarshalJSON(doc, &cfg))
+	assert.Equal(t, creds{"1", "2", "3", "4"}, cfg)
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond, "two calls beyond the burst wait 50ms each")
+
+	jenv.SetRateLimit("ratetest", 1, 1)
+	start = time.Now()
+	err := jenv.UnmarshalJSON(doc, &cfg, jenv.WithResolveTimeout(100*time.Millisecond))
+	assert.ErrorContains(t, err, "rate limit for ratetest: context deadline exceeded")
+	assert.Less(t, time.Since(start), 100*time.Millisecond, "a wait past the deadline fails immediately")
+
+	jenv.SetRateLimit("ratetest", 0, 0)
+	start = time.Now()
+	require.NoError(t, jenv.UnmarshalJSON(doc, &cfg))
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
@@ -0,0 +1,109 @@
+package jenv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// readerParser parses a document straight from a reader, for the formats
+// that can be parsed without reading it into memory first.
+type readerParser func(d *decoder, r io.Reader) (map[string]any, error)
+
+// readerParsers is guarded by formatsMu. A format replaced with
+// RegisterFormat loses its reader parser.
+var readerParsers = map[string]readerParser{
+	"json": (*decoder).readJSON,
+	"yaml": (*decoder).readYAML,
+}
+
+// Decode decodes the document read from r, in the named format ("json",
+// "yaml", "toml", or any added with RegisterFormat), into cfg. JSON and
+// YAML are parsed as they are read, so large files and HTTP bodies need
+// not be held in memory as bytes as well; other formats, and JSON under
+// WithDuplicateKeys, are read in full first. An empty format sniffs the
+// content, as Unmarshal does.
+func Decode(r io.Reader, format string, cfg any, opts ...Option) error {
+	d := newDecoder(opts)
+	defer d.close()
+	rawMap, err := d.read(r, format)
+	if err != nil {
+		return err
+	}
+	return d.decode(cfg, rawMap)
+}
+
+// DecodeJSON decodes the JSON document read from r into cfg.
+func DecodeJSON(r io.Reader, cfg any, opts ...Option) error {
+	return Decode(r, "json", cfg, opts...)
+}
+
+// DecodeYAML decodes the YAML document read from r into cfg.
+func DecodeYAML(r io.Reader, cfg any, opts ...Option) error {
+	return Decode(r, "yaml", cfg, opts...)
+}
+
+// read parses the document read from r in the named format.
+func (d *decoder) read(r io.Reader, format string) (map[string]any, error) {
+	formatsMu.RLock()
+	parse, ok := readerParsers[format]
+	formatsMu.RUnlock()
+	if ok {
+		rawMap, err := parse(d, r)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshalling %s: %v", format, err)
+		}
+		return rawMap, nil
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if format == "" {
+		format = sniffFormat(data)
+	}
+	return d.parse(format, data)
+}
+
+func (d *decoder) readJSON(r io.Reader) (map[string]any, error) {
+	if d.duplicateKeys != 0 {
+		// Duplicate keys are reported by line, which needs the bytes.
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.parseJSON(data)
+	}
+	dec := json.NewDecoder(r)
+	var rawMap map[string]any
+	if err := dec.Decode(&rawMap); errors.Is(err, io.EOF) {
+		// As from json.Unmarshal: an empty document is not valid JSON.
+		return nil, errors.New("unexpected end of JSON input")
+	} else if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid character after top-level value at offset %d", dec.InputOffset())
+	}
+	return rawMap, nil
+}
+
+func (d *decoder) readYAML(r io.Reader) (map[string]any, error) {
+	if d.duplicateKeys != 0 {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.parseYAML(data)
+	}
+	var rawMap map[string]any
+	// An empty document decodes to nothing, as with yaml.Unmarshal.
+	if err := yaml.NewDecoder(r).Decode(&rawMap); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	stringKeys(rawMap)
+	return rawMap, nil
+}
@@ -0,0 +1,59 @@
+package jenv_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestDecodeReader(t *testing.T) {
+	t.Setenv("READER_HOST", "db.internal")
+	type config struct {
+		Host  string   `json:"host" yaml:"host"`
+		Port  int      `json:"port" yaml:"port"`
+		Hosts []string `json:"hosts" yaml:"hosts"`
+	}
+	want := config{Host: "db.internal", Port: 5432, Hosts: []string{"a", "b"}}
+
+	var cfg config
+	require.NoError(t, jenv.DecodeJSON(strings.NewReader(`{"host": "${READER_HOST}", "port": 5432, "hosts": "a,b"}`), &cfg))
+	assert.Equal(t, want, cfg)
+
+	cfg = config{}
+	require.NoError(t, jenv.DecodeYAML(strings.NewReader("host: ${READER_HOST}\nport: 5432\nhosts: [a, b]\n"), &cfg))
+	assert.Equal(t, want, cfg)
+
+	cfg = config{}
+	require.NoError(t, jenv.Decode(strings.NewReader("host = \"${READER_HOST}\"\nport = 5432\nhosts = [\"a\", \"b\"]\n"), "toml", &cfg))
+	assert.Equal(t, want, cfg)
+
+	cfg = config{}
+	require.NoError(t, jenv.Decode(io.MultiReader(strings.NewReader(`{"host": "${READER_HOST}",`), strings.NewReader(`"port": 5432, "hosts": ["a", "b"]}`)), "", &cfg))
+	assert.Equal(t, want, cfg, "an empty format is sniffed")
+
+	require.NoError(t, jenv.DecodeYAML(strings.NewReader(""), &config{}), "an empty document decodes nothing")
+	err := jenv.DecodeJSON(strings.NewReader(`{"port": 1} {"port": 2}`), &cfg)
+	assert.ErrorContains(t, err, "after top-level value")
+	err = jenv.DecodeJSON(strings.NewReader(`{"port": 1, "port": 2}`), &cfg, jenv.WithDuplicateKeys(jenv.DuplicateKeysError))
+	assert.ErrorContains(t, err, "port")
+	err = jenv.Decode(strings.NewReader("a = 1"), "xml", &cfg)
+	assert.EqualError(t, err, "unsupported config format 'xml'")
+}
+
+func TestDecodeEmptyJSON(t *testing.T) {
+	var cfg struct {
+		Port int `json:"port"`
+	}
+	want := jenv.UnmarshalJSON(nil, &cfg)
+	require.Error(t, want)
+	for _, input := range []string{"", "  \n"} {
+		assert.EqualError(t, jenv.DecodeJSON(strings.NewReader(input), &cfg), want.Error())
+	}
+	err := jenv.DecodeJSON(strings.NewReader(""), &cfg, jenv.WithDuplicateKeys(jenv.DuplicateKeysError))
+	assert.Error(t, err)
+}
@@ -0,0 +1,134 @@
+package jenv
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// Redacted is the value Redact puts in place of sensitive values.
+const Redacted = "[REDACTED]"
+
+// Redact returns cfg, a struct or pointer to one, as a tree of maps,
+// slices and scalars keyed like its document and safe to log or serve.
+// Values are replaced by Redacted when their field is tagged
+// `secret:"true"`, when their key looks like a credential (password,
+// token, api_key, ...), or, if res is given, when they were resolved from
+// a secret store.
+func Redact(cfg any, res *Result) any {
+	var secretValues map[string]bool
+	if res != nil {
+		secretValues = res.secretValues
+	}
+	return redactValue(reflect.ValueOf(cfg), "", secretValues)
+}
+
+// fieldValuePath and elemValuePath extend a value path, which names a
+// value by the indexes of the struct fields and the list indexes or map
+// keys leading to it. Fields are matched by index rather than by key, so
+// a value is found however its document key was matched, and promoted
+// fields name the same value as they do through their embedded struct.
+func fieldValuePath(index []int) string {
+	var b strings.Builder
+	for _, i := range index {
+		fmt.Fprintf(&b, "/%d", i)
+	}
+	return b.String()
+}
+
+func elemValuePath(key any) string {
+	return fmt.Sprintf("[%#v]", key)
+}
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+func redactValue(v reflect.Value, path string, secretValues map[string]bool) any {
+	if secretValues[path] {
+		return Redacted
+	}
+	for {
+		if !v.IsValid() || (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			return nil
+		}
+		if t, ok := v.Interface().(*template.Template); ok {
+			return t.Root.String()
+		}
+		if marshals(v.Type()) {
+			return v.Interface()
+		}
+		if v.CanAddr() && marshals(reflect.PointerTo(v.Type())) {
+			return v.Addr().Interface()
+		}
+		if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface {
+			break
+		}
+		v = v.Elem()
+	}
+	typ := v.Type()
+	switch v.Kind() {
+	case reflect.Struct:
+		out := map[string]any{}
+		for _, field := range structFields(typ, defaultTagOrder) {
+			fv, ok := fieldByIndexRead(v, field.Index)
+			if !ok {
+				continue
+			}
+			key := fieldKey(field, defaultTagOrder)
+			if (field.Tag.Get("secret") == "true" || isSecretKey(key)) && !fv.IsZero() {
+				out[key] = Redacted
+				continue
+			}
+			out[key] = redactValue(fv, path+fieldValuePath(field.Index), secretValues)
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := map[string]any{}
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			if isSecretKey(key) {
+				out[key] = Redacted
+				continue
+			}
+			out[key] = redactValue(iter.Value(), path+elemValuePath(iter.Key().Interface()), secretValues)
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = redactValue(v.Index(i), path+elemValuePath(i), secretValues)
+		}
+		return out
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return nil
+	}
+	return v.Interface()
+}
+
+// marshals reports whether values of typ know how to serialize themselves,
+// such as time.Time or net.IP.
+func marshals(typ reflect.Type) bool {
+	return typ.Implements(textMarshalerType) || typ.Implements(jsonMarshalerType)
+}
+
+// isSecretKey reports whether a document key names a credential, using the
+// same rule as the plaintext-secret lint.
+func isSecretKey(key string) bool {
+	normalized := strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+	return secretKey.MatchString(normalized)
+}
@@ -0,0 +1,123 @@
+package jenv_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestRedact(t *testing.T) {
+	jenv.RegisterSecretStore("redacttest", mapStore{"db/dsn": "postgres://admin:hunter2@db/app"})
+	defer jenv.UnregisterSecretStore("redacttest")
+
+	type config struct {
+		Name     string            `json:"name"`
+		DSN      string            `json:"dsn"`
+		Password string            `json:"password"`
+		Empty    string            `json:"api_key"`
+		Internal string            `json:"internal" secret:"true"`
+		Timeout  time.Duration     `json:"timeout"`
+		Started  time.Time         `json:"started"`
+		Headers  map[string]string `json:"headers"`
+		Peers    []struct {
+			Host  string `json:"host"`
+			Token string `json:"token"`
+		} `json:"peers"`
+	}
+	doc := []byte(`{
+		"name": "app",
+		"dsn": "${redacttest:db/dsn}",
+		"password": "plain",
+		"internal": "x",
+		"timeout": "5s",
+		"started": "2024-01-02T03:04:05Z",
+		"headers": {"Accept": "json", "X-Api-Token": "abc"},
+		"peers": [{"host": "a", "token": "t"}]
+	}`)
+	var cfg config
+	var res jenv.Result
+	require.NoError(t, jenv.UnmarshalJSON(doc, &cfg, jenv.WithResult(&res)))
+
+	out, err := json.Marshal(jenv.Redact(&cfg, &res))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"name": "app",
+		"dsn": "[REDACTED]",
+		"password": "[REDACTED]",
+		"api_key": "",
+		"internal": "[REDACTED]",
+		"timeout": 5000000000,
+		"started": "2024-01-02T03:04:05Z",
+		"headers": {"Accept": "json", "X-Api-Token": "[REDACTED]"},
+		"peers": [{"host": "a", "token": "[REDACTED]"}]
+	}`, string(out))
+
+	out, err = json.Marshal(jenv.Redact(cfg, nil))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "hunter2", "without a Result only keys are inspected")
+}
+
+func TestRedactMatchedKeys(t *testing.T) {
+	jenv.RegisterSecretStore("redactkeys", mapStore{"a": "SECRET-a", "b": "SECRET-b", "c": "SECRET-c"})
+	defer jenv.UnregisterSecretStore("redactkeys")
+
+	type replica struct {
+		URL string `mapstructure:"dsn_url"`
+	}
+	type config struct {
+		DBConn   string
+		Replicas map[int]replica `mapstructure:"replicas"`
+		Primary  replica         `mapstructure:"primary"`
+	}
+	doc := []byte(`{
+		"dbconn": "${redactkeys:a}",
+		"replicas": {"1": {"dsn_url": "${redactkeys:b}"}},
+		"primary": {"dsn_url": "${redactkeys:c}"}
+	}`)
+	var cfg config
+	var res jenv.Result
+	require.NoError(t, jenv.UnmarshalJSON(doc, &cfg, jenv.WithTagName("mapstructure"), jenv.WithResult(&res)))
+	require.Equal(t, "SECRET-a", cfg.DBConn)
+	require.Equal(t, "SECRET-c", cfg.Primary.URL)
+
+	out, err := json.Marshal(jenv.Redact(&cfg, &res))
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "SECRET")
+}
+
+func TestRedactThroughVars(t *testing.T) {
+	jenv.RegisterSecretStore("redactvars", mapStore{"db": "hunter2"})
+	defer jenv.UnregisterSecretStore("redactvars")
+
+	type database struct {
+		Pass string `json:"pw"`
+		Host string `json:"host"`
+	}
+	type config struct {
+		DSN      string   `json:"dsn"`
+		Database database `json:"database"`
+		Region   string   `json:"region"`
+	}
+	doc := []byte(`{
+		"vars": {"pw": "${redactvars:db}", "db": {"pw": "${redactvars:db}", "host": "h"}, "region": "eu"},
+		"dsn": "postgres://u:${vars.pw}@h",
+		"database": "${vars.db}",
+		"region": "${vars.region}"
+	}`)
+	var cfg config
+	var res jenv.Result
+	require.NoError(t, jenv.UnmarshalJSON(doc, &cfg, jenv.WithResult(&res)))
+	require.Equal(t, "postgres://u:hunter2@h", cfg.DSN)
+	require.Equal(t, "hunter2", cfg.Database.Pass)
+
+	out, err := json.Marshal(jenv.Redact(&cfg, &res))
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "hunter2")
+	assert.Contains(t, string(out), `"host":"h"`)
+	assert.Contains(t, string(out), `"region":"eu"`)
+}
@@ -0,0 +1,199 @@
+// Package rediscache provides a jenv.Cache backed by Redis, so that
+// replicas of a service share resolved secrets instead of each querying
+// the secret store. It speaks the Redis protocol directly and has no
+// dependencies beyond the standard library.
+//
+//	cache := rediscache.New("redis.internal:6379", rediscache.Options{Password: pw})
+//	defer cache.Close()
+//	err := jenv.Load(ctx, "config.yaml", &cfg, jenv.WithCache(cache, 5*time.Minute))
+package rediscache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oarkflow/jenv"
+)
+
+// Options configure the connection.
+type Options struct {
+	// Password is sent with AUTH when set; Username selects an ACL user.
+	Username string
+	Password string
+	// DB is the database selected after connecting.
+	DB int
+	// Prefix is prepended to every key; defaults to "jenv:".
+	Prefix string
+	// DialTimeout bounds connecting when the context has no deadline;
+	// defaults to 5s.
+	DialTimeout time.Duration
+}
+
+// Cache is a jenv.Cache storing entries in Redis. It keeps a single
+// connection, re-established after errors, and is safe for concurrent use.
+type Cache struct {
+	addr string
+	opts Options
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+var _ jenv.Cache = (*Cache)(nil)
+
+// New returns a cache using the Redis server at addr (host:port). The
+// connection is made on first use.
+func New(addr string, opts Options) *Cache {
+	if opts.Prefix == "" {
+		opts.Prefix = "jenv:"
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 5 * time.Second
+	}
+	return &Cache{addr: addr, opts: opts}
+}
+
+func (c *Cache) Get(ctx context.Context, key string) (string, bool, error) {
+	reply, err := c.do(ctx, "GET", c.opts.Prefix+key)
+	if err != nil {
+		return "", false, err
+	}
+	if reply == nil {
+		return "", false, nil
+	}
+	return *reply, true, nil
+}
+
+func (c *Cache) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	args := []string{"SET", c.opts.Prefix + key, value}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(max(ttl.Milliseconds(), 1), 10))
+	}
+	_, err := c.do(ctx, args...)
+	return err
+}
+
+// Close closes the connection.
+func (c *Cache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn, c.r = nil, nil
+	return err
+}
+
+// do sends a command and returns its reply; nil stands for a nil bulk
+// string. Any error drops the connection, so the next command starts on a
+// fresh one.
+func (c *Cache) do(ctx context.Context, args ...string) (*string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		if err := c.connect(ctx); err != nil {
+			return nil, err
+		}
+	}
+	reply, err := c.roundTrip(ctx, args)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) {
+		c.conn.Close()
+		c.conn, c.r = nil, nil
+	}
+	return reply, err
+}
+
+func (c *Cache) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: c.opts.DialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return fmt.Errorf("redis: %w", err)
+	}
+	c.conn, c.r = conn, bufio.NewReader(conn)
+	var setup [][]string
+	if c.opts.Password != "" {
+		if c.opts.Username != "" {
+			setup = append(setup, []string{"AUTH", c.opts.Username, c.opts.Password})
+		} else {
+			setup = append(setup, []string{"AUTH", c.opts.Password})
+		}
+	}
+	if c.opts.DB != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.opts.DB)})
+	}
+	for _, cmd := range setup {
+		if _, err := c.roundTrip(ctx, cmd); err != nil {
+			conn.Close()
+			c.conn, c.r = nil, nil
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Cache) roundTrip(ctx context.Context, args []string) (*string, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Time{}
+	}
+	c.conn.SetDeadline(deadline)
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	return readReply(c.r)
+}
+
+// redisError is an error reply from the server; the connection stays
+// usable after one.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+func readReply(r *bufio.Reader) (*string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+	switch line[0] {
+	case '+', ':':
+		s := line[1:]
+		return &s, nil
+	case '-':
+		return nil, redisError(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: bad bulk length %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, fmt.Errorf("redis: %w", err)
+		}
+		s := string(buf[:n])
+		return &s, nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
@@ -0,0 +1,156 @@
+package rediscache_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv/rediscache"
+)
+
+// fakeRedis implements the handful of commands the cache sends.
+type fakeRedis struct {
+	mu       sync.Mutex
+	password string
+	data     map[string]string
+	expires  map[string]time.Time
+	commands []string
+}
+
+func startFakeRedis(t *testing.T, password string) (*fakeRedis, string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	f := &fakeRedis{password: password, data: map[string]string{}, expires: map[string]time.Time{}}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f, ln.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authed := f.password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, strings.Join(args, " "))
+		var reply string
+		switch cmd := strings.ToUpper(args[0]); {
+		case cmd == "AUTH":
+			if args[len(args)-1] == f.password {
+				authed, reply = true, "+OK\r\n"
+			} else {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case !authed:
+			reply = "-NOAUTH Authentication required.\r\n"
+		case cmd == "SELECT":
+			reply = "+OK\r\n"
+		case cmd == "SET":
+			f.data[args[1]] = args[2]
+			delete(f.expires, args[1])
+			if len(args) == 5 && args[3] == "PX" {
+				ms, _ := strconv.Atoi(args[4])
+				f.expires[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+			}
+			reply = "+OK\r\n"
+		case cmd == "GET":
+			v, ok := f.data[args[1]]
+			if exp, has := f.expires[args[1]]; has && time.Now().After(exp) {
+				ok = false
+			}
+			if ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+			} else {
+				reply = "$-1\r\n"
+			}
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		f.mu.Unlock()
+		io.WriteString(conn, reply)
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func TestCache(t *testing.T) {
+	srv, addr := startFakeRedis(t, "hunter2")
+	c := rediscache.New(addr, rediscache.Options{Password: "hunter2", DB: 2})
+	defer c.Close()
+	ctx := context.Background()
+
+	_, ok, err := c.Get(ctx, "vault:db")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, c.Set(ctx, "vault:db", "s3cret", time.Minute))
+	require.NoError(t, c.Set(ctx, "vault:short", "brief", time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+
+	val, ok, err := c.Get(ctx, "vault:db")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "s3cret", val)
+	_, ok, err = c.Get(ctx, "vault:short")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	assert.Equal(t, []string{
+		"AUTH hunter2", "SELECT 2",
+		"GET jenv:vault:db",
+		"SET jenv:vault:db s3cret PX 60000",
+		"SET jenv:vault:short brief PX 1",
+		"GET jenv:vault:db",
+		"GET jenv:vault:short",
+	}, srv.commands)
+}
+
+func TestCacheAuthFailure(t *testing.T) {
+	_, addr := startFakeRedis(t, "hunter2")
+	c := rediscache.New(addr, rediscache.Options{Password: "wrong"})
+	defer c.Close()
+	_, _, err := c.Get(context.Background(), "vault:db")
+	assert.EqualError(t, err, "redis: WRONGPASS invalid password")
+}
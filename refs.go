@@ -0,0 +1,124 @@
+package jenv
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var keyRef = regexp.MustCompile(`\$\{\.([A-Za-z0-9_.\[\]-]+)\}`)
+
+// expandRefs replaces `${.path}` references with the value of another key
+// of the same document, so `${.database.host}` repeats database.host. The
+// referenced value is expanded first, so references may chain; a cycle is
+// an error. As with `${vars.name}`, a reference making up a whole value
+// keeps the referenced type and references inside a longer string are
+// interpolated. Placeholders in the referenced value are copied as written
+// and resolved with the field they end up in.
+func expandRefs(rawMap map[string]any) error {
+	r := &refResolver{root: rawMap, state: map[string]refState{}}
+	_, err := r.resolve("", rawMap)
+	return err
+}
+
+type refState int
+
+const (
+	refResolving refState = iota + 1
+	refResolved
+)
+
+type refResolver struct {
+	root map[string]any
+	// state tracks the keys being expanded, to detect cycles, and the ones
+	// already expanded in place.
+	state map[string]refState
+}
+
+func (r *refResolver) resolve(path string, value any) (any, error) {
+	switch r.state[path] {
+	case refResolving:
+		return nil, fmt.Errorf("reference cycle through '.%s'", path)
+	case refResolved:
+		return value, nil
+	}
+	r.state[path] = refResolving
+	defer func() { r.state[path] = refResolved }()
+	switch v := value.(type) {
+	case string:
+		return r.substitute(v)
+	case map[string]any:
+		for k, child := range v {
+			expanded, err := r.resolve(joinPath(path, k), child)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = expanded
+		}
+	case []any:
+		for i, child := range v {
+			expanded, err := r.resolve(fmt.Sprintf("%s[%d]", path, i), child)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+	}
+	return value, nil
+}
+
+func (r *refResolver) substitute(s string) (any, error) {
+	var matches [][]int
+	for _, m := range keyRef.FindAllStringSubmatchIndex(s, -1) {
+		if m[0] == 0 || s[m[0]-1] != '$' {
+			matches = append(matches, m)
+		}
+	}
+	if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(s) {
+		return r.lookup(s[matches[0][2]:matches[0][3]])
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		val, err := r.lookup(s[m[2]:m[3]])
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(s[last:m[0]])
+		fmt.Fprintf(&b, "%v", val)
+		last = m[1]
+	}
+	b.WriteString(s[last:])
+	return b.String(), nil
+}
+
+// lookup returns the expanded value of the key at path, storing the
+// expansion back into the document.
+func (r *refResolver) lookup(path string) (any, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, fmt.Errorf("undefined reference '.%s'", path)
+	}
+	var parent, value any = nil, r.root
+	var seg pathSegment
+	matched := ""
+	for _, s := range segments {
+		var ok bool
+		parent = value
+		if value, seg, ok = child(parent, s, false); !ok {
+			return nil, fmt.Errorf("undefined reference '.%s'", path)
+		}
+		matched = seg.String(matched)
+	}
+	expanded, err := r.resolve(matched, value)
+	if err != nil {
+		return nil, err
+	}
+	switch p := parent.(type) {
+	case map[string]any:
+		p[seg.key] = expanded
+	case []any:
+		p[seg.index] = expanded
+	}
+	return expanded, nil
+}
@@ -0,0 +1,61 @@
+package jenv_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestKeyReferences(t *testing.T) {
+	t.Setenv("REF_DB_HOST", "db.internal")
+	type config struct {
+		Database struct {
+			Host string `yaml:"host"`
+			Port int    `yaml:"port"`
+		} `yaml:"database"`
+		Replica struct {
+			Host string `yaml:"host"`
+			Port int    `yaml:"port"`
+		} `yaml:"replica"`
+		DSN     string   `yaml:"dsn"`
+		Hosts   []string `yaml:"hosts"`
+		Primary string   `yaml:"primary"`
+		Literal string   `yaml:"literal"`
+	}
+	doc := []byte(`
+dsn: "postgres://${.database.host}:${.database.port}/app"
+primary: "${.dsn}"
+database:
+  host: "${REF_DB_HOST:localhost}"
+  port: 5432
+replica: "${.database}"
+hosts: ["${.database.host}", "backup.internal"]
+literal: "$${.database.host}"
+`)
+	var cfg config
+	require.NoError(t, jenv.UnmarshalYAML(doc, &cfg))
+	assert.Equal(t, "db.internal", cfg.Database.Host)
+	assert.Equal(t, "db.internal", cfg.Replica.Host)
+	assert.Equal(t, 5432, cfg.Replica.Port)
+	assert.Equal(t, "postgres://db.internal:5432/app", cfg.DSN)
+	assert.Equal(t, cfg.DSN, cfg.Primary)
+	assert.Equal(t, []string{"db.internal", "backup.internal"}, cfg.Hosts)
+	assert.Equal(t, "${.database.host}", cfg.Literal)
+
+	err := jenv.UnmarshalYAML([]byte(`dsn: "${.database.missing}"`), &cfg)
+	assert.EqualError(t, err, "undefined reference '.database.missing'")
+	err = jenv.UnmarshalYAML([]byte("dsn: \"${.primary}\"\nprimary: \"${.dsn}\""), &cfg)
+	assert.ErrorContains(t, err, "reference cycle through")
+
+	err = jenv.UnmarshalYAML([]byte("hosts: [a, b]\nprimary: \"${.hosts[1]}\"\ndsn: \"${.hosts.0}\""), &cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "b", cfg.Primary)
+	assert.Equal(t, "a", cfg.DSN)
+
+	issues, err := jenv.Lint([]byte(`{"host": "x", "dsn": "${.host}"}`), nil)
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+}
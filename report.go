@@ -0,0 +1,58 @@
+package jenv
+
+import "reflect"
+
+// Report gathers everything Check found in a document, grouped by
+// severity. It is meant to be serialized for CI systems and dashboards;
+// every list is present, empty when nothing was found.
+type Report struct {
+	// Errors are problems that make the document unusable: it does not
+	// parse, or it does not decode into the configuration struct.
+	Errors []LintIssue `json:"errors"`
+	// Warnings are lint findings (see Lint).
+	Warnings []LintIssue `json:"warnings"`
+	// Unresolved lists env placeholders whose variable is unset and that
+	// have no default, so their fields would be left empty.
+	Unresolved []LintIssue `json:"unresolved"`
+	// Deprecations lists keys setting fields tagged `deprecated`.
+	Deprecations []LintIssue `json:"deprecations"`
+}
+
+// OK reports whether the document can be used as is: it decoded and every
+// placeholder resolved.
+func (r *Report) OK() bool {
+	return len(r.Errors) == 0 && len(r.Unresolved) == 0
+}
+
+// Check lints data and, when cfg is given, decodes it into cfg with opts,
+// collecting every finding into a Report instead of stopping at the first
+// error. Placeholders are resolved as a decode with opts would resolve
+// them, so WithEnv and WithEnvReplay apply.
+func Check(data []byte, cfg any, opts ...Option) *Report {
+	r := &Report{Errors: []LintIssue{}, Warnings: []LintIssue{}, Unresolved: []LintIssue{}, Deprecations: []LintIssue{}}
+	d := newDecoder(opts)
+	l := &linter{checkFields: cfg != nil, lookupEnv: d.lookupEnv}
+	err := l.lint(data, cfg)
+	d.close()
+	if err != nil {
+		r.Errors = append(r.Errors, LintIssue{Rule: "parse", Message: err.Error()})
+		return r
+	}
+	for _, issue := range l.issues {
+		switch issue.Rule {
+		case "unresolved-placeholder":
+			r.Unresolved = append(r.Unresolved, issue)
+		case "deprecated-key":
+			r.Deprecations = append(r.Deprecations, issue)
+		default:
+			r.Warnings = append(r.Warnings, issue)
+		}
+	}
+	if cfg == nil || reflect.ValueOf(cfg).Kind() != reflect.Ptr {
+		return r
+	}
+	if err := unmarshalFormat(sniffFormat(data), data, cfg, opts...); err != nil {
+		r.Errors = append(r.Errors, LintIssue{Rule: "decode", Message: err.Error()})
+	}
+	return r
+}
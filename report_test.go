@@ -0,0 +1,53 @@
+package jenv_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestCheck(t *testing.T) {
+	type config struct {
+		Host    string `json:"host"`
+		Port    int    `json:"port"`
+		Timeout string `json:"timeout" deprecated:"use 'deadline'"`
+		Name    string `json:"name"`
+	}
+	doc := []byte(`
+host: "${REPORT_HOST}"
+port: "${REPORT_PORT}"
+timeout: 5s
+name: "${REPORT_NAME}"
+`)
+	ctx := jenv.WithEnv(context.Background(), map[string]string{"REPORT_PORT": "eighty", "REPORT_NAME": "api"})
+	var cfg config
+	report := jenv.Check(doc, &cfg, jenv.WithContext(ctx))
+
+	rules := func(issues []jenv.LintIssue) map[string]string {
+		out := map[string]string{}
+		for _, issue := range issues {
+			out[issue.Path] = issue.Rule
+		}
+		return out
+	}
+	assert.Equal(t, map[string]string{"host": "unresolved-placeholder"}, rules(report.Unresolved))
+	assert.Equal(t, map[string]string{"timeout": "deprecated-key"}, rules(report.Deprecations))
+	assert.Equal(t, map[string]string{"port": "placeholder-without-default", "name": "placeholder-without-default"}, rules(report.Warnings))
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, "decode", report.Errors[0].Rule)
+	assert.Contains(t, report.Errors[0].Message, "'port'")
+	assert.False(t, report.OK())
+
+	data, err := json.Marshal(jenv.Check([]byte(`name: x`), nil))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"errors": [], "warnings": [], "unresolved": [], "deprecations": []}`, string(data))
+
+	report = jenv.Check([]byte("a: [b"), nil)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, "parse", report.Errors[0].Rule)
+}
@@ -0,0 +1,88 @@
+package jenv
+
+import (
+	"path"
+	"sync"
+)
+
+// ResolutionCache keeps the values secret stores returned for each
+// `scheme:ref` placeholder, so repeated decodes of the same document only
+// contact stores for placeholders that are new or were invalidated. Env
+// var placeholders are always read afresh.
+type ResolutionCache struct {
+	mu      sync.Mutex
+	entries map[string]resolvedEntry
+	touched map[string]bool
+}
+
+type resolvedEntry struct {
+	value  string
+	source string
+}
+
+// NewResolutionCache returns an empty cache.
+func NewResolutionCache() *ResolutionCache {
+	return &ResolutionCache{entries: map[string]resolvedEntry{}, touched: map[string]bool{}}
+}
+
+// WithResolutionCache serves secret placeholders from c when possible and
+// stores newly resolved values in it.
+func WithResolutionCache(c *ResolutionCache) Option {
+	return func(o *options) {
+		o.resolutionCache = c
+	}
+}
+
+// Invalidate drops the entries whose `scheme:ref` key matches any of the
+// patterns (path.Match syntax, e.g. "vault:*"). Without patterns every
+// entry is dropped.
+func (c *ResolutionCache) Invalidate(patterns ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(patterns) == 0 {
+		clear(c.entries)
+		return
+	}
+	for key := range c.entries {
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, key); ok {
+				delete(c.entries, key)
+				break
+			}
+		}
+	}
+}
+
+// Len returns the number of cached placeholders.
+func (c *ResolutionCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+func (c *ResolutionCache) get(key string) (resolvedEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.touched[key] = true
+	e, ok := c.entries[key]
+	return e, ok
+}
+
+func (c *ResolutionCache) set(key string, e resolvedEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = e
+}
+
+// retainTouched drops the entries not used since the last call, so
+// placeholders removed from the document do not linger.
+func (c *ResolutionCache) retainTouched() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if !c.touched[key] {
+			delete(c.entries, key)
+		}
+	}
+	clear(c.touched)
+}
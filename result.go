@@ -0,0 +1,58 @@
+package jenv
+
+// Result records how a decode resolved its values. Pass one with
+// WithResult to inspect it after decoding.
+type Result struct {
+	// Sources maps the path of each field resolved through a secret store
+	// to the source that answered, e.g. "database.password" -> "vault".
+	// Values that fell back to a placeholder default are recorded as
+	// "default".
+	Sources map[string]string
+	// Documents lists the documents read by the load.
+	Documents []string
+	// Dependencies lists the env vars and secrets each field was
+	// resolved from.
+	Dependencies []Dependency
+	// Env holds the environment variables the decode read, when
+	// WithEnvSnapshot is set. Pass it to WithEnvReplay to decode again
+	// against exactly the same values.
+	Env EnvSnapshot
+	// Warnings lists problems that did not fail the decode, such as
+	// duplicate keys under DuplicateKeysWarn.
+	Warnings []string
+	// secretValues holds the value paths (see decoder.valuePath) of the
+	// fields resolved from secret stores, for Redact.
+	secretValues map[string]bool
+}
+
+func (d *decoder) recordSource(path, source string) {
+	for _, res := range d.results {
+		if res.Sources == nil {
+			res.Sources = map[string]string{}
+		}
+		res.Sources[path] = source
+	}
+}
+
+func (d *decoder) recordWarning(msg string) {
+	for _, res := range d.results {
+		res.Warnings = append(res.Warnings, msg)
+	}
+}
+
+// recordSecretValue marks the value being decoded as resolved from a
+// secret store. Placeholders resolved outside a field, such as those of
+// document variables, are recorded when the fields using them are
+// decoded; see decoder.secretPaths.
+func (d *decoder) recordSecretValue() {
+	d.secretResolved = true
+	if d.valuePath == "" {
+		return
+	}
+	for _, res := range d.results {
+		if res.secretValues == nil {
+			res.secretValues = map[string]bool{}
+		}
+		res.secretValues[d.valuePath] = true
+	}
+}
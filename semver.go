@@ -0,0 +1,159 @@
+package jenv
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SemVer is a semantic version (https://semver.org) validated when the
+// configuration is decoded. A leading "v" is accepted.
+type SemVer struct {
+	Major, Minor, Patch uint64
+	Prerelease          string
+	Build               string
+}
+
+func init() {
+	registerConverter(ParseSemVer)
+}
+
+// ParseSemVer parses a semantic version such as "1.4.0" or "v2.0.0-rc.1".
+func ParseSemVer(s string) (SemVer, error) {
+	var v SemVer
+	rest := strings.TrimPrefix(s, "v")
+	rest, build, hasBuild := strings.Cut(rest, "+")
+	rest, pre, hasPre := strings.Cut(rest, "-")
+	v.Prerelease, v.Build = pre, build
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return SemVer{}, fmt.Errorf("invalid semantic version %q: expected MAJOR.MINOR.PATCH", s)
+	}
+	nums := [3]*uint64{&v.Major, &v.Minor, &v.Patch}
+	for i, p := range parts {
+		n, err := strconv.ParseUint(p, 10, 64)
+		if err != nil || len(p) > 1 && p[0] == '0' {
+			return SemVer{}, fmt.Errorf("invalid semantic version %q: bad number %q", s, p)
+		}
+		*nums[i] = n
+	}
+	if hasPre && !validSemVerIdents(pre) {
+		return SemVer{}, fmt.Errorf("invalid semantic version %q: bad pre-release %q", s, pre)
+	}
+	if hasBuild && !validSemVerIdents(build) {
+		return SemVer{}, fmt.Errorf("invalid semantic version %q: bad build metadata %q", s, build)
+	}
+	return v, nil
+}
+
+func validSemVerIdents(ids string) bool {
+	for _, id := range strings.Split(ids, ".") {
+		if id == "" {
+			return false
+		}
+		for _, c := range id {
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// String returns the version without a "v" prefix.
+func (v SemVer) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or +1 depending on whether v is lower than, equal
+// to or higher than o. Build metadata is ignored, and a pre-release is
+// lower than the release it precedes.
+func (v SemVer) Compare(o SemVer) int {
+	for _, c := range [3][2]uint64{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		if c[0] != c[1] {
+			if c[0] < c[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case v.Prerelease == o.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case o.Prerelease == "":
+		return -1
+	}
+	a, b := strings.Split(v.Prerelease, "."), strings.Split(o.Prerelease, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := comparePrereleaseIdent(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+func comparePrereleaseIdent(a, b string) int {
+	an, aErr := strconv.ParseUint(a, 10, 64)
+	bn, bErr := strconv.ParseUint(b, 10, 64)
+	switch {
+	case aErr == nil && bErr == nil:
+		if an == bn {
+			return 0
+		}
+		if an < bn {
+			return -1
+		}
+		return 1
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// LessThan reports whether v is lower than o.
+func (v SemVer) LessThan(o SemVer) bool {
+	return v.Compare(o) < 0
+}
+
+// AtLeast reports whether v is equal to or higher than o, as when checking
+// a client against a minimum supported version.
+func (v SemVer) AtLeast(o SemVer) bool {
+	return v.Compare(o) >= 0
+}
+
+// IsZero reports whether v was never set.
+func (v SemVer) IsZero() bool {
+	return v == SemVer{}
+}
+
+// MarshalText returns the version without a "v" prefix.
+func (v SemVer) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText parses a semantic version.
+func (v *SemVer) UnmarshalText(text []byte) error {
+	parsed, err := ParseSemVer(string(text))
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
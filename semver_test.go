@@ -0,0 +1,52 @@
+package jenv_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestSemVer(t *testing.T) {
+	type gates struct {
+		MinClient jenv.SemVer `yaml:"min_client"`
+		Migration jenv.SemVer `yaml:"migration"`
+	}
+	var cfg gates
+	require.NoError(t, jenv.UnmarshalYAML([]byte("min_client: v1.4.0\nmigration: 2.0.0-rc.1+build.5"), &cfg))
+	assert.Equal(t, jenv.SemVer{Major: 1, Minor: 4}, cfg.MinClient)
+	assert.Equal(t, "2.0.0-rc.1+build.5", cfg.Migration.String())
+
+	client, err := jenv.ParseSemVer("1.10.2")
+	require.NoError(t, err)
+	assert.True(t, client.AtLeast(cfg.MinClient))
+	assert.True(t, client.LessThan(cfg.Migration))
+
+	err = jenv.UnmarshalYAML([]byte("min_client: 1.4"), &cfg)
+	assert.ErrorContains(t, err, `error decoding 'min_client': invalid semantic version "1.4": expected MAJOR.MINOR.PATCH`)
+}
+
+func TestSemVerOrdering(t *testing.T) {
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.2.0", "2.0.0",
+	}
+	for i := 0; i+1 < len(ordered); i++ {
+		a, err := jenv.ParseSemVer(ordered[i])
+		require.NoError(t, err)
+		b, err := jenv.ParseSemVer(ordered[i+1])
+		require.NoError(t, err)
+		assert.Equal(t, -1, a.Compare(b), "%s < %s", a, b)
+		assert.Equal(t, 1, b.Compare(a), "%s > %s", b, a)
+	}
+	a, _ := jenv.ParseSemVer("1.0.0+a")
+	b, _ := jenv.ParseSemVer("1.0.0+b")
+	assert.Equal(t, 0, a.Compare(b))
+
+	for _, s := range []string{"01.0.0", "1.0.0-", "1.0.0-a..b", "1.x.0", "1.0.0+b_1"} {
+		_, err := jenv.ParseSemVer(s)
+		assert.Error(t, err, s)
+	}
+}
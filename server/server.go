@@ -0,0 +1,201 @@
+// Package server serves a service's effective configuration over HTTP as
+// JSON, so sidecars and debugging tools can query what was actually
+// resolved instead of guessing from files. Values are passed through
+// jenv.Redact before they are served, and every request must be
+// authenticated. Admin adds endpoints to reload the configuration and
+// inspect its provenance and reload history:
+//
+//	admin := server.Admin(live, server.Options{Token: token})
+//	mux.Handle("/config", admin)
+//	mux.Handle("/config/", admin)
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/oarkflow/jenv"
+)
+
+// Snapshot returns the configuration to serve and, when available, the
+// Result recorded while decoding it. The Result lets values resolved from
+// secret stores be redacted even when their keys look harmless.
+type Snapshot func() (cfg any, res *jenv.Result)
+
+// FromLive serves the current value of live.
+func FromLive[T any](live *jenv.Live[T]) Snapshot {
+	return func() (any, *jenv.Result) {
+		return live.Get(), live.Result()
+	}
+}
+
+// Static serves a configuration decoded once, such as with jenv.Load.
+func Static(cfg any, res *jenv.Result) Snapshot {
+	return func() (any, *jenv.Result) {
+		return cfg, res
+	}
+}
+
+// Options configure authentication. A request is accepted when it carries
+// `Authorization: Bearer <Token>` or when Authorize returns true; with
+// neither set every request is rejected.
+type Options struct {
+	Token     string
+	Authorize func(*http.Request) bool
+}
+
+// Handler returns an http.Handler answering GET requests with the redacted
+// configuration returned by snapshot.
+func Handler(snapshot Snapshot, opts Options) http.Handler {
+	return opts.guard(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		cfg, res := snapshot()
+		writeJSON(w, http.StatusOK, jenv.Redact(cfg, res))
+	})
+}
+
+// ProvenanceHandler returns an http.Handler answering GET requests with
+// the documents the configuration was read from and, for each field
+// resolved from the environment or a secret store, where its value came
+// from. Only names are reported, never values.
+func ProvenanceHandler(snapshot Snapshot, opts Options) http.Handler {
+	return opts.guard(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		_, res := snapshot()
+		writeJSON(w, http.StatusOK, newProvenance(res))
+	})
+}
+
+// ReloadHandler returns an http.Handler calling reload on POST requests.
+// A failed reload is answered with 500 and the error.
+func ReloadHandler(reload func(context.Context) error, opts Options) http.Handler {
+	return opts.guard(http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+		if err := reload(r.Context()); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+}
+
+// HistoryHandler returns an http.Handler answering GET requests with the
+// reloads returned by history.
+func HistoryHandler(history func() []jenv.ReloadEvent, opts Options) http.Handler {
+	return opts.guard(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		events := []reloadEvent{}
+		for _, e := range history() {
+			event := reloadEvent{Time: e.Time, Changed: e.Changed}
+			if e.Err != nil {
+				event.Error = e.Err.Error()
+			}
+			events = append(events, event)
+		}
+		writeJSON(w, http.StatusOK, events)
+	})
+}
+
+// Admin returns an http.Handler serving the standard endpoints for live:
+//
+//	GET  /config             the redacted configuration
+//	GET  /config/provenance  where each value came from
+//	GET  /config/history     recent reloads
+//	POST /config/reload      reload from the source
+//
+// Mount it at the root of a mux or router; the individual handlers are
+// available for services that want different paths.
+func Admin[T any](live *jenv.Live[T], opts Options) http.Handler {
+	snapshot := FromLive(live)
+	mux := http.NewServeMux()
+	mux.Handle("/config", Handler(snapshot, opts))
+	mux.Handle("/config/provenance", ProvenanceHandler(snapshot, opts))
+	mux.Handle("/config/history", HistoryHandler(live.History, opts))
+	mux.Handle("/config/reload", ReloadHandler(live.Reload, opts))
+	return mux
+}
+
+// guard authenticates requests and restricts them to method before
+// calling h. HEAD is accepted wherever GET is.
+func (o Options) guard(method string, h http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !o.authorized(r) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method != method && !(method == http.MethodGet && r.Method == http.MethodHead) {
+			allow := method
+			if method == http.MethodGet {
+				allow = "GET, HEAD"
+			}
+			w.Header().Set("Allow", allow)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h(w, r)
+	})
+}
+
+type provenance struct {
+	Documents []string                   `json:"documents"`
+	Fields    map[string]fieldProvenance `json:"fields"`
+}
+
+type fieldProvenance struct {
+	Source       string       `json:"source,omitempty"`
+	Dependencies []dependency `json:"dependencies,omitempty"`
+}
+
+type dependency struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+func newProvenance(res *jenv.Result) provenance {
+	p := provenance{Documents: []string{}, Fields: map[string]fieldProvenance{}}
+	if res == nil {
+		return p
+	}
+	p.Documents = append(p.Documents, res.Documents...)
+	for path, source := range res.Sources {
+		f := p.Fields[path]
+		f.Source = source
+		p.Fields[path] = f
+	}
+	for _, dep := range res.Dependencies {
+		f := p.Fields[dep.Path]
+		f.Dependencies = append(f.Dependencies, dependency{Kind: dep.Kind, Name: dep.Name})
+		p.Fields[dep.Path] = f
+	}
+	return p
+}
+
+type reloadEvent struct {
+	Time    time.Time `json:"time"`
+	Changed bool      `json:"changed"`
+	Error   string    `json:"error,omitempty"`
+}
+
+func (o Options) authorized(r *http.Request) bool {
+	if o.Authorize != nil && o.Authorize(r) {
+		return true
+	}
+	if o.Token == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(o.Token)) == 1
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
@@ -0,0 +1,119 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+	"github.com/oarkflow/jenv/server"
+)
+
+func TestHandler(t *testing.T) {
+	cfg := struct {
+		Host     string `json:"host"`
+		Password string `json:"password"`
+	}{Host: "db.internal", Password: "hunter2"}
+	h := server.Handler(server.Static(&cfg, nil), server.Options{Token: "t0ken"})
+
+	do := func(method, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/config", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodGet, "Bearer t0ken")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, map[string]any{"host": "db.internal", "password": "[REDACTED]"}, body)
+
+	rec = do(http.MethodGet, "Bearer wrong")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, do(http.MethodPost, "Bearer t0ken").Code)
+}
+
+func TestHandlerAuthorize(t *testing.T) {
+	h := server.Handler(server.Static(map[string]int{"port": 80}, nil), server.Options{
+		Authorize: func(r *http.Request) bool { return r.Header.Get("X-Internal") == "1" },
+	})
+	req := httptest.NewRequest(http.MethodGet, "/config", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req.Header.Set("X-Internal", "1")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"port": 80}`, rec.Body.String())
+}
+
+func TestAdmin(t *testing.T) {
+	t.Setenv("ADMIN_HOST", "db.internal")
+	var doc atomic.Value
+	doc.Store(`{"host": "${ADMIN_HOST}", "port": 5432}`)
+	src := jenv.SourceFunc(func(context.Context) ([]byte, string, error) {
+		return []byte(doc.Load().(string)), "json", nil
+	})
+	type config struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+	live, err := jenv.NewLive[config](context.Background(), src)
+	require.NoError(t, err)
+	h := server.Admin(live, server.Options{Token: "t0ken"})
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer t0ken")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodGet, "/config")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"host": "db.internal", "port": 5432}`, rec.Body.String())
+
+	rec = do(http.MethodGet, "/config/provenance")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"documents": [], "fields": {"host": {"dependencies": [{"kind": "env", "name": "ADMIN_HOST"}]}}}`, rec.Body.String())
+
+	assert.Equal(t, http.StatusMethodNotAllowed, do(http.MethodGet, "/config/reload").Code)
+	doc.Store(`{"host": "${ADMIN_HOST}", "port": 6432}`)
+	rec = do(http.MethodPost, "/config/reload")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 6432, live.Get().Port)
+
+	doc.Store(`{"port": "not a number"}`)
+	rec = do(http.MethodPost, "/config/reload")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, rec.Body.String(), "'port'")
+
+	rec = do(http.MethodGet, "/config/history")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var history []struct {
+		Changed bool   `json:"changed"`
+		Error   string `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &history))
+	require.Len(t, history, 3)
+	assert.True(t, history[0].Changed)
+	assert.True(t, history[1].Changed)
+	assert.False(t, history[2].Changed)
+	assert.NotEmpty(t, history[2].Error)
+}
@@ -0,0 +1,76 @@
+package jenv
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// secretFlights collapses concurrent lookups of the same secret, from any
+// number of decodes, into a single store call.
+var secretFlights flightGroup
+
+// errFlightPanicked is returned to the callers waiting on a store call
+// that panicked; the panic itself continues in the caller that made it.
+var errFlightPanicked = errors.New("secret store panicked")
+
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+type flight struct {
+	done        chan struct{}
+	val, source string
+	err         error
+	// cancelled is set when the call failed because the context of the
+	// caller that started it was done, which says nothing about the
+	// other callers' lookups.
+	cancelled bool
+}
+
+// do runs fn for key unless a call for key is already in flight, in which
+// case it waits for that call's result. The call runs with the context of
+// the caller that started it; if that context ends the call, waiters whose
+// own ctx is not done start another call rather than take its error.
+// Waiters give up when their own ctx is done.
+func (g *flightGroup) do(ctx context.Context, key string, fn func() (string, string, error)) (string, string, error) {
+	for {
+		g.mu.Lock()
+		f, ok := g.calls[key]
+		if !ok {
+			break
+		}
+		g.mu.Unlock()
+		select {
+		case <-f.done:
+			if f.cancelled && ctx.Err() == nil {
+				continue
+			}
+			return f.val, f.source, f.err
+		case <-ctx.Done():
+			return "", "", ctx.Err()
+		}
+	}
+	f := &flight{done: make(chan struct{})}
+	if g.calls == nil {
+		g.calls = map[string]*flight{}
+	}
+	g.calls[key] = f
+	g.mu.Unlock()
+
+	returned := false
+	defer func() {
+		if !returned {
+			f.err = errFlightPanicked
+		}
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(f.done)
+	}()
+	f.val, f.source, f.err = fn()
+	f.cancelled = f.err != nil && ctx.Err() != nil
+	returned = true
+	return f.val, f.source, f.err
+}
@@ -0,0 +1,128 @@
+package jenv_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestConcurrentLookupsShareOneCall(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	jenv.RegisterSecretStore("flighttest", jenv.SecretStoreFunc(func(ctx context.Context, ref string) (string, error) {
+		calls.Add(1)
+		<-release
+		return "value-of-" + ref, nil
+	}))
+	defer jenv.UnregisterSecretStore("flighttest")
+
+	type worker struct {
+		Token string `json:"token"`
+	}
+	const workers = 8
+	var wg sync.WaitGroup
+	results := make([]worker, workers)
+	errs := make([]error, workers)
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = jenv.UnmarshalJSON([]byte(`{"token": "${flighttest:api/token}"}`), &results[i])
+		}()
+	}
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond) // let the other workers join the call
+	close(release)
+	wg.Wait()
+
+	for i := range workers {
+		require.NoError(t, errs[i])
+		assert.Equal(t, "value-of-api/token", results[i].Token)
+	}
+	assert.Equal(t, int32(1), calls.Load(), "concurrent decodes should share one lookup")
+}
+
+func TestSharedLookupOutlivesCancelledCaller(t *testing.T) {
+	var calls atomic.Int32
+	jenv.RegisterSecretStore("flightcancel", jenv.SecretStoreFunc(func(ctx context.Context, ref string) (string, error) {
+		if calls.Add(1) == 1 {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return "value-of-" + ref, nil
+	}))
+	defer jenv.UnregisterSecretStore("flightcancel")
+
+	type worker struct {
+		Token string `json:"token"`
+	}
+	doc := []byte(`{"token": "${flightcancel:api/token}"}`)
+	var leaderErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		leaderErr = jenv.UnmarshalJSON(doc, &worker{}, jenv.WithResolveTimeout(100*time.Millisecond))
+	}()
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	var w worker
+	require.NoError(t, jenv.UnmarshalJSON(doc, &w), "the leader's timeout should not fail the waiter")
+	assert.Equal(t, "value-of-api/token", w.Token)
+	wg.Wait()
+	assert.Error(t, leaderErr)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestSharedLookupPanic(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	jenv.RegisterSecretStore("flightpanic", jenv.SecretStoreFunc(func(ctx context.Context, ref string) (string, error) {
+		if calls.Add(1) == 1 {
+			<-release
+			panic("store bug")
+		}
+		return "value-of-" + ref, nil
+	}))
+	defer jenv.UnregisterSecretStore("flightpanic")
+
+	type worker struct {
+		Token string `json:"token"`
+	}
+	doc := []byte(`{"token": "${flightpanic:api/token}"}`)
+	var recovered any
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer func() { recovered = recover() }()
+		jenv.UnmarshalJSON(doc, &worker{})
+	}()
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	var waiterErr error
+	go func() {
+		defer wg.Done()
+		waiterErr = jenv.UnmarshalJSON(doc, &worker{}, jenv.WithResolveTimeout(time.Second))
+	}()
+	time.Sleep(50 * time.Millisecond) // let the waiter join the call
+	close(release)
+	wg.Wait()
+	assert.Equal(t, "store bug", recovered, "the panic should reach the caller that made the call")
+	assert.ErrorContains(t, waiterErr, "secret store panicked")
+
+	var w worker
+	require.NoError(t, jenv.UnmarshalJSON(doc, &w, jenv.WithResolveTimeout(time.Second)))
+	assert.Equal(t, "value-of-api/token", w.Token)
+}
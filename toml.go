@@ -0,0 +1,51 @@
+package jenv
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// UnmarshalTOML decodes a TOML document into cfg, resolving placeholders
+// as UnmarshalJSON and UnmarshalYAML do.
+func UnmarshalTOML(tomlData []byte, cfg any, opts ...Option) error {
+	return unmarshalFormat("toml", tomlData, cfg, opts...)
+}
+
+func parseTOML(_ *decoder, data []byte) (map[string]any, error) {
+	var rawMap map[string]any
+	if err := toml.Unmarshal(data, &rawMap); err != nil {
+		return nil, err
+	}
+	return normalizeTOML(rawMap).(map[string]any), nil
+}
+
+// normalizeTOML converts the values the TOML parser produces into the
+// shapes the JSON and YAML parsers produce: arrays of tables become []any,
+// and dates and times become strings in their TOML form.
+func normalizeTOML(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = normalizeTOML(item)
+		}
+		return v
+	case []map[string]any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = normalizeTOML(item)
+		}
+		return out
+	case []any:
+		for i, item := range v {
+			v[i] = normalizeTOML(item)
+		}
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	}
+	return v
+}
@@ -0,0 +1,69 @@
+package jenv_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestUnmarshalTOML(t *testing.T) {
+	t.Setenv("TOML_DB_HOST", "db.internal")
+	doc := []byte(`
+name = "api"
+timeout = "${TOML_TIMEOUT:30s}"
+started = 2024-01-02T03:04:05Z
+ratio = 0.5
+
+[database]
+host = "${TOML_DB_HOST:localhost}"
+port = 5432
+
+[[upstreams]]
+url = "http://a"
+weight = 1
+
+[[upstreams]]
+url = "http://b"
+weight = 2
+`)
+	type config struct {
+		Name     string        `json:"name"`
+		Timeout  time.Duration `json:"timeout"`
+		Started  time.Time     `json:"started"`
+		Ratio    float64       `json:"ratio"`
+		Database struct {
+			Host string `json:"host"`
+			Port int    `json:"port"`
+		} `json:"database"`
+		Upstreams []struct {
+			URL    string `json:"url"`
+			Weight int    `json:"weight"`
+		} `json:"upstreams"`
+	}
+	var cfg config
+	require.NoError(t, jenv.UnmarshalTOML(doc, &cfg))
+	assert.Equal(t, "api", cfg.Name)
+	assert.Equal(t, 30*time.Second, cfg.Timeout)
+	assert.True(t, cfg.Started.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+	assert.Equal(t, 0.5, cfg.Ratio)
+	assert.Equal(t, "db.internal", cfg.Database.Host)
+	assert.Equal(t, 5432, cfg.Database.Port)
+	require.Len(t, cfg.Upstreams, 2)
+	assert.Equal(t, "http://b", cfg.Upstreams[1].URL)
+	assert.Equal(t, 2, cfg.Upstreams[1].Weight)
+
+	path := filepath.Join(t.TempDir(), "app.toml")
+	require.NoError(t, os.WriteFile(path, doc, 0o644))
+	var loaded config
+	require.NoError(t, jenv.Load(context.Background(), path, &loaded))
+	assert.Equal(t, cfg, loaded)
+
+	assert.ErrorContains(t, jenv.UnmarshalTOML([]byte("a = "), &cfg), "error unmarshalling toml")
+}
@@ -0,0 +1,107 @@
+package jenv
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// union describes how mappings decode into an interface type: the value
+// of the discriminator key names the concrete type.
+type union struct {
+	key      string
+	variants map[string]reflect.Type
+}
+
+var (
+	unionsMu sync.RWMutex
+	unions   = map[reflect.Type]union{}
+)
+
+// RegisterUnion makes fields of the interface type iface decode mappings
+// into one of variants, chosen by the value of the discriminator key:
+//
+//	jenv.RegisterUnion(reflect.TypeOf((*Notifier)(nil)).Elem(), "type", map[string]reflect.Type{
+//		"slack": reflect.TypeOf(&SlackNotifier{}),
+//		"email": reflect.TypeOf(&EmailNotifier{}),
+//	})
+//
+// A document then writes `{type: slack, webhook: ...}` for a Notifier. The
+// mapping, discriminator included, populates a new value of the variant's
+// type, which may be a struct or a pointer to one. It panics unless iface
+// is an interface type implemented by every variant.
+func RegisterUnion(iface reflect.Type, key string, variants map[string]reflect.Type) {
+	u := newUnion(iface, key, variants)
+	unionsMu.Lock()
+	defer unionsMu.Unlock()
+	unions[iface] = u
+}
+
+// WithUnion decodes fields of the interface type iface as RegisterUnion
+// does, for a single decode.
+func WithUnion(iface reflect.Type, key string, variants map[string]reflect.Type) Option {
+	u := newUnion(iface, key, variants)
+	return func(o *options) {
+		if o.unions == nil {
+			o.unions = map[reflect.Type]union{}
+		}
+		o.unions[iface] = u
+	}
+}
+
+func newUnion(iface reflect.Type, key string, variants map[string]reflect.Type) union {
+	if iface == nil || iface.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("jenv: union type %v is not an interface", iface))
+	}
+	if key == "" {
+		panic("jenv: union discriminator key is empty")
+	}
+	for name, typ := range variants {
+		if typ == nil || nestedStruct(typ) == nil || !typ.Implements(iface) {
+			panic(fmt.Sprintf("jenv: union variant %q (%v) is not a struct implementing %v", name, typ, iface))
+		}
+	}
+	return union{key: key, variants: maps.Clone(variants)}
+}
+
+// unionFor returns the union registered for the interface type typ, per
+// decode or globally.
+func (d *decoder) unionFor(typ reflect.Type) (union, bool) {
+	if u, ok := d.unions[typ]; ok {
+		return u, true
+	}
+	unionsMu.RLock()
+	defer unionsMu.RUnlock()
+	u, ok := unions[typ]
+	return u, ok
+}
+
+// decodeUnion stores in field a value of the variant named by rawValue's
+// discriminator, populated from rawValue.
+func (d *decoder) decodeUnion(field reflect.Value, u union, rawValue any, path string) error {
+	rawMap, ok := rawValue.(map[string]any)
+	if !ok {
+		return fmt.Errorf("expected mapping with '%s' for field, got %T", u.key, rawValue)
+	}
+	disc, ok := rawMap[u.key]
+	if !ok {
+		return fmt.Errorf("missing '%s'", u.key)
+	}
+	name, err := d.getEnv(disc, joinPath(path, u.key))
+	if err != nil {
+		return err
+	}
+	typ, ok := u.variants[name]
+	if !ok {
+		return fmt.Errorf("unknown %s %q, expected one of %s", u.key, name, strings.Join(slices.Sorted(maps.Keys(u.variants)), ", "))
+	}
+	v := reflect.New(typ).Elem()
+	if err := d.setFieldValue(v, rawMap, path, ""); err != nil {
+		return err
+	}
+	field.Set(v)
+	return nil
+}
@@ -0,0 +1,66 @@
+package jenv_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+type notifier interface {
+	Notify(msg string) string
+}
+
+type slackNotifier struct {
+	Webhook string `yaml:"webhook"`
+	Channel string `yaml:"channel" default:"#alerts"`
+}
+
+func (s *slackNotifier) Notify(msg string) string { return s.Channel + ": " + msg }
+
+type emailNotifier struct {
+	Kind string   `yaml:"kind"`
+	To   []string `yaml:"to"`
+}
+
+func (e emailNotifier) Notify(msg string) string { return e.To[0] + ": " + msg }
+
+func TestUnionFields(t *testing.T) {
+	t.Setenv("UNION_WEBHOOK", "https://hooks.example.com/x")
+	notifierType := reflect.TypeOf((*notifier)(nil)).Elem()
+	withNotifiers := jenv.WithUnion(notifierType, "kind", map[string]reflect.Type{
+		"slack": reflect.TypeOf(&slackNotifier{}),
+		"email": reflect.TypeOf(emailNotifier{}),
+	})
+	type config struct {
+		Primary notifier            `yaml:"primary"`
+		Others  []notifier          `yaml:"others"`
+		ByTeam  map[string]notifier `yaml:"by_team"`
+	}
+	var cfg config
+	require.NoError(t, jenv.UnmarshalYAML([]byte(`
+primary:
+  kind: slack
+  webhook: "${UNION_WEBHOOK}"
+others:
+  - kind: email
+    to: [ops@example.com]
+by_team:
+  payments: {kind: "${PAYMENTS_NOTIFIER:slack}", channel: "#payments"}
+`), &cfg, withNotifiers))
+	assert.Equal(t, &slackNotifier{Webhook: "https://hooks.example.com/x", Channel: "#alerts"}, cfg.Primary)
+	assert.Equal(t, []notifier{emailNotifier{Kind: "email", To: []string{"ops@example.com"}}}, cfg.Others)
+	assert.Equal(t, "#payments: down", cfg.ByTeam["payments"].Notify("down"))
+
+	err := jenv.UnmarshalYAML([]byte("primary: {kind: pager}\n"), &cfg, withNotifiers)
+	assert.ErrorContains(t, err, `error decoding 'primary': unknown kind "pager", expected one of email, slack`)
+	err = jenv.UnmarshalYAML([]byte("primary: {webhook: x}\n"), &cfg, withNotifiers)
+	assert.ErrorContains(t, err, "error decoding 'primary': missing 'kind'")
+
+	assert.Panics(t, func() {
+		jenv.RegisterUnion(notifierType, "kind", map[string]reflect.Type{"slack": reflect.TypeOf(slackNotifier{})})
+	}, "only *slackNotifier implements notifier")
+}
@@ -0,0 +1,290 @@
+package jenv
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RuleError is the cause of a FieldError for a value that breaks a rule
+// of its field's `validate` tag, such as `validate:"min=1,max=65535"`.
+type RuleError struct {
+	// Rule is the rule as written in the tag, such as "max=65535".
+	Rule    string
+	Message string
+}
+
+func (e *RuleError) Error() string {
+	return fmt.Sprintf("%s (%s)", e.Message, e.Rule)
+}
+
+// Validator is implemented by configuration structs with rules that span
+// fields, such as a TLS certificate and key that must be set together.
+// Validate is called once the struct has been decoded and its `validate`
+// tags checked, after the Validate methods of the structs inside it.
+type Validator interface {
+	Validate() error
+}
+
+// validateFields checks the `validate` tags of cfg, decoded from the key
+// at path, once every field has been decoded and computed. Every
+// violation is reported, in declaration order, as a *FieldError in a
+// *DecodeErrors. The rules, separated by commas, are:
+//
+//	nonzero        the value is not its type's zero value (also "required")
+//	omitempty      skip the remaining rules when the value is zero
+//	min=N, max=N   bounds for numbers, and for the length of strings,
+//	               slices and maps; durations are bounded by a duration
+//	               such as "1s", byte sizes by a size such as "1MiB"
+//	len=N          the exact length of a string, slice or map
+//	oneof=a b c    the value, as written, is one of the listed words
+//	url            an absolute URL, with a scheme and a host
+//	hostname       a host name or IP address
+//	hostname_port  a host and port, such as "db:5432" or ":8080"
+//	email          a bare email address
+//
+// Structs nested in fields, pointers, slices and maps are checked too,
+// and any of them that is a Validator, cfg included, is validated.
+func (d *decoder) validateFields(cfg any, path string) error {
+	var errs []error
+	d.walkNested(reflect.ValueOf(cfg).Elem(), path, func(field reflect.StructField, fv reflect.Value, path string) bool {
+		rules, ok := field.Tag.Lookup("validate")
+		if !ok {
+			return true
+		}
+		if err := validateValue(fv, rules); err != nil {
+			// Got is left out: the value may have come from a secret
+			// store, and errors end up in logs.
+			errs = append(errs, &FieldError{Path: path, Expected: fv.Type().String(), Cause: err})
+			return false
+		}
+		return true
+	}, func(val reflect.Value, path string) {
+		if v, ok := validator(val); ok {
+			if err := v.Validate(); err != nil {
+				if path != "" {
+					err = &FieldError{Path: path, Expected: val.Type().String(), Cause: err}
+				}
+				errs = append(errs, err)
+			}
+		}
+	})
+	if len(errs) > 0 {
+		return &DecodeErrors{Errors: errs}
+	}
+	return nil
+}
+
+// validator returns val as a Validator, by pointer when it can.
+func validator(val reflect.Value) (Validator, bool) {
+	if val.CanAddr() {
+		if v, ok := val.Addr().Interface().(Validator); ok {
+			return v, true
+		}
+	}
+	v, ok := val.Interface().(Validator)
+	return v, ok
+}
+
+// walkStructs visits val and every struct nested in its fields, through
+// pointers, slices and maps, in declaration order. visit is called on each
+// field before the structs inside it, which are skipped when it returns
+// false, and leave is called on a struct after its fields; either may be
+// nil.
+func (d *decoder) walkStructs(val reflect.Value, path string, visit func(field reflect.StructField, fv reflect.Value, path string) bool, leave func(val reflect.Value, path string)) {
+	for _, field := range structFields(val.Type(), d.tagOrder) {
+		fv, ok := fieldByIndexRead(val, field.Index)
+		if !ok {
+			continue
+		}
+		fieldPath := joinPath(path, fieldKey(field, d.tagOrder))
+		if visit == nil || visit(field, fv, fieldPath) {
+			d.walkNested(fv, fieldPath, visit, leave)
+		}
+	}
+	if leave != nil {
+		leave(val, path)
+	}
+}
+
+func (d *decoder) walkNested(v reflect.Value, path string, visit func(field reflect.StructField, fv reflect.Value, path string) bool, leave func(val reflect.Value, path string)) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() != reflect.TypeOf(time.Time{}) {
+			d.walkStructs(v, path, visit, leave)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			d.walkNested(v.Index(i), fmt.Sprintf("%s[%d]", path, i), visit, leave)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+		})
+		for _, k := range keys {
+			d.walkNested(v.MapIndex(k), joinPath(path, fmt.Sprint(k.Interface())), visit, leave)
+		}
+	}
+}
+
+// validateValue checks v against a `validate` tag, stopping at the first
+// rule it breaks.
+func validateValue(v reflect.Value, rules string) error {
+	for _, rule := range strings.Split(rules, ",") {
+		rule = strings.TrimSpace(rule)
+		name, arg, _ := strings.Cut(rule, "=")
+		if name == "omitempty" {
+			if v.IsZero() {
+				return nil
+			}
+			continue
+		}
+		if rule == "" {
+			continue
+		}
+		if msg := checkRule(v, name, arg); msg != "" {
+			return &RuleError{Rule: rule, Message: msg}
+		}
+	}
+	return nil
+}
+
+// checkRule returns why v breaks the rule, or "" when it holds.
+func checkRule(v reflect.Value, name, arg string) string {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			if name == "nonzero" || name == "required" {
+				return "must be set"
+			}
+			return ""
+		}
+		v = v.Elem()
+	}
+	switch name {
+	case "nonzero", "required":
+		if v.IsZero() {
+			return "must be set"
+		}
+	case "min", "max", "len":
+		return checkBound(v, name, arg)
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		if !slices.Contains(strings.Fields(arg), s) {
+			return fmt.Sprintf("value is not one of %s", strings.Join(strings.Fields(arg), ", "))
+		}
+	case "url", "hostname", "hostname_port", "email":
+		if v.Kind() != reflect.String {
+			return fmt.Sprintf("%s applies to strings, not %s", name, v.Type())
+		}
+		return checkFormat(v.String(), name)
+	default:
+		return fmt.Sprintf("unknown validation rule %q", name)
+	}
+	return ""
+}
+
+// checkBound checks a min, max or len rule. Lengths are shown in the
+// message, but values are not, as they may be secrets.
+func checkBound(v reflect.Value, name, arg string) string {
+	var got, limit float64
+	var show func(float64) string
+	subject := func(float64) string { return "value" }
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := parseDuration(arg)
+		if err != nil {
+			return fmt.Sprintf("invalid %s %q: %v", name, arg, err)
+		}
+		got, limit = float64(v.Int()), float64(d)
+		show = func(f float64) string { return time.Duration(f).String() }
+	case v.Type() == reflect.TypeOf(ByteSize(0)):
+		size, err := ParseByteSize(arg)
+		if err != nil {
+			return fmt.Sprintf("invalid %s %q: %v", name, arg, err)
+		}
+		got, limit = float64(v.Int()), float64(size)
+		show = func(f float64) string { return ByteSize(f).String() }
+	default:
+		n, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Sprintf("invalid %s %q", name, arg)
+		}
+		limit = n
+		show = func(f float64) string { return strconv.FormatFloat(f, 'g', -1, 64) }
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			got = float64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			got = float64(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			got = v.Float()
+		case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+			got = float64(v.Len())
+			show = func(f float64) string { return strconv.FormatFloat(f, 'g', -1, 64) + " items" }
+			if v.Kind() == reflect.String {
+				got = float64(len([]rune(v.String())))
+				show = func(f float64) string { return strconv.FormatFloat(f, 'g', -1, 64) + " characters" }
+			}
+			subject = show
+		default:
+			return fmt.Sprintf("%s does not apply to %s", name, v.Type())
+		}
+	}
+	switch {
+	case name == "min" && got < limit:
+		return fmt.Sprintf("%s is less than %s", subject(got), show(limit))
+	case name == "max" && got > limit:
+		return fmt.Sprintf("%s is more than %s", subject(got), show(limit))
+	case name == "len" && got != limit:
+		return fmt.Sprintf("%s, want %s", subject(got), show(limit))
+	}
+	return ""
+}
+
+// checkFormat checks a string rule.
+func checkFormat(s, name string) string {
+	var err error
+	switch name {
+	case "url":
+		_, err = ParseURL(s)
+	case "hostname":
+		_, err = ParseHostname(s)
+	case "email":
+		_, err = ParseEmail(s)
+	case "hostname_port":
+		err = checkHostPort(s)
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// checkHostPort checks for a host and port; the host may be empty, as in
+// ":8080", for every interface.
+func checkHostPort(s string) error {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		return fmt.Errorf("invalid host and port %q", s)
+	}
+	if n, err := strconv.ParseUint(port, 10, 16); err != nil || n == 0 {
+		return fmt.Errorf("invalid port %q in %q", port, s)
+	}
+	if host != "" {
+		if _, err := ParseHostname(host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package jenv_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestValidateTag(t *testing.T) {
+	type backend struct {
+		Addr string `yaml:"addr" validate:"hostname_port"`
+	}
+	type config struct {
+		Env      string        `yaml:"env" validate:"oneof=dev staging prod"`
+		Port     int           `yaml:"port" validate:"min=1,max=65535"`
+		Name     string        `yaml:"name" validate:"nonzero"`
+		Docs     string        `yaml:"docs" validate:"omitempty,url"`
+		Timeout  time.Duration `yaml:"timeout" validate:"max=1m"`
+		Cache    jenv.ByteSize `yaml:"cache" validate:"min=1MiB"`
+		Tags     []string      `yaml:"tags" validate:"max=2"`
+		Backends []backend     `yaml:"backends"`
+	}
+	var cfg config
+	require.NoError(t, jenv.UnmarshalYAML([]byte(`
+env: prod
+port: 8080
+name: api
+timeout: 30s
+cache: 64MiB
+tags: [a, b]
+backends:
+  - addr: "db:5432"
+  - addr: ":8080"
+`), &cfg))
+
+	cfg = config{}
+	err := jenv.UnmarshalYAML([]byte(`
+env: qa
+port: 70000
+docs: not a url
+timeout: 2m
+cache: 1KiB
+tags: [a, b, c]
+backends:
+  - addr: "db:5432"
+  - addr: "db"
+`), &cfg)
+	var errs *jenv.DecodeErrors
+	require.ErrorAs(t, err, &errs)
+	var paths []string
+	for _, err := range errs.Errors {
+		var fe *jenv.FieldError
+		require.True(t, errors.As(err, &fe))
+		paths = append(paths, fe.Path)
+	}
+	assert.Equal(t, []string{"env", "port", "name", "docs", "timeout", "cache", "tags", "backends[1].addr"}, paths)
+	assert.Contains(t, err.Error(), "env: value is not one of dev, staging, prod (oneof=dev staging prod)\n")
+	assert.Contains(t, err.Error(), "port: value is more than 65535 (max=65535)")
+	assert.Contains(t, err.Error(), "timeout: value is more than 1m0s (max=1m)")
+	assert.Contains(t, err.Error(), "tags: 3 items is more than 2 items (max=2)")
+
+	var rule *jenv.RuleError
+	require.ErrorAs(t, errs.Errors[2], &rule)
+	assert.Equal(t, "nonzero", rule.Rule)
+}
+
+type validatedTLS struct {
+	Cert string `yaml:"cert"`
+	Key  string `yaml:"key"`
+}
+
+func (t validatedTLS) Validate() error {
+	if (t.Cert == "") != (t.Key == "") {
+		return errors.New("cert and key must both be set")
+	}
+	return nil
+}
+
+type validatedListener struct {
+	Addr string        `yaml:"addr"`
+	TLS  *validatedTLS `yaml:"tls"`
+}
+
+type validatedServer struct {
+	Listeners []validatedListener `yaml:"listeners"`
+	Admin     validatedTLS        `yaml:"admin"`
+	Port      int                 `yaml:"port" validate:"min=1"`
+	calls     *[]string
+}
+
+func (s *validatedServer) Validate() error {
+	*s.calls = append(*s.calls, "server")
+	if len(s.Listeners) == 0 {
+		return errors.New("at least one listener is required")
+	}
+	return nil
+}
+
+func TestValidateHidesValues(t *testing.T) {
+	t.Setenv("VALIDATE_DB_PASS", "hunter2")
+	var cfg struct {
+		Password string `json:"password" validate:"min=12"`
+		Mode     string `json:"mode" validate:"oneof=a b"`
+	}
+	err := jenv.UnmarshalYAML([]byte("password: ${VALIDATE_DB_PASS}\nmode: ${VALIDATE_DB_PASS}\n"), &cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "password: 7 characters is less than 12 characters (min=12)")
+	assert.NotContains(t, err.Error(), "hunter2")
+	var fe *jenv.FieldError
+	require.ErrorAs(t, err, &fe)
+	assert.Nil(t, fe.Got)
+}
+
+func TestValidatorInterface(t *testing.T) {
+	var calls []string
+	cfg := validatedServer{calls: &calls}
+	require.NoError(t, jenv.UnmarshalYAML([]byte(`
+port: 80
+listeners:
+  - addr: ":80"
+  - addr: ":443"
+    tls: {cert: a.pem, key: a.key}
+`), &cfg))
+	assert.Equal(t, []string{"server"}, calls)
+
+	cfg = validatedServer{calls: &calls}
+	err := jenv.UnmarshalYAML([]byte(`
+listeners:
+  - addr: ":443"
+    tls: {cert: a.pem}
+admin: {key: admin.key}
+`), &cfg)
+	var errs *jenv.DecodeErrors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs.Errors, 3)
+	assert.EqualError(t, errs.Errors[0], "error decoding 'listeners[0].tls': cert and key must both be set")
+	assert.EqualError(t, errs.Errors[1], "error decoding 'admin': cert and key must both be set")
+	assert.EqualError(t, errs.Errors[2], "error decoding 'port': value is less than 1 (min=1)")
+
+	cfg = validatedServer{calls: &calls}
+	err = jenv.UnmarshalYAML([]byte("port: 1\n"), &cfg)
+	assert.EqualError(t, err, "1 field failed to decode:\n\tat least one listener is required")
+}
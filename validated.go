@@ -0,0 +1,104 @@
+package jenv
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+// Email is an email address validated when the configuration is decoded.
+// Only a bare address is accepted, not one with a display name.
+type Email string
+
+// Hostname is a DNS host name (RFC 1123) or IP address validated when the
+// configuration is decoded.
+type Hostname string
+
+// URL is an absolute URL validated when the configuration is decoded: it
+// must have a scheme and a host.
+type URL struct {
+	url.URL
+}
+
+func init() {
+	registerConverter(ParseEmail)
+	registerConverter(ParseHostname)
+	registerConverter(ParseURL)
+}
+
+// ParseEmail validates s as an email address.
+func ParseEmail(s string) (Email, error) {
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return "", fmt.Errorf("invalid email address %q", s)
+	}
+	return Email(s), nil
+}
+
+// Domain returns the part of the address after the @.
+func (e Email) Domain() string {
+	return string(e[strings.LastIndex(string(e), "@")+1:])
+}
+
+// ParseHostname validates s as a host name or IP address.
+func ParseHostname(s string) (Hostname, error) {
+	if net.ParseIP(s) != nil {
+		return Hostname(s), nil
+	}
+	name := strings.TrimSuffix(s, ".")
+	if name == "" || len(name) > 253 {
+		return "", fmt.Errorf("invalid hostname %q", s)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if !validLabel(label) {
+			return "", fmt.Errorf("invalid hostname %q: bad label %q", s, label)
+		}
+	}
+	return Hostname(s), nil
+}
+
+func validLabel(label string) bool {
+	if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+	for _, c := range label {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseURL validates s as an absolute URL.
+func ParseURL(s string) (URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return URL{}, fmt.Errorf("invalid URL %q: %w", s, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return URL{}, fmt.Errorf("invalid URL %q: scheme and host are required", s)
+	}
+	return URL{URL: *u}, nil
+}
+
+// String returns the URL in its textual form.
+func (u URL) String() string {
+	return u.URL.String()
+}
+
+// MarshalText returns the URL in its textual form.
+func (u URL) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText parses and validates an absolute URL.
+func (u *URL) UnmarshalText(text []byte) error {
+	parsed, err := ParseURL(string(text))
+	if err != nil {
+		return err
+	}
+	*u = parsed
+	return nil
+}
@@ -0,0 +1,47 @@
+package jenv_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestValidatedTypes(t *testing.T) {
+	type notify struct {
+		From     jenv.Email    `yaml:"from"`
+		SMTPHost jenv.Hostname `yaml:"smtp_host"`
+		Webhook  *jenv.URL     `yaml:"webhook"`
+	}
+	t.Setenv("NOTIFY_FROM", "alerts@example.com")
+	var cfg notify
+	require.NoError(t, jenv.UnmarshalYAML([]byte(`
+from: "${NOTIFY_FROM}"
+smtp_host: smtp.example.com
+webhook: https://hooks.example.com/notify?channel=ops
+`), &cfg))
+	assert.Equal(t, jenv.Email("alerts@example.com"), cfg.From)
+	assert.Equal(t, "example.com", cfg.From.Domain())
+	assert.Equal(t, jenv.Hostname("smtp.example.com"), cfg.SMTPHost)
+	assert.Equal(t, "hooks.example.com", cfg.Webhook.Hostname())
+	assert.Equal(t, "https://hooks.example.com/notify?channel=ops", cfg.Webhook.String())
+
+	tests := map[string]string{
+		"from: not-an-email":        `error decoding 'from': invalid email address "not-an-email"`,
+		"from: Ops <ops@x.com>":     `error decoding 'from': invalid email address "Ops <ops@x.com>"`,
+		"smtp_host: -bad.example":   `error decoding 'smtp_host': invalid hostname "-bad.example": bad label "-bad"`,
+		"smtp_host: under_score.io": `error decoding 'smtp_host': invalid hostname "under_score.io"`,
+		"webhook: /relative/path":   `error decoding 'webhook': invalid URL "/relative/path": scheme and host are required`,
+	}
+	for doc, want := range tests {
+		err := jenv.UnmarshalYAML([]byte(doc), &notify{})
+		assert.ErrorContains(t, err, want, doc)
+	}
+
+	for _, host := range []string{"10.0.0.1", "::1", "localhost", "a-b.example.com."} {
+		_, err := jenv.ParseHostname(host)
+		assert.NoError(t, err, host)
+	}
+}
@@ -0,0 +1,156 @@
+package jenv
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// varsKey is the top-level document key holding in-document variables.
+const varsKey = "vars"
+
+var varRef = regexp.MustCompile(`\$\{vars\.([A-Za-z0-9_.\[\]-]+)\}`)
+
+// expandVars replaces `${vars.name}` references throughout the document
+// with entries of its top-level `vars:` block. Variable values have their
+// own placeholders resolved first, so a variable can wrap an env var. A
+// reference making up a whole value keeps the variable's type; references
+// inside a longer string are interpolated.
+func (d *decoder) expandVars(rawMap map[string]any) error {
+	vars, ok := rawMap[varsKey].(map[string]any)
+	if !ok {
+		return nil
+	}
+	if err := d.resolveVars(vars, varsKey); err != nil {
+		return err
+	}
+	for k, v := range rawMap {
+		if k == varsKey {
+			continue
+		}
+		expanded, err := d.substituteVars(v, vars, k)
+		if err != nil {
+			return err
+		}
+		rawMap[k] = expanded
+	}
+	return nil
+}
+
+func (d *decoder) resolveVars(vars map[string]any, path string) error {
+	for k, v := range vars {
+		switch v := v.(type) {
+		case string:
+			d.secretResolved = false
+			val, err := d.getEnv(v, joinPath(path, k))
+			if err != nil {
+				return err
+			}
+			vars[k] = val
+			if d.secretResolved {
+				if d.secretVars == nil {
+					d.secretVars = map[string]bool{}
+				}
+				d.secretVars[joinPath(path, k)] = true
+			}
+		case map[string]any:
+			if err := d.resolveVars(v, joinPath(path, k)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// substituteVars replaces the variable references in value, found at path
+// in the document.
+func (d *decoder) substituteVars(value any, vars map[string]any, path string) (any, error) {
+	switch v := value.(type) {
+	case string:
+		var matches [][]int
+		for _, m := range varRef.FindAllStringSubmatchIndex(v, -1) {
+			if m[0] == 0 || v[m[0]-1] != '$' {
+				matches = append(matches, m)
+			}
+		}
+		if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(v) {
+			name := v[matches[0][2]:matches[0][3]]
+			val, err := lookupVar(vars, name)
+			if err == nil {
+				d.markSecretVars(name, path, true)
+			}
+			return val, err
+		}
+		var b strings.Builder
+		last := 0
+		for _, m := range matches {
+			val, err := lookupVar(vars, v[m[2]:m[3]])
+			if err != nil {
+				return nil, err
+			}
+			d.markSecretVars(v[m[2]:m[3]], path, false)
+			b.WriteString(v[last:m[0]])
+			fmt.Fprintf(&b, "%v", val)
+			last = m[1]
+		}
+		b.WriteString(v[last:])
+		return b.String(), nil
+	case map[string]any:
+		for k, child := range v {
+			expanded, err := d.substituteVars(child, vars, joinPath(path, k))
+			if err != nil {
+				return nil, err
+			}
+			v[k] = expanded
+		}
+	case []any:
+		for i, child := range v {
+			expanded, err := d.substituteVars(child, vars, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+	}
+	return value, nil
+}
+
+// markSecretVars records where the secrets among the variable name end up
+// once it is substituted at path: at the same place inside the value when
+// it is the whole value, and at path itself when it is interpolated.
+func (d *decoder) markSecretVars(name, path string, whole bool) {
+	segments, err := parsePath(name)
+	if err != nil {
+		return
+	}
+	name = varsKey
+	for _, seg := range segments {
+		name = seg.String(name)
+	}
+	for secret := range d.secretVars {
+		rest, ok := strings.CutPrefix(secret, name)
+		if !ok || rest != "" && rest[0] != '.' {
+			continue
+		}
+		if d.secretPaths == nil {
+			d.secretPaths = map[string]bool{}
+		}
+		if whole {
+			d.secretPaths[path+rest] = true
+		} else {
+			d.secretPaths[path] = true
+		}
+	}
+}
+
+func lookupVar(vars map[string]any, name string) (any, error) {
+	segments, err := parsePath(name)
+	if err != nil {
+		return nil, fmt.Errorf("undefined variable 'vars.%s'", name)
+	}
+	value, ok := getPath(vars, segments, false)
+	if !ok {
+		return nil, fmt.Errorf("undefined variable 'vars.%s'", name)
+	}
+	return value, nil
+}
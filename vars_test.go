@@ -0,0 +1,49 @@
+package jenv_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestDocumentVars(t *testing.T) {
+	t.Setenv("BASE_HOST", "api.example.com")
+	type endpoints struct {
+		Health  string   `yaml:"health"`
+		Metrics string   `yaml:"metrics"`
+		Port    int      `yaml:"port"`
+		Hosts   []string `yaml:"hosts"`
+		Region  string   `yaml:"region"`
+	}
+	doc := []byte(`
+vars:
+  base_host: "${BASE_HOST:localhost}"
+  port: 8443
+  hosts: [a, b]
+  geo:
+    region: eu-west-1
+health: "https://${vars.base_host}/healthz"
+metrics: "https://${vars.base_host}:${vars.port}/metrics"
+port: "${vars.port}"
+hosts: "${vars.hosts}"
+region: "${vars.geo.region}"
+`)
+	var cfg endpoints
+	assert.NoError(t, jenv.UnmarshalYAML(doc, &cfg))
+	assert.Equal(t, endpoints{
+		Health:  "https://api.example.com/healthz",
+		Metrics: "https://api.example.com:8443/metrics",
+		Port:    8443,
+		Hosts:   []string{"a", "b"},
+		Region:  "eu-west-1",
+	}, cfg)
+
+	cfg = endpoints{}
+	assert.NoError(t, jenv.UnmarshalYAML([]byte("vars: {hosts: [a, b]}\nhealth: \"${vars.hosts[1]}\""), &cfg))
+	assert.Equal(t, "b", cfg.Health)
+
+	err := jenv.UnmarshalYAML([]byte("vars: {}\nhealth: \"${vars.missing}\""), &cfg)
+	assert.ErrorContains(t, err, "undefined variable 'vars.missing'")
+}
@@ -0,0 +1,169 @@
+package jenv
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VaultStore is a SecretStore reading HashiCorp Vault's KV secrets engine.
+// It is not registered by default:
+//
+//	jenv.RegisterSecretStore("vault", &jenv.VaultStore{Mount: "kv"})
+//
+// after which `${vault:path/to/secret#field}` reads field of the secret at
+// path. The field may be left out when the secret holds a single key.
+//
+// Empty fields fall back to the variables used by the Vault CLI:
+// VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE, plus VAULT_ROLE_ID and
+// VAULT_SECRET_ID for AppRole login. A token is used as is; otherwise the
+// store logs in with the role and secret IDs and reuses the token until
+// its lease is about to expire.
+type VaultStore struct {
+	// Address is the Vault server, by default https://127.0.0.1:8200.
+	Address string
+	// Token authenticates requests directly.
+	Token string
+	// RoleID and SecretID log in through the AppRole auth method mounted
+	// at AuthMount, "approle" by default.
+	RoleID, SecretID string
+	AuthMount        string
+	// Mount is where the KV secrets engine is mounted, "secret" by default.
+	Mount string
+	// KVVersion is 1 or 2, the default.
+	KVVersion int
+	// Namespace is sent as X-Vault-Namespace on Vault Enterprise.
+	Namespace string
+	// Client sends the requests, by default with a 30 second timeout.
+	Client *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+var vaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+func (s *VaultStore) Get(ctx context.Context, ref string) (string, error) {
+	path, field, _ := strings.Cut(strings.TrimSpace(ref), "#")
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "", fmt.Errorf("invalid vault reference '%s', expected path#field", ref)
+	}
+	token, err := s.login(ctx)
+	if err != nil {
+		return "", err
+	}
+	mount := strings.Trim(cmp.Or(s.Mount, "secret"), "/")
+	endpoint := "/v1/" + mount + "/data/" + path
+	if s.KVVersion == 1 {
+		endpoint = "/v1/" + mount + "/" + path
+	}
+	var secret struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := s.do(ctx, http.MethodGet, endpoint, token, nil, &secret); err != nil {
+		return "", err
+	}
+	data := secret.Data
+	if s.KVVersion != 1 {
+		data, _ = data["data"].(map[string]any)
+	}
+	if field == "" {
+		if len(data) != 1 {
+			return "", fmt.Errorf("vault secret '%s' has %d keys; name one as '%s#field'", path, len(data), path)
+		}
+		for _, v := range data {
+			return secretString(v)
+		}
+	}
+	v, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("vault field '%s' not found in '%s': %w", field, path, ErrSecretNotFound)
+	}
+	return secretString(v)
+}
+
+// login returns the token to send, logging in with AppRole when no token
+// is configured.
+func (s *VaultStore) login(ctx context.Context) (string, error) {
+	if token := cmp.Or(s.Token, Getenv("VAULT_TOKEN")); token != "" {
+		return token, nil
+	}
+	roleID, secretID := cmp.Or(s.RoleID, Getenv("VAULT_ROLE_ID")), cmp.Or(s.SecretID, Getenv("VAULT_SECRET_ID"))
+	if roleID == "" {
+		return "", fmt.Errorf("vault requires a token (VAULT_TOKEN) or an AppRole role ID (VAULT_ROLE_ID)")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Now().Before(s.expires) {
+		return s.token, nil
+	}
+	body, _ := json.Marshal(map[string]string{"role_id": roleID, "secret_id": secretID})
+	var resp struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	mount := strings.Trim(cmp.Or(s.AuthMount, "approle"), "/")
+	if err := s.do(ctx, http.MethodPost, "/v1/auth/"+mount+"/login", "", body, &resp); err != nil {
+		return "", err
+	}
+	s.token = resp.Auth.ClientToken
+	// Renew a little before the lease runs out.
+	lease := time.Duration(resp.Auth.LeaseDuration) * time.Second
+	s.expires = time.Now().Add(lease - lease/10)
+	return s.token, nil
+}
+
+func (s *VaultStore) do(ctx context.Context, method, path, token string, body []byte, out any) error {
+	addr := strings.TrimSuffix(cmp.Or(s.Address, Getenv("VAULT_ADDR"), "https://127.0.0.1:8200"), "/")
+	req, err := http.NewRequestWithContext(ctx, method, addr+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if ns := cmp.Or(s.Namespace, Getenv("VAULT_NAMESPACE")); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	client := s.Client
+	if client == nil {
+		client = vaultHTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling vault: %v", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return json.NewDecoder(resp.Body).Decode(out)
+	case http.StatusNotFound:
+		return fmt.Errorf("vault path '%s': %w", path, ErrSecretNotFound)
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("vault returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+}
+
+// secretString renders a secret value; values that are not strings are
+// returned as JSON.
+func secretString(v any) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
@@ -0,0 +1,83 @@
+package jenv_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oarkflow/jenv"
+)
+
+func TestVaultStore(t *testing.T) {
+	logins := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/auth/approle/login" {
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			assert.Equal(t, map[string]string{"role_id": "role", "secret_id": "secret"}, body)
+			logins++
+			json.NewEncoder(w).Encode(map[string]any{"auth": map[string]any{"client_token": "approle-token", "lease_duration": 3600}})
+			return
+		}
+		if tok := r.Header.Get("X-Vault-Token"); tok != "root" && tok != "approle-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/kv/data/app/db":
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{
+				"data": map[string]any{"username": "app", "password": "s3cret", "port": 5432},
+			}})
+		case "/v1/kv/data/app/token":
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": map[string]any{"value": "tok"}}})
+		case "/v1/legacy/app/db":
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"password": "old"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	jenv.RegisterSecretStore("tvault", &jenv.VaultStore{Address: srv.URL, Token: "root", Mount: "kv"})
+	defer jenv.UnregisterSecretStore("tvault")
+	jenv.RegisterSecretStore("tvault1", &jenv.VaultStore{Address: srv.URL, Token: "root", Mount: "legacy", KVVersion: 1})
+	defer jenv.UnregisterSecretStore("tvault1")
+
+	var cfg struct {
+		Password string `json:"password"`
+		Port     int    `json:"port"`
+		Token    string `json:"token"`
+		Legacy   string `json:"legacy"`
+	}
+	err := jenv.UnmarshalJSON([]byte(`{
+		"password": "${tvault:app/db#password}",
+		"port": "${tvault:app/db#port}",
+		"token": "${tvault:app/token}",
+		"legacy": "${tvault1:app/db#password}"
+	}`), &cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", cfg.Password)
+	assert.Equal(t, 5432, cfg.Port)
+	assert.Equal(t, "tok", cfg.Token)
+	assert.Equal(t, "old", cfg.Legacy)
+
+	store := &jenv.VaultStore{Address: srv.URL, RoleID: "role", SecretID: "secret", Mount: "kv"}
+	t.Setenv("VAULT_TOKEN", "")
+	for range 2 {
+		v, err := store.Get(t.Context(), "app/db#username")
+		require.NoError(t, err)
+		assert.Equal(t, "app", v)
+	}
+	assert.Equal(t, 1, logins)
+
+	_, err = store.Get(t.Context(), "app/db#missing")
+	assert.ErrorIs(t, err, jenv.ErrSecretNotFound)
+	_, err = store.Get(t.Context(), "app/missing#x")
+	assert.ErrorIs(t, err, jenv.ErrSecretNotFound)
+	_, err = store.Get(t.Context(), "app/db")
+	assert.ErrorContains(t, err, "has 3 keys")
+}